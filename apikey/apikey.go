@@ -0,0 +1,360 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+const (
+	// DefaultPrefix 默认的 API Key 前缀
+	DefaultPrefix = "gsk"
+
+	// idSize Key ID 随机字节数，十六进制编码后为 12 个字符
+	idSize = 6
+
+	// idAttempts 生成 Key ID 的最大尝试次数，随机ID已被占用时重新生成
+	idAttempts = 3
+
+	// secretSize Key 密钥随机字节数
+	secretSize = 32
+
+	// lastUsedInterval 最后使用时间的最小更新间隔，避免每个请求都写存储
+	lastUsedInterval = time.Minute
+)
+
+// APIKey API Key 元数据，存储中只保存完整Key的摘要，最后使用时间单独保存
+type APIKey struct {
+	ID         string    `json:"id"`
+	Prefix     string    `json:"prefix"` // 可公开展示的Key前缀（如 gsk_1a2b3c4d5e6f），用于识别Key
+	Name       string    `json:"name"`
+	UserID     string    `json:"user_id"` // 所有者用户ID，API Key 请求以该用户身份鉴权
+	Scopes     []string  `json:"scopes,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpireTime time.Time `json:"expire_time,omitempty"` // 零值表示永不过期
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+}
+
+// Expired 是否已过期
+func (k *APIKey) Expired() bool {
+	return !k.ExpireTime.IsZero() && time.Now().After(k.ExpireTime)
+}
+
+// public 返回不含摘要的副本
+func (k *APIKey) public() *APIKey {
+	copied := *k
+	copied.Hash = ""
+	return &copied
+}
+
+// CreateRequest 创建 API Key 请求
+type CreateRequest struct {
+	UserID string
+	Name   string
+	Scopes []string      // 授权范围，对应权限标识，"*" 表示所有者的全部权限
+	TTL    time.Duration // 有效期，0 表示永不过期
+}
+
+// Service API Key 服务
+// 完整Key格式为 <prefix>_<id>_<secret>，只在创建与轮换时返回一次；存储中按ID保存元数据与 SHA-256 摘要
+type Service struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	prefix     string
+}
+
+// NewService 创建 API Key 服务，prefix 为空时使用 DefaultPrefix
+func NewService(storage core.Storage, keyService *core.KeyService, prefix string) *Service {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Service{
+		storage:    storage,
+		keyService: keyService,
+		prefix:     prefix,
+	}
+}
+
+// Create 创建 API Key，返回完整Key与元数据
+func (s *Service) Create(ctx context.Context, req *CreateRequest) (string, *APIKey, error) {
+	if req == nil || req.UserID == "" {
+		return "", nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+	if req.TTL < 0 {
+		return "", nil, errors.New(core.ErrMsgAPIKeyTTL)
+	}
+
+	id, err := s.newID(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	info := &APIKey{
+		ID:        id,
+		Prefix:    s.prefix + "_" + id,
+		Name:      req.Name,
+		UserID:    req.UserID,
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+	}
+	if req.TTL > 0 {
+		info.ExpireTime = now.Add(req.TTL)
+	}
+
+	key, err := s.issue(ctx, info)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.storage.Set(ctx, s.keyService.UserAPIKeyKey(info.UserID, info.ID), info.ID, s.ttl(info)); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", core.ErrMsgSaveAPIKey, err)
+	}
+	return key, info.public(), nil
+}
+
+// newID 生成未被占用的 Key ID，避免随机ID碰撞时覆盖其他Key的记录与摘要
+func (s *Service) newID(ctx context.Context) (string, error) {
+	for i := 0; i < idAttempts; i++ {
+		id, err := randomString(idSize, hex.EncodeToString)
+		if err != nil {
+			return "", err
+		}
+		exists, err := s.storage.Exists(ctx, s.keyService.APIKeyKey(id))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateAPIKey, err)
+		}
+		if !exists {
+			return id, nil
+		}
+	}
+	return "", errors.New(core.ErrMsgAPIKeyIDTaken)
+}
+
+// Get 获取 API Key 元数据
+func (s *Service) Get(ctx context.Context, id string) (*APIKey, error) {
+	info, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return info.public(), nil
+}
+
+// List 列出用户的 API Key，按创建时间排序
+func (s *Service) List(ctx context.Context, userID string) ([]*APIKey, error) {
+	if userID == "" {
+		return nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	keys, err := s.storage.Keys(ctx, s.keyService.UserAPIKeyPattern(userID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgListAPIKeys, err)
+	}
+
+	list := make([]*APIKey, 0, len(keys))
+	for _, key := range keys {
+		info, err := s.get(ctx, key[strings.LastIndex(key, ":")+1:])
+		if err != nil {
+			// Key 已过期或被吊销，清理残留的索引
+			s.storage.Delete(ctx, key)
+			continue
+		}
+		list = append(list, info.public())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+// Revoke 吊销 API Key，立即失效
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	info, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.Delete(ctx, s.keyService.APIKeyKey(id)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgRevokeAPIKey, err)
+	}
+	if err := s.storage.Delete(ctx, s.keyService.UserAPIKeyKey(info.UserID, id)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgRevokeAPIKey, err)
+	}
+	if err := s.storage.Delete(ctx, s.keyService.APIKeyLastUsedKey(id)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgRevokeAPIKey, err)
+	}
+	return nil
+}
+
+// Rotate 轮换 API Key：保留ID、名称、授权范围与有效期，生成新的密钥，旧Key立即失效
+func (s *Service) Rotate(ctx context.Context, id string) (string, *APIKey, error) {
+	info, err := s.get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := s.issue(ctx, info)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, info.public(), nil
+}
+
+// Verify 校验完整Key并返回元数据，同时按间隔更新最后使用时间
+func (s *Service) Verify(ctx context.Context, key string) (*APIKey, error) {
+	id, ok := s.parse(key)
+	if !ok {
+		return nil, core.ErrAPIKeyInvalid
+	}
+
+	info, err := s.get(ctx, id)
+	if err != nil {
+		if errors.Is(err, core.ErrAPIKeyNotFound) {
+			return nil, core.ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(info.Hash), []byte(hashKey(key))) != 1 {
+		return nil, core.ErrAPIKeyInvalid
+	}
+	if info.Expired() {
+		return nil, core.ErrAPIKeyExpired
+	}
+
+	if now := time.Now(); now.Sub(info.LastUsedAt) >= lastUsedInterval {
+		info.LastUsedAt = now
+		s.touch(ctx, info)
+	}
+	return info.public(), nil
+}
+
+// VerifyAPIKey 校验完整Key并返回所有者的用户信息，供 web 中间件使用
+func (s *Service) VerifyAPIKey(ctx context.Context, key string) (*core.UserInfo, error) {
+	info, err := s.Verify(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &core.UserInfo{
+		ID:       info.UserID,
+		APIKeyID: info.ID,
+		Scopes:   info.Scopes,
+	}, nil
+}
+
+// issue 生成新的密钥并保存元数据，返回完整Key
+func (s *Service) issue(ctx context.Context, info *APIKey) (string, error) {
+	secret, err := randomString(secretSize, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	key := info.Prefix + "_" + secret
+	info.Hash = hashKey(key)
+	if err := s.save(ctx, info); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// touch 更新最后使用时间，失败不影响本次校验
+// 最后使用时间单独保存而不回写Key记录，避免与吊销、轮换并发时写回已吊销的Key或旧摘要
+func (s *Service) touch(ctx context.Context, info *APIKey) {
+	key := s.keyService.APIKeyLastUsedKey(info.ID)
+	if err := s.storage.Set(ctx, key, info.LastUsedAt, s.ttl(info)); err != nil {
+		return
+	}
+	// 期间Key已被吊销时清理，避免残留
+	if exists, err := s.storage.Exists(ctx, s.keyService.APIKeyKey(info.ID)); err == nil && !exists {
+		_ = s.storage.Delete(ctx, key)
+	}
+}
+
+// parse 从完整Key中解析ID
+func (s *Service) parse(key string) (string, bool) {
+	rest := strings.TrimPrefix(key, s.prefix+"_")
+	if rest == key {
+		return "", false
+	}
+	parts := strings.SplitN(rest, "_", 2)
+	if len(parts) != 2 || len(parts[0]) != idSize*2 || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// get 读取 API Key 记录
+func (s *Service) get(ctx context.Context, id string) (*APIKey, error) {
+	if id == "" {
+		return nil, core.ErrAPIKeyNotFound
+	}
+
+	// 存储对不存在的键返回错误，视为不存在
+	data, err := s.storage.Get(ctx, s.keyService.APIKeyKey(id))
+	if err != nil || data == nil {
+		return nil, core.ErrAPIKeyNotFound
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var info APIKey
+	if err := json.Unmarshal(dataBytes, &info); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseAPIKey, err)
+	}
+
+	// 最后使用时间只用于展示，读取失败时保留记录中的值
+	if data, err := s.storage.Get(ctx, s.keyService.APIKeyLastUsedKey(id)); err == nil {
+		var lastUsedAt time.Time
+		if dataBytes, ok := data.([]byte); ok && json.Unmarshal(dataBytes, &lastUsedAt) == nil && lastUsedAt.After(info.LastUsedAt) {
+			info.LastUsedAt = lastUsedAt
+		}
+	}
+	return &info, nil
+}
+
+// save 保存 API Key 记录，有效期与Key一致
+func (s *Service) save(ctx context.Context, info *APIKey) error {
+	if err := s.storage.Set(ctx, s.keyService.APIKeyKey(info.ID), info, s.ttl(info)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveAPIKey, err)
+	}
+	return nil
+}
+
+// ttl 存储记录的剩余有效期，永不过期时为 0
+func (s *Service) ttl(info *APIKey) time.Duration {
+	if info.ExpireTime.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(info.ExpireTime); ttl > 0 {
+		return ttl
+	}
+	return time.Millisecond
+}
+
+// hashKey 完整Key的 SHA-256 摘要（Key 为高熵随机值，无需慢哈希）
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString 生成随机字节并编码
+func randomString(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateAPIKey, err)
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// LogCodeSender 将验证码写入日志的发送器，仅用于开发调试
+type LogCodeSender struct {
+	logger *log.Logger
+}
+
+// NewLogCodeSender 创建日志发送器，logger 为空时使用标准库默认 logger
+func NewLogCodeSender(logger *log.Logger) *LogCodeSender {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogCodeSender{logger: logger}
+}
+
+// SendCode 输出验证码到日志
+func (s *LogCodeSender) SendCode(ctx context.Context, identifier, code string, ttl time.Duration) error {
+	s.logger.Printf("gstoken: login code for %s is %s, valid for %s", identifier, code, ttl)
+	return nil
+}
+
+// MemoryCodeSender 将验证码保存在内存中的发送器，用于测试
+type MemoryCodeSender struct {
+	mu    sync.Mutex
+	codes map[string]string
+	sent  int
+}
+
+// NewMemoryCodeSender 创建内存发送器
+func NewMemoryCodeSender() *MemoryCodeSender {
+	return &MemoryCodeSender{codes: make(map[string]string)}
+}
+
+// SendCode 记录发送给登录标识的最新验证码
+func (s *MemoryCodeSender) SendCode(ctx context.Context, identifier, code string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[identifier] = code
+	s.sent++
+	return nil
+}
+
+// LastCode 获取最近一次发送给登录标识的验证码
+func (s *MemoryCodeSender) LastCode(identifier string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[identifier]
+	return code, ok
+}
+
+// SentCount 获取累计发送次数
+func (s *MemoryCodeSender) SentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
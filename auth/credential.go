@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// PasswordVerifier 基于 CredentialStore 的密码凭证校验器
+// hasher 用于新密码哈希与重新哈希；legacy 用于校验历史算法生成的哈希（如从 bcrypt 迁移到 argon2id）
+// 登录成功且哈希算法或参数与当前配置不一致时，自动以当前配置重新哈希并写回存储
+type PasswordVerifier struct {
+	store  core.CredentialStore
+	hasher core.PasswordHasher
+	legacy []core.PasswordHasher
+}
+
+// NewPasswordVerifier 创建密码凭证校验器
+func NewPasswordVerifier(store core.CredentialStore, hasher core.PasswordHasher, legacy ...core.PasswordHasher) *PasswordVerifier {
+	return &PasswordVerifier{
+		store:  store,
+		hasher: hasher,
+		legacy: legacy,
+	}
+}
+
+// VerifyCredential 校验登录名与密码
+func (v *PasswordVerifier) VerifyCredential(ctx context.Context, username, password string) (string, error) {
+	userID, hash, err := v.store.GetPasswordHash(ctx, username)
+	if err != nil {
+		if errors.Is(err, core.ErrUserNotFound) {
+			// 用户不存在时同样执行一次哈希，避免通过响应时间枚举账号
+			v.hasher.Hash(password)
+			return "", core.ErrInvalidCredentials
+		}
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGetPasswordHash, err)
+	}
+
+	hasher := v.hasherFor(hash)
+	if hasher == nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgUnknownHashFormat, core.ErrInvalidCredentials)
+	}
+
+	ok, err := hasher.Verify(password, hash)
+	if err != nil || !ok {
+		return "", core.ErrInvalidCredentials
+	}
+
+	// 算法或参数变化时重新哈希，写回失败不影响本次登录
+	if hasher != v.hasher || v.hasher.NeedsRehash(hash) {
+		if newHash, err := v.hasher.Hash(password); err == nil {
+			_ = v.store.UpdatePasswordHash(ctx, userID, newHash)
+		}
+	}
+
+	return userID, nil
+}
+
+// hasherFor 根据哈希格式选择哈希器
+func (v *PasswordVerifier) hasherFor(hash string) core.PasswordHasher {
+	if v.hasher.Supports(hash) {
+		return v.hasher
+	}
+	for _, hasher := range v.legacy {
+		if hasher.Supports(hash) {
+			return hasher
+		}
+	}
+	return nil
+}
+
+// LoginWithPassword 密码登录：先通过配置的 CredentialVerifier 校验凭证，再签发Token
+// 配置了登录失败限制时，校验前预占一次尝试，锁定期内或进行中的尝试已占满剩余次数时直接拒绝，
+// 凭证错误时按登录名与IP计为失败，登录成功后清除该登录名的失败记录
+// 按登录名而非用户ID计数：凭证错误时无法得到用户ID，且不存在的账号同样计数，避免通过锁定行为枚举账号
+func (e *Engine) LoginWithPassword(ctx context.Context, req *core.PasswordLoginRequest) (*core.LoginResponse, error) {
+	if req == nil {
+		return nil, errors.New(core.ErrMsgPasswordLoginEmpty)
+	}
+	if e.config.CredentialVerifier == nil {
+		return nil, errors.New(core.ErrMsgCredentialVerifierEmpty)
+	}
+
+	if err := e.attemptService.BeginAttempt(ctx, req.Username, req.IP); err != nil {
+		return nil, err
+	}
+
+	userID, err := e.config.CredentialVerifier.VerifyCredential(ctx, req.Username, req.Password)
+	if endErr := e.endLoginAttempt(ctx, req, errors.Is(err, core.ErrInvalidCredentials)); endErr != nil {
+		return nil, endErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.attemptService.ResetAttempts(ctx, req.Username, ""); err != nil {
+		return nil, err
+	}
+
+	return e.Login(ctx, &core.LoginRequest{
+		UserID: userID,
+		Device: req.Device,
+		IP:     req.IP,
+		Extra:  req.Extra,
+	})
+}
+
+// endLoginAttempt 结束预占的尝试，本次失败触发锁定时通知安全事件监听器并返回锁定错误
+// 凭证错误时失败已在预占时计入，其他记录错误不影响返回凭证错误
+func (e *Engine) endLoginAttempt(ctx context.Context, req *core.PasswordLoginRequest, failed bool) error {
+	err := e.attemptService.EndAttempt(ctx, req.Username, req.IP, failed)
+
+	var locked *core.TooManyAttemptsError
+	if !errors.As(err, &locked) {
+		if failed {
+			return nil
+		}
+		return err
+	}
+	e.emitLoginLocked(ctx, locked, req.Username, req.IP)
+	return locked
+}
+
+// emitLoginLocked 通知安全事件监听器登录（或多因素认证）因失败次数过多被锁定
+func (e *Engine) emitLoginLocked(ctx context.Context, locked *core.TooManyAttemptsError, username, ip string) {
+	e.emitSecurityEvent(ctx, &core.SecurityEvent{
+		Type: core.SecurityEventLoginLocked,
+		Time: time.Now(),
+		Extra: map[string]interface{}{
+			"kind":        locked.Kind,
+			"subject":     locked.Subject,
+			"username":    username,
+			"ip":          ip,
+			"retry_after": locked.RetryAfter.String(),
+		},
+	})
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// DisableService 账号封禁服务默认实现，封禁记录保存在存储中，到期由TTL自动解除
+type DisableService struct {
+	storage    core.Storage
+	keyService *core.KeyService
+}
+
+// NewDisableService 创建新的封禁服务
+func NewDisableService(storage core.Storage, keyService *core.KeyService) core.DisableService {
+	return &DisableService{
+		storage:    storage,
+		keyService: keyService,
+	}
+}
+
+// Disable 封禁账号
+func (d *DisableService) Disable(ctx context.Context, userID, service string, duration time.Duration, reason string) error {
+	if userID == "" {
+		return errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	now := time.Now()
+	info := &core.DisableInfo{
+		UserID:      userID,
+		Service:     normalizeDisableService(service),
+		Reason:      reason,
+		DisableTime: now,
+	}
+	if duration > 0 {
+		info.ExpireTime = now.Add(duration)
+	} else {
+		duration = 0
+	}
+
+	if err := d.storage.Set(ctx, d.keyService.DisableKey(userID, info.Service), info, duration); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDisableUser, err)
+	}
+	return nil
+}
+
+// IsDisabled 检查账号是否被封禁
+func (d *DisableService) IsDisabled(ctx context.Context, userID, service string) (bool, error) {
+	info, err := d.GetDisableInfo(ctx, userID, service)
+	if err != nil {
+		return false, err
+	}
+	return info != nil, nil
+}
+
+// CheckDisabled 账号被封禁时返回 *core.UserDisabledError
+func (d *DisableService) CheckDisabled(ctx context.Context, userID, service string) error {
+	info, err := d.GetDisableInfo(ctx, userID, service)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return nil
+	}
+	return &core.UserDisabledError{
+		UserID:     info.UserID,
+		Service:    info.Service,
+		Reason:     info.Reason,
+		ExpireTime: info.ExpireTime,
+	}
+}
+
+// GetDisableInfo 获取生效中的封禁信息，全部服务的封禁优先
+func (d *DisableService) GetDisableInfo(ctx context.Context, userID, service string) (*core.DisableInfo, error) {
+	if userID == "" {
+		return nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	services := []string{core.DisableServiceAll}
+	if service = normalizeDisableService(service); service != core.DisableServiceAll {
+		services = append(services, service)
+	}
+
+	for _, svc := range services {
+		info, err := d.getDisableInfo(ctx, userID, svc)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			return info, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetDisableTime 获取剩余封禁时长
+func (d *DisableService) GetDisableTime(ctx context.Context, userID, service string) (time.Duration, error) {
+	info, err := d.GetDisableInfo(ctx, userID, service)
+	if err != nil || info == nil {
+		return 0, err
+	}
+	if info.Permanent() {
+		return core.DisablePermanent, nil
+	}
+	return time.Until(info.ExpireTime), nil
+}
+
+// Untie 解除封禁
+func (d *DisableService) Untie(ctx context.Context, userID, service string) error {
+	if userID == "" {
+		return errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	if err := d.storage.Delete(ctx, d.keyService.DisableKey(userID, normalizeDisableService(service))); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgUntieUser, err)
+	}
+	return nil
+}
+
+// getDisableInfo 读取指定范围的封禁记录，不存在或已到期时返回 nil
+// 存储对不存在的键返回错误，先以 Exists 区分未封禁与存储故障，故障时返回错误，不放行被封禁的账号
+func (d *DisableService) getDisableInfo(ctx context.Context, userID, service string) (*core.DisableInfo, error) {
+	key := d.keyService.DisableKey(userID, service)
+	exists, err := d.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetDisableInfo, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := d.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetDisableInfo, err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var info core.DisableInfo
+	if err := json.Unmarshal(dataBytes, &info); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseDisableInfo, err)
+	}
+
+	if !info.Permanent() && time.Now().After(info.ExpireTime) {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// normalizeDisableService 空的服务范围视为全部服务
+func normalizeDisableService(service string) string {
+	if service == "" {
+		return core.DisableServiceAll
+	}
+	return service
+}
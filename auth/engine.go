@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/luckxgo/gstoken/core"
@@ -18,6 +19,16 @@ type Engine struct {
 	authService       core.AuthService
 	sessionService    core.SessionService
 	permissionService core.PermissionService
+	disableService    core.DisableService
+	tempTokenService  core.TempTokenService
+	attemptService    core.LoginAttemptService
+	mfaService        core.MFAService
+	mfaAttempts       core.LoginAttemptService
+	otpService        core.OTPService
+	revocation        *revocationList
+
+	hookMu          sync.RWMutex
+	sessionEndHooks []core.SessionEndFunc
 }
 
 // NewEngine 创建新的认证引擎
@@ -29,10 +40,25 @@ func NewEngine(config *core.Config, storage core.Storage, tokenGenerator core.To
 		keyService:     keyService,
 	}
 
+	// 开启摘要存储时，存储键与持久化记录仅保存Token摘要
+	if config.TokenHashing && !keyService.TokenHashing() {
+		keyService.SetTokenHasher(core.NewTokenHasher(config.TokenHashSecret))
+	}
+
 	// 初始化各个服务
-	engine.sessionService = NewSessionService(storage, config, keyService)
+	engine.revocation = newRevocationList(storage, tokenGenerator, config, keyService)
+	engine.sessionService = NewSessionServiceWithGenerator(storage, tokenGenerator, config, keyService)
+	if sessionService, ok := engine.sessionService.(*SessionServiceImpl); ok {
+		sessionService.OnSessionEnd(engine.sessionEnded)
+	}
 	engine.authService = NewAuthService(storage, tokenGenerator, engine.sessionService, config, keyService)
 	engine.permissionService = NewPermissionService(storage, keyService)
+	engine.disableService = NewDisableService(storage, keyService)
+	engine.tempTokenService = NewTempTokenService(storage, tokenGenerator, keyService)
+	engine.attemptService = NewLoginAttemptService(storage, keyService, config.LoginAttempt)
+	engine.mfaService = NewMFAService(storage, keyService, config.MFA)
+	engine.mfaAttempts = newMFAAttemptService(storage, keyService, config.MFA)
+	engine.otpService = NewOTPService(storage, keyService, config.OTP, config.CodeSender, config.IdentifierResolver)
 
 	return engine
 }
@@ -47,6 +73,15 @@ func (e *Engine) Login(ctx context.Context, req *core.LoginRequest) (*core.Login
 		return nil, errors.New(core.ErrMsgUserIDEmpty)
 	}
 
+	// 已启用多因素认证的用户先返回待验证Token，提交验证码后再签发正式Token
+	enabled, err := e.mfaService.IsEnabled(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return e.beginMFA(ctx, req)
+	}
+
 	// 调用认证服务进行登录
 	return e.authService.Login(ctx, req)
 }
@@ -60,12 +95,35 @@ func (e *Engine) Logout(ctx context.Context, token string) error {
 	return e.authService.Logout(ctx, token)
 }
 
+// LogoutByRef 根据Token的存储引用登出（引用见 KeyService.TokenRef），调用方无需保存原始Token
+func (e *Engine) LogoutByRef(ctx context.Context, ref string) error {
+	if ref == "" {
+		return errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	service, ok := e.authService.(*Service)
+	if !ok {
+		return errors.New(core.ErrMsgLogoutByRefUnavailable)
+	}
+	return service.LogoutByRef(ctx, ref)
+}
+
 // Verify 验证Token并获取用户信息
 func (e *Engine) Verify(ctx context.Context, token string) (*core.UserInfo, error) {
 	if token == "" {
 		return nil, errors.New(core.ErrMsgTokenEmpty)
 	}
 
+	// 无状态/混合模式：本地校验签名Token，混合模式额外查询吊销记录
+	if e.config.VerifyMode == core.VerifyStateless || e.config.VerifyMode == core.VerifyHybrid {
+		return e.verifySigned(ctx, token)
+	}
+
+	// 伪造的Token在访问存储前即被拒绝
+	if err := e.ValidateTokenFormat(token); err != nil {
+		return nil, err
+	}
+
 	// 获取登录信息
 	loginInfo, err := e.authService.GetLoginInfo(ctx, token)
 	if err != nil {
@@ -73,36 +131,24 @@ func (e *Engine) Verify(ctx context.Context, token string) (*core.UserInfo, erro
 	}
 
 	// 检查Token是否过期
-	if time.Now().After(loginInfo.LastAccess.Add(e.config.TokenExpire)) {
+	if time.Now().After(loginInfo.LastAccess.Add(e.config.TokenExpireFor(loginInfo.Device))) {
 		return nil, errors.New(core.ErrMsgTokenExpired)
 	}
 
-	// 自动续期：仅在开启时更新最后访问时间并重置TTL
-	if e.config.AutoRenew {
-		now := time.Now()
+	// 检查绝对有效期与空闲超时，无论是否续期均强制失效
+	if err := e.checkSessionLifetime(loginInfo); err != nil {
+		e.authService.Logout(ctx, token)
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgTokenExpired, err)
+	}
 
-		// 更新会话的最后访问时间并重置TTL
-		session, err := e.sessionService.GetSession(ctx, token)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %w", core.ErrMsgGetSessionInfo, err)
-		}
-		session.LastAccess = now
-		if err := e.sessionService.UpdateSession(ctx, session); err != nil {
-			// 更新失败不影响验证结果
-		}
+	// 被封禁账号的已有Token同样拒绝，解封后恢复可用
+	if err := e.disableService.CheckDisabled(ctx, loginInfo.UserID, core.DisableServiceAll); err != nil {
+		return nil, err
+	}
 
-		// 同步更新登录信息的最后访问时间并重置TTL
-		loginInfo.LastAccess = now
-		// 通过认证服务的存储逻辑重置TTL
-		_ = e.authService.Login // 防止未使用提示
-		// 直接使用会话服务关联的键生成器，通过认证服务内部方法更新
-		// 这里复用存储键规则：login:{token}
-		loginKey := e.keyService.LoginInfoKey(token)
-		// 由于没有直接暴露的更新方法，使用存储接口重置TTL
-		// 存储层的 Set 会覆盖并设置新的过期时间
-		if err := e.storage.Set(ctx, loginKey, loginInfo, e.config.TokenExpire); err != nil {
-			// 不影响验证结果
-		}
+	// 自动续期：仅在开启且满足节流条件时更新最后访问时间并重置TTL，其余请求只读
+	if e.config.AutoRenewFor(loginInfo.Device) && e.shouldRenew(loginInfo) {
+		e.renew(ctx, token, loginInfo)
 	}
 
 	// 构造用户信息
@@ -112,10 +158,131 @@ func (e *Engine) Verify(ctx context.Context, token string) (*core.UserInfo, erro
 		Extra:    make(map[string]interface{}),
 		Roles:    []string{}, // 角色信息需要通过用户自定义的 UserRoleProvider 获取
 	}
+	if loginInfo.Impersonator != nil {
+		userInfo.ImpersonatorID = loginInfo.Impersonator.UserID
+	}
 
 	return userInfo, nil
 }
 
+// checkSessionLifetime 检查会话的绝对有效期（从首次认证起算）与空闲超时
+func (e *Engine) checkSessionLifetime(loginInfo *core.LoginInfo) error {
+	now := time.Now()
+
+	if maxLifetime := e.config.MaxLifetimeFor(loginInfo.Device); maxLifetime > 0 {
+		authTime := loginInfo.AuthTime
+		if authTime.IsZero() {
+			authTime = loginInfo.LoginTime
+		}
+		if now.After(authTime.Add(maxLifetime)) {
+			return core.ErrSessionLifetimeExceeded
+		}
+	}
+
+	if idleTimeout := e.config.IdleTimeoutFor(loginInfo.Device); idleTimeout > 0 {
+		if now.After(loginInfo.LastAccess.Add(idleTimeout)) {
+			return core.ErrSessionIdleTimeout
+		}
+	}
+
+	return nil
+}
+
+// checkTokenLifetime 检查签名Token的绝对有效期，从 auth_time 起算，缺失时使用签发时间
+// 空闲超时需要记录最后访问时间，签名Token无法在本地校验，已在配置校验时拒绝该组合
+func (e *Engine) checkTokenLifetime(tokenInfo *core.TokenInfo) error {
+	device, _ := tokenInfo.Extra[core.TokenExtraKeyDevice].(string)
+	maxLifetime := e.config.MaxLifetimeFor(device)
+	if maxLifetime <= 0 {
+		return nil
+	}
+
+	authTime := tokenInfo.AuthTime
+	if authTime.IsZero() {
+		authTime = tokenInfo.IssuedAt
+	}
+	if authTime.IsZero() || time.Now().After(authTime.Add(maxLifetime)) {
+		return core.ErrSessionLifetimeExceeded
+	}
+	return nil
+}
+
+// ValidateTokenFormat 在访问存储前本地校验Token格式（校验码风格校验HMAC校验码）
+func (e *Engine) ValidateTokenFormat(token string) error {
+	if e.config.TokenStyle != core.StyleChecksum {
+		return nil
+	}
+
+	if _, err := e.tokenGenerator.Parse(token); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgParseToken, err)
+	}
+	return nil
+}
+
+// verifySigned 本地校验签名Token
+func (e *Engine) verifySigned(ctx context.Context, token string) (*core.UserInfo, error) {
+	if !e.config.TokenStyle.SelfContained() {
+		return nil, errors.New(core.ErrMsgStatelessStyle)
+	}
+
+	tokenInfo, err := e.tokenGenerator.Parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseToken, err)
+	}
+
+	if tokenInfo.UserID == "" {
+		return nil, core.ErrTokenInvalid
+	}
+
+	// 刷新Token不能作为访问Token使用
+	switch t, _ := tokenInfo.Extra[core.TokenExtraKeyType].(string); t {
+	case core.TokenTypeRefresh:
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgRefreshAsAccess, core.ErrTokenInvalid)
+	case core.TokenTypeTemp:
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgTempAsAccess, core.ErrTokenInvalid)
+	case core.TokenTypeMFA:
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgMFAAsAccess, core.ErrTokenInvalid)
+	case core.TokenTypeOAuth:
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthAsAccess, core.ErrTokenInvalid)
+	}
+
+	// 签名Token同样受绝对有效期限制，自动续期与刷新均不延长
+	if err := e.checkTokenLifetime(tokenInfo); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgTokenExpired, err)
+	}
+
+	if e.config.VerifyMode == core.VerifyHybrid {
+		revoked, err := e.revocation.IsRevoked(ctx, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, core.ErrTokenRevoked
+		}
+
+		if err := e.disableService.CheckDisabled(ctx, tokenInfo.UserID, core.DisableServiceAll); err != nil {
+			return nil, err
+		}
+	}
+
+	extra := make(map[string]interface{}, len(tokenInfo.Extra))
+	for k, v := range tokenInfo.Extra {
+		if k == core.TokenExtraKeyUserID {
+			continue
+		}
+		extra[k] = v
+	}
+	impersonatorID, _ := tokenInfo.Extra[core.TokenExtraKeyImpersonator].(string)
+
+	return &core.UserInfo{
+		ID:             tokenInfo.UserID,
+		Username:       tokenInfo.UserID,
+		Roles:          []string{},
+		Extra:          extra,
+		ImpersonatorID: impersonatorID,
+	}, nil
+}
+
 // CheckPermission 检查用户权限
 func (e *Engine) CheckPermission(ctx context.Context, userID string, permission string) (bool, error) {
 	if userID == "" {
@@ -149,6 +316,83 @@ func (e *Engine) GetPermissionService() core.PermissionService {
 	return e.permissionService
 }
 
+// GetDisableService 获取封禁服务
+func (e *Engine) GetDisableService() core.DisableService {
+	return e.disableService
+}
+
+// GetTempTokenService 获取临时Token服务
+func (e *Engine) GetTempTokenService() core.TempTokenService {
+	return e.tempTokenService
+}
+
+// GetLoginAttemptService 获取登录失败限制服务
+func (e *Engine) GetLoginAttemptService() core.LoginAttemptService {
+	return e.attemptService
+}
+
+// CheckLoginAttempts 登录名或IP处于锁定期时返回 *core.TooManyAttemptsError
+func (e *Engine) CheckLoginAttempts(ctx context.Context, username, ip string) error {
+	return e.attemptService.CheckAttempts(ctx, username, ip)
+}
+
+// ResetLoginAttempts 清除登录名或IP的失败记录与锁定
+func (e *Engine) ResetLoginAttempts(ctx context.Context, username, ip string) error {
+	return e.attemptService.ResetAttempts(ctx, username, ip)
+}
+
+// GetMFAService 获取多因素认证服务
+func (e *Engine) GetMFAService() core.MFAService {
+	return e.mfaService
+}
+
+// GetOTPService 获取验证码登录服务
+func (e *Engine) GetOTPService() core.OTPService {
+	return e.otpService
+}
+
+// CreateTempToken 创建绑定用途的一次性临时Token
+func (e *Engine) CreateTempToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error) {
+	return e.tempTokenService.CreateTempToken(ctx, purpose, value, ttl)
+}
+
+// ConsumeTempToken 消费临时Token并返回业务数据
+func (e *Engine) ConsumeTempToken(ctx context.Context, purpose, token string) (string, error) {
+	return e.tempTokenService.ConsumeTempToken(ctx, purpose, token)
+}
+
+// Disable 封禁账号，duration <= 0 表示永久封禁
+// 封禁全部服务时对用户现有的会话调用会话结束回调，会话本身保留，解封后恢复可用
+func (e *Engine) Disable(ctx context.Context, userID, service string, duration time.Duration, reason string) error {
+	if err := e.disableService.Disable(ctx, userID, service, duration, reason); err != nil {
+		return err
+	}
+	if normalizeDisableService(service) != core.DisableServiceAll {
+		return nil
+	}
+	return e.endUserSessions(ctx, userID)
+}
+
+// IsDisabled 检查账号在指定服务上是否被封禁
+func (e *Engine) IsDisabled(ctx context.Context, userID, service string) (bool, error) {
+	return e.disableService.IsDisabled(ctx, userID, service)
+}
+
+// CheckDisabled 账号被封禁时返回 *core.UserDisabledError
+func (e *Engine) CheckDisabled(ctx context.Context, userID, service string) error {
+	return e.disableService.CheckDisabled(ctx, userID, service)
+}
+
+// GetDisableTime 获取剩余封禁时长
+func (e *Engine) GetDisableTime(ctx context.Context, userID, service string) (time.Duration, error) {
+	return e.disableService.GetDisableTime(ctx, userID, service)
+}
+
+// Untie 解除封禁
+func (e *Engine) Untie(ctx context.Context, userID, service string) error {
+	return e.disableService.Untie(ctx, userID, service)
+}
+
 // LogoutByUserID 根据用户ID登出所有会话
 func (e *Engine) LogoutByUserID(ctx context.Context, userID string) error {
 	return e.authService.LogoutByUserID(ctx, userID)
@@ -161,5 +405,62 @@ func (e *Engine) GetLoginInfo(ctx context.Context, token string) (*core.LoginInf
 
 // RefreshToken 刷新Token
 func (e *Engine) RefreshToken(ctx context.Context, refreshToken string) (*core.LoginResponse, error) {
-	return e.authService.RefreshAccessToken(ctx, refreshToken)
+	return e.Refresh(ctx, &core.RefreshRequest{RefreshToken: refreshToken})
+}
+
+// Refresh 使用刷新请求刷新Token
+func (e *Engine) Refresh(ctx context.Context, req *core.RefreshRequest) (*core.LoginResponse, error) {
+	if req != nil && req.RefreshToken != "" {
+		if err := e.ValidateTokenFormat(req.RefreshToken); err != nil {
+			return nil, err
+		}
+	}
+	if refresher, ok := e.authService.(core.RefreshRequestService); ok {
+		return refresher.Refresh(ctx, req)
+	}
+	if req == nil {
+		return nil, errors.New(core.ErrMsgRefreshTokenEmpty)
+	}
+	return e.authService.RefreshAccessToken(ctx, req.RefreshToken)
+}
+
+// OnSessionEnd 注册会话结束回调：会话被登出、踢出、挤下线，或用户被封禁全部服务时调用
+// 回调在触发操作的调用中同步执行，SSO 服务端据此通知应用注销本地登录态
+func (e *Engine) OnSessionEnd(fn core.SessionEndFunc) {
+	e.hookMu.Lock()
+	defer e.hookMu.Unlock()
+	e.sessionEndHooks = append(e.sessionEndHooks, fn)
+}
+
+// sessionEnded 调用已注册的会话结束回调
+func (e *Engine) sessionEnded(ctx context.Context, userID, tokenRef string) {
+	e.hookMu.RLock()
+	hooks := e.sessionEndHooks
+	e.hookMu.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, userID, tokenRef)
+	}
+}
+
+// endUserSessions 对用户的全部会话调用会话结束回调
+func (e *Engine) endUserSessions(ctx context.Context, userID string) error {
+	e.hookMu.RLock()
+	hooked := len(e.sessionEndHooks) > 0
+	e.hookMu.RUnlock()
+	if !hooked {
+		return nil
+	}
+
+	keys, err := e.storage.Keys(ctx, e.keyService.UserSessionPattern(userID))
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGetUserSessionList, err)
+	}
+	for _, key := range keys {
+		ref, err := readTokenRef(ctx, e.storage, key)
+		if err != nil {
+			continue
+		}
+		e.sessionEnded(ctx, userID, ref)
+	}
+	return nil
 }
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// Impersonate 模拟登录：操作人（如客服）以目标用户身份签发访问Token
+// 会话与登录信息中记录实际操作人，模拟会话不签发刷新Token；开始、结束与被拒绝均触发安全事件用于审计
+func (e *Engine) Impersonate(ctx context.Context, req *core.ImpersonateRequest) (*core.LoginResponse, error) {
+	if req == nil {
+		return nil, errors.New(core.ErrMsgImpersonateRequestEmpty)
+	}
+	if req.TargetUserID == "" {
+		return nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	service, ok := e.authService.(*Service)
+	if !ok {
+		return nil, errors.New(core.ErrMsgImpersonateUnavailable)
+	}
+
+	// 操作人Token需完整校验（过期、封禁等）
+	if _, err := e.Verify(ctx, req.ActorToken); err != nil {
+		return nil, err
+	}
+	actorInfo, err := e.authService.GetLoginInfo(ctx, req.ActorToken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetLoginInfo, err)
+	}
+
+	if err := e.checkImpersonation(ctx, actorInfo, req.TargetUserID); err != nil {
+		e.emitImpersonationEvent(ctx, core.SecurityEventImpersonationDenied, req.TargetUserID, actorInfo.UserID, req, err)
+		return nil, err
+	}
+
+	impersonator := &core.Impersonator{
+		UserID:    actorInfo.UserID,
+		Token:     e.keyService.TokenRef(req.ActorToken),
+		Reason:    req.Reason,
+		StartTime: time.Now(),
+	}
+	resp, err := service.login(ctx, &core.LoginRequest{
+		UserID: req.TargetUserID,
+		Device: actorInfo.Device,
+		IP:     req.IP,
+	}, impersonator)
+	if err != nil {
+		return nil, err
+	}
+
+	e.emitImpersonationEvent(ctx, core.SecurityEventImpersonationStart, req.TargetUserID, actorInfo.UserID, req, nil)
+	return resp, nil
+}
+
+// EndImpersonation 结束模拟登录：注销模拟Token并返回操作人原会话的登录信息
+// 操作人继续使用原Token；原会话已失效时模拟Token仍会被注销，并返回错误
+func (e *Engine) EndImpersonation(ctx context.Context, token string) (*core.LoginInfo, error) {
+	if token == "" {
+		return nil, errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	service, ok := e.authService.(*Service)
+	if !ok {
+		return nil, errors.New(core.ErrMsgImpersonateUnavailable)
+	}
+
+	loginInfo, err := e.authService.GetLoginInfo(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetLoginInfo, err)
+	}
+	if loginInfo.Impersonator == nil {
+		return nil, errors.New(core.ErrMsgNotImpersonating)
+	}
+	impersonator := loginInfo.Impersonator
+
+	if err := e.authService.Logout(ctx, token); err != nil {
+		return nil, err
+	}
+
+	e.emitSecurityEvent(ctx, &core.SecurityEvent{
+		Type:   core.SecurityEventImpersonationEnd,
+		UserID: loginInfo.UserID,
+		Time:   time.Now(),
+		Extra: map[string]interface{}{
+			"actor_id": impersonator.UserID,
+			"reason":   impersonator.Reason,
+			"duration": time.Since(impersonator.StartTime).String(),
+		},
+	})
+
+	original, err := service.getLoginInfoByRef(ctx, impersonator.Token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOriginalSessionExpired, err)
+	}
+	return original, nil
+}
+
+// checkImpersonation 检查是否允许模拟：操作人须拥有模拟登录权限，禁止嵌套模拟、模拟自己以及模拟拥有受保护角色的用户
+func (e *Engine) checkImpersonation(ctx context.Context, actorInfo *core.LoginInfo, targetUserID string) error {
+	permission := e.config.ImpersonationPermission
+	if permission == "" {
+		return fmt.Errorf("%s: %w", core.ErrMsgImpersonateNotEnabled, core.ErrImpersonationNotAllowed)
+	}
+	allowed, err := e.permissionService.CheckPermission(ctx, actorInfo.UserID, permission)
+	if err != nil {
+		// 无法确认操作人权限时拒绝模拟
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%s %s: %w", core.ErrMsgImpersonateNoPermission, permission, core.ErrImpersonationNotAllowed)
+	}
+
+	if actorInfo.Impersonator != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgImpersonateNested, core.ErrImpersonationNotAllowed)
+	}
+	if actorInfo.UserID == targetUserID {
+		return fmt.Errorf("%s: %w", core.ErrMsgImpersonateSelf, core.ErrImpersonationNotAllowed)
+	}
+
+	for _, role := range e.config.ImpersonationBlockedRoles {
+		has, err := e.permissionService.CheckRole(ctx, targetUserID, role)
+		if err != nil {
+			// 无法确认目标用户角色时拒绝模拟
+			return fmt.Errorf("%s: %w", core.ErrMsgGetUserRoles, err)
+		}
+		if has {
+			return fmt.Errorf("%s %s: %w", core.ErrMsgImpersonateBlockedRole, role, core.ErrImpersonationNotAllowed)
+		}
+	}
+	return nil
+}
+
+// emitImpersonationEvent 触发模拟登录开始或被拒绝的审计事件
+func (e *Engine) emitImpersonationEvent(ctx context.Context, eventType core.SecurityEventType, targetUserID, actorID string, req *core.ImpersonateRequest, cause error) {
+	extra := map[string]interface{}{
+		"actor_id": actorID,
+		"reason":   req.Reason,
+		"ip":       req.IP,
+	}
+	if cause != nil {
+		extra["error"] = cause.Error()
+	}
+	e.emitSecurityEvent(ctx, &core.SecurityEvent{
+		Type:   eventType,
+		UserID: targetUserID,
+		Time:   time.Now(),
+		Extra:  extra,
+	})
+}
+
+// emitSecurityEvent 通知安全事件监听器
+func (e *Engine) emitSecurityEvent(ctx context.Context, event *core.SecurityEvent) {
+	if e.config.SecurityEventListener != nil {
+		e.config.SecurityEventListener.OnSecurityEvent(ctx, event)
+	}
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+const (
+	defaultLoginAttemptWindow = 15 * time.Minute
+	defaultMaxLockDuration    = 24 * time.Hour
+)
+
+// LoginAttemptService 登录失败限制服务默认实现，失败次数与锁定记录保存在存储中
+// 失败次数按滑动窗口统计：每个固定窗口使用一个原子计数（core.CounterStorage），
+// 当前窗口的次数加上前一窗口按未过去部分加权的次数作为最近一个窗口时长内的失败次数，
+// 避免在固定窗口边界前后连续尝试得到两倍的次数；锁定后清零，
+// 锁定记录在锁定解除后保留一个窗口时长，期间再次被锁定时锁定时长翻倍
+type LoginAttemptService struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	policy     core.LoginAttemptPolicy
+	userKind   string // 按用户计数的维度，多因素认证复用该服务时为 LoginAttemptKindMFA
+}
+
+// NewLoginAttemptService 创建新的登录失败限制服务
+func NewLoginAttemptService(storage core.Storage, keyService *core.KeyService, policy core.LoginAttemptPolicy) core.LoginAttemptService {
+	return newLoginAttemptService(storage, keyService, policy, core.LoginAttemptKindUser)
+}
+
+// newLoginAttemptService 创建按指定维度计数用户失败次数的登录失败限制服务
+func newLoginAttemptService(storage core.Storage, keyService *core.KeyService, policy core.LoginAttemptPolicy, userKind string) *LoginAttemptService {
+	if policy.Window <= 0 {
+		policy.Window = defaultLoginAttemptWindow
+	}
+	if policy.LockDuration <= 0 {
+		policy.LockDuration = policy.Window
+	}
+	if policy.MaxLockDuration <= 0 {
+		policy.MaxLockDuration = defaultMaxLockDuration
+	}
+	return &LoginAttemptService{
+		storage:    storage,
+		keyService: keyService,
+		policy:     policy,
+		userKind:   userKind,
+	}
+}
+
+// CheckAttempts 检查登录名与IP是否处于锁定期，均被锁定时返回剩余时长较长的一个
+func (s *LoginAttemptService) CheckAttempts(ctx context.Context, username, ip string) error {
+	var locked *core.TooManyAttemptsError
+	for _, subject := range s.subjects(username, ip) {
+		info, err := s.getLockInfo(ctx, subject.kind, subject.value)
+		if err != nil {
+			return err
+		}
+		if retryAfter := time.Until(info.LockUntil); retryAfter > 0 && (locked == nil || retryAfter > locked.RetryAfter) {
+			locked = &core.TooManyAttemptsError{Kind: subject.kind, Subject: subject.value, RetryAfter: retryAfter}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// BeginAttempt 预占一次尝试：先计入失败次数再校验凭证，并发请求中超出上限的部分直接拒绝
+func (s *LoginAttemptService) BeginAttempt(ctx context.Context, username, ip string) error {
+	if err := s.CheckAttempts(ctx, username, ip); err != nil {
+		return err
+	}
+
+	subjects := s.subjects(username, ip)
+	for i, subject := range subjects {
+		n, err := s.count(ctx, subject, 1)
+		if err != nil {
+			s.release(ctx, subjects[:i])
+			return err
+		}
+		if n <= float64(subject.limit) {
+			continue
+		}
+
+		// 剩余次数已被进行中的尝试占满，这些尝试失败时将触发锁定
+		s.release(ctx, subjects[:i+1])
+		info, err := s.getLockInfo(ctx, subject.kind, subject.value)
+		if err != nil {
+			return err
+		}
+		return &core.TooManyAttemptsError{Kind: subject.kind, Subject: subject.value, RetryAfter: s.lockDuration(info.LockCount)}
+	}
+	return nil
+}
+
+// EndAttempt 结束预占的尝试：失败时预占的次数即为失败次数，达到上限时锁定；成功时归还预占的次数
+func (s *LoginAttemptService) EndAttempt(ctx context.Context, username, ip string, failed bool) error {
+	if !failed {
+		return s.release(ctx, s.subjects(username, ip))
+	}
+	return s.recordFailures(ctx, username, ip, 0)
+}
+
+// RecordFailure 记录一次登录失败
+func (s *LoginAttemptService) RecordFailure(ctx context.Context, username, ip string) error {
+	return s.recordFailures(ctx, username, ip, 1)
+}
+
+// ResetAttempts 清除失败次数与锁定
+func (s *LoginAttemptService) ResetAttempts(ctx context.Context, username, ip string) error {
+	for _, subject := range []attemptSubject{{kind: s.userKind, value: username}, {kind: core.LoginAttemptKindIP, value: ip}} {
+		if subject.value == "" {
+			continue
+		}
+		current := s.currentWindow()
+		for _, key := range []string{
+			s.keyService.LoginAttemptKey(subject.kind, subject.value, current),
+			s.keyService.LoginAttemptKey(subject.kind, subject.value, current-1),
+			s.keyService.LoginLockKey(subject.kind, subject.value),
+		} {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				return fmt.Errorf("%s: %w", core.ErrMsgResetLoginAttempt, err)
+			}
+		}
+	}
+	return nil
+}
+
+// attemptSubject 计数维度与对应的失败次数上限
+type attemptSubject struct {
+	kind  string
+	value string
+	limit int
+}
+
+// subjects 返回需要计数的维度，未配置上限或参数为空的维度跳过
+func (s *LoginAttemptService) subjects(username, ip string) []attemptSubject {
+	subjects := make([]attemptSubject, 0, 2)
+	if username != "" && s.policy.MaxAttempts > 0 {
+		subjects = append(subjects, attemptSubject{kind: s.userKind, value: username, limit: s.policy.MaxAttempts})
+	}
+	if ip != "" && s.policy.MaxIPAttempts > 0 {
+		subjects = append(subjects, attemptSubject{kind: core.LoginAttemptKindIP, value: ip, limit: s.policy.MaxIPAttempts})
+	}
+	return subjects
+}
+
+// recordFailures 按各维度记录失败，均触发锁定时返回剩余时长较长的一个
+func (s *LoginAttemptService) recordFailures(ctx context.Context, username, ip string, delta int64) error {
+	var locked *core.TooManyAttemptsError
+	for _, subject := range s.subjects(username, ip) {
+		lockErr, err := s.recordFailure(ctx, subject, delta)
+		if err != nil {
+			return err
+		}
+		if lockErr != nil && (locked == nil || lockErr.RetryAfter > locked.RetryAfter) {
+			locked = lockErr
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// recordFailure 原子累加失败次数（delta 为 0 时失败已由 BeginAttempt 计入），达到上限时锁定并清零
+// 并发失败同时达到上限时基于同一锁定记录计算，锁定次数只增加一次
+func (s *LoginAttemptService) recordFailure(ctx context.Context, subject attemptSubject, delta int64) (*core.TooManyAttemptsError, error) {
+	info, err := s.getLockInfo(ctx, subject.kind, subject.value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Before(info.LockUntil) {
+		// 锁定期内的失败不再累计，避免延长锁定
+		return &core.TooManyAttemptsError{Kind: subject.kind, Subject: subject.value, RetryAfter: info.LockUntil.Sub(now)}, nil
+	}
+
+	n, err := s.count(ctx, subject, delta)
+	if err != nil {
+		return nil, err
+	}
+	if n < float64(subject.limit) {
+		return nil, nil
+	}
+
+	lockDuration := s.lockDuration(info.LockCount)
+	info.LockCount++
+	info.LockUntil = now.Add(lockDuration)
+	lockKey := s.keyService.LoginLockKey(subject.kind, subject.value)
+	if err := s.storage.Set(ctx, lockKey, info, lockDuration+s.policy.Window); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgSaveLoginAttempt, err)
+	}
+	current := s.currentWindow()
+	for _, window := range []int64{current, current - 1} {
+		if err := s.storage.Delete(ctx, s.keyService.LoginAttemptKey(subject.kind, subject.value, window)); err != nil {
+			return nil, fmt.Errorf("%s: %w", core.ErrMsgSaveLoginAttempt, err)
+		}
+	}
+	return &core.TooManyAttemptsError{Kind: subject.kind, Subject: subject.value, RetryAfter: lockDuration}, nil
+}
+
+// currentWindow 返回当前时间所在的固定窗口序号
+func (s *LoginAttemptService) currentWindow() int64 {
+	return time.Now().UnixNano() / int64(s.policy.Window)
+}
+
+// count 将当前窗口的计数增加 delta，返回滑动窗口内的失败次数估算值
+// 前一窗口的次数按其仍处于滑动窗口内的比例计入；计数键保留两个窗口时长，供下一窗口加权读取
+func (s *LoginAttemptService) count(ctx context.Context, subject attemptSubject, delta int64) (float64, error) {
+	now := time.Now().UnixNano()
+	window := int64(s.policy.Window)
+	current := now / window
+
+	n, err := storage.Incr(ctx, s.storage, s.keyService.LoginAttemptKey(subject.kind, subject.value, current), delta, 2*s.policy.Window)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", core.ErrMsgSaveLoginAttempt, err)
+	}
+	previous, err := storage.Incr(ctx, s.storage, s.keyService.LoginAttemptKey(subject.kind, subject.value, current-1), 0, 2*s.policy.Window)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", core.ErrMsgGetLoginAttempt, err)
+	}
+	if previous < 0 {
+		previous = 0
+	}
+
+	weight := 1 - float64(now%window)/float64(window)
+	return float64(n) + float64(previous)*weight, nil
+}
+
+// release 归还预占的尝试次数，计数已因锁定清零时删除归还产生的负数
+func (s *LoginAttemptService) release(ctx context.Context, subjects []attemptSubject) error {
+	var errs []error
+	for _, subject := range subjects {
+		key := s.keyService.LoginAttemptKey(subject.kind, subject.value, s.currentWindow())
+		n, err := storage.Incr(ctx, s.storage, key, -1, 2*s.policy.Window)
+		if err == nil && n <= 0 {
+			err = s.storage.Delete(ctx, key)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", core.ErrMsgSaveLoginAttempt, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lockDuration 按已锁定次数指数退避，不超过上限
+func (s *LoginAttemptService) lockDuration(lockCount int) time.Duration {
+	duration := s.policy.LockDuration
+	for i := 0; i < lockCount && duration < s.policy.MaxLockDuration; i++ {
+		duration *= 2
+	}
+	if duration > s.policy.MaxLockDuration {
+		duration = s.policy.MaxLockDuration
+	}
+	return duration
+}
+
+// getLockInfo 读取锁定记录，不存在时返回空记录；存储故障时返回错误，不视为未锁定
+func (s *LoginAttemptService) getLockInfo(ctx context.Context, kind, subject string) (*core.LoginAttemptInfo, error) {
+	key := s.keyService.LoginLockKey(kind, subject)
+	// 存储对不存在的键返回错误，先以 Exists 区分不存在与存储故障
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetLoginAttempt, err)
+	}
+	if !exists {
+		return &core.LoginAttemptInfo{}, nil
+	}
+
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetLoginAttempt, err)
+	}
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var info core.LoginAttemptInfo
+	if err := json.Unmarshal(dataBytes, &info); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseLoginAttempt, err)
+	}
+	return &info, nil
+}
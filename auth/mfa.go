@@ -0,0 +1,475 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+const (
+	defaultMFADigits        = 6
+	defaultMFAPeriod        = 30 * time.Second
+	defaultMFASkew          = 1
+	defaultMFAPendingTTL    = 5 * time.Minute
+	defaultMFAMaxAttempts   = 5
+	defaultMFAUserAttempts  = 10
+	defaultMFALockDuration  = 15 * time.Minute
+	defaultRecoveryCodeSize = 10
+)
+
+// mfaRecord 多因素认证存储记录，恢复码仅保存 SHA-256 摘要
+// 密钥以明文保存，生产环境建议配合存储加密使用
+// 已使用的时间步与恢复码以独立的原子标记记录（MFAUsedKey），校验验证码时不回写该记录
+type mfaRecord struct {
+	Secret        string    `json:"secret"`
+	Confirmed     bool      `json:"confirmed"`
+	RecoveryCodes []string  `json:"recovery_codes,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// mfaPending 密码校验通过、等待提交验证码的登录请求，错误次数以独立的原子计数记录（MFAPendingAttemptKey）
+type mfaPending struct {
+	Request   *core.LoginRequest `json:"request"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// MFAService 多因素认证服务默认实现，绑定信息保存在存储中
+type MFAService struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	config     core.MFAConfig
+}
+
+// NewMFAService 创建新的多因素认证服务
+func NewMFAService(storage core.Storage, keyService *core.KeyService, config core.MFAConfig) core.MFAService {
+	return &MFAService{
+		storage:    storage,
+		keyService: keyService,
+		config:     normalizeMFAConfig(config),
+	}
+}
+
+// normalizeMFAConfig 为未设置的字段填充默认值
+func normalizeMFAConfig(config core.MFAConfig) core.MFAConfig {
+	if config.Digits <= 0 {
+		config.Digits = defaultMFADigits
+	}
+	if config.Period < time.Second {
+		config.Period = defaultMFAPeriod
+	}
+	if config.Skew <= 0 {
+		config.Skew = defaultMFASkew
+	}
+	if config.PendingTTL <= 0 {
+		config.PendingTTL = defaultMFAPendingTTL
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMFAMaxAttempts
+	}
+	if config.MaxUserAttempts <= 0 {
+		config.MaxUserAttempts = defaultMFAUserAttempts
+	}
+	if config.LockDuration <= 0 {
+		config.LockDuration = defaultMFALockDuration
+	}
+	if config.RecoveryCodeCount <= 0 {
+		config.RecoveryCodeCount = defaultRecoveryCodeSize
+	}
+	return config
+}
+
+// newMFAAttemptService 创建按用户统计验证码错误次数的限制服务，计数跨待验证Token累计，重新登录不清零
+func newMFAAttemptService(storage core.Storage, keyService *core.KeyService, config core.MFAConfig) *LoginAttemptService {
+	config = normalizeMFAConfig(config)
+	return newLoginAttemptService(storage, keyService, core.LoginAttemptPolicy{
+		MaxAttempts:  config.MaxUserAttempts,
+		Window:       config.LockDuration,
+		LockDuration: config.LockDuration,
+	}, core.LoginAttemptKindMFA)
+}
+
+// Enroll 生成新的 TOTP 密钥与恢复码
+func (m *MFAService) Enroll(ctx context.Context, userID, accountName string) (*core.TOTPEnrollment, error) {
+	if userID == "" {
+		return nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+
+	record, err := m.getRecord(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil && record.Confirmed {
+		return nil, errors.New(core.ErrMsgMFAAlreadyEnabled)
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := m.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	record = &mfaRecord{
+		Secret:        secret,
+		RecoveryCodes: hashes,
+		CreatedAt:     time.Now(),
+	}
+	if err := m.saveRecord(ctx, userID, record); err != nil {
+		return nil, err
+	}
+
+	if accountName == "" {
+		accountName = userID
+	}
+	return &core.TOTPEnrollment{
+		Secret:        secret,
+		URI:           totpURI(m.config.Issuer, accountName, secret, m.config.Digits, m.config.Period),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Confirm 校验验证码并启用多因素认证
+func (m *MFAService) Confirm(ctx context.Context, userID, code string) error {
+	record, err := m.requireRecord(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := m.verifyTOTP(ctx, userID, record, code); err != nil {
+		return err
+	}
+
+	record.Confirmed = true
+	return m.saveRecord(ctx, userID, record)
+}
+
+// IsEnabled 用户是否已确认绑定
+func (m *MFAService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	record, err := m.getRecord(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return record != nil && record.Confirmed, nil
+}
+
+// VerifyCode 校验 TOTP 验证码或恢复码
+func (m *MFAService) VerifyCode(ctx context.Context, userID, code string) error {
+	if code == "" {
+		return errors.New(core.ErrMsgMFACodeEmpty)
+	}
+
+	record, err := m.requireRecord(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !record.Confirmed {
+		return core.ErrMFANotEnrolled
+	}
+
+	if m.isTOTPCode(code) {
+		return m.verifyTOTP(ctx, userID, record, code)
+	}
+	return m.useRecoveryCode(ctx, userID, record, code)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (m *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	record, err := m.requireRecord(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := m.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	oldHashes := record.RecoveryCodes
+	record.RecoveryCodes = hashes
+	if err := m.saveRecord(ctx, userID, record); err != nil {
+		return nil, err
+	}
+	if err := m.deleteRecoveryMarkers(ctx, userID, oldHashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 解除绑定
+func (m *MFAService) Disable(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New(core.ErrMsgUserIDEmpty)
+	}
+	record, err := m.getRecord(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := m.storage.Delete(ctx, m.keyService.MFAKey(userID)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDeleteMFA, err)
+	}
+	if record != nil {
+		return m.deleteRecoveryMarkers(ctx, userID, record.RecoveryCodes)
+	}
+	return nil
+}
+
+// isTOTPCode 位数与配置一致且全为数字时按 TOTP 验证码处理，否则按恢复码处理
+func (m *MFAService) isTOTPCode(code string) bool {
+	if len(code) != m.config.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyTOTP 在允许的时间偏移内校验验证码，通过后原子标记该时间步，同一时间步的验证码只有一个请求能使用
+// 标记保留到该时间步超出允许偏移之后，防止重放
+func (m *MFAService) verifyTOTP(ctx context.Context, userID string, record *mfaRecord, code string) error {
+	key, err := decodeTOTPSecret(record.Secret)
+	if err != nil {
+		return err
+	}
+
+	step := time.Now().Unix() / int64(m.config.Period/time.Second)
+	for i := -m.config.Skew; i <= m.config.Skew; i++ {
+		s := step + int64(i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(s), m.config.Digits)), []byte(code)) == 1 {
+			ttl := m.config.Period * time.Duration(2*m.config.Skew+2)
+			return m.markUsed(ctx, userID, fmt.Sprintf("step:%d", s), ttl)
+		}
+	}
+	return core.ErrMFACodeInvalid
+}
+
+// useRecoveryCode 校验恢复码并原子标记为已使用，并发提交同一恢复码时只有一个请求成功
+func (m *MFAService) useRecoveryCode(ctx context.Context, userID string, record *mfaRecord, code string) error {
+	hash := hashRecoveryCode(code)
+	for _, stored := range record.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			return m.markUsed(ctx, userID, "recovery:"+hash, 0)
+		}
+	}
+	return core.ErrMFACodeInvalid
+}
+
+// markUsed 原子标记验证码已使用，标记已存在时返回 core.ErrMFACodeInvalid
+func (m *MFAService) markUsed(ctx context.Context, userID, marker string, ttl time.Duration) error {
+	n, err := storage.Incr(ctx, m.storage, m.keyService.MFAUsedKey(userID, marker), 1, ttl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveMFA, err)
+	}
+	if n != 1 {
+		return core.ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// deleteRecoveryMarkers 删除已作废恢复码的使用标记
+func (m *MFAService) deleteRecoveryMarkers(ctx context.Context, userID string, hashes []string) error {
+	for _, hash := range hashes {
+		if err := m.storage.Delete(ctx, m.keyService.MFAUsedKey(userID, "recovery:"+hash)); err != nil {
+			return fmt.Errorf("%s: %w", core.ErrMsgDeleteMFA, err)
+		}
+	}
+	return nil
+}
+
+// newRecoveryCodes 生成恢复码，返回明文与摘要
+func (m *MFAService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, m.config.RecoveryCodeCount)
+	hashes := make([]string, m.config.RecoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", core.ErrMsgGenerateRecoveryCode, err)
+		}
+		raw := strings.ToLower(hex.EncodeToString(buf))
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 恢复码摘要，忽略大小写、空格与连字符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// requireRecord 读取绑定记录，不存在时返回 core.ErrMFANotEnrolled
+func (m *MFAService) requireRecord(ctx context.Context, userID string) (*mfaRecord, error) {
+	if userID == "" {
+		return nil, errors.New(core.ErrMsgUserIDEmpty)
+	}
+	record, err := m.getRecord(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, core.ErrMFANotEnrolled
+	}
+	return record, nil
+}
+
+// getRecord 读取绑定记录，不存在时返回 nil
+// 读取或解密失败时返回错误而不是视为未绑定，避免存储故障时登录跳过多因素认证
+func (m *MFAService) getRecord(ctx context.Context, userID string) (*mfaRecord, error) {
+	key := m.keyService.MFAKey(userID)
+	// 存储对不存在的键返回错误，先以 Exists 区分不存在与存储故障
+	exists, err := m.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetMFA, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := m.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetMFA, err)
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var record mfaRecord
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseMFA, err)
+	}
+	return &record, nil
+}
+
+// saveRecord 保存绑定记录（永不过期）
+func (m *MFAService) saveRecord(ctx context.Context, userID string, record *mfaRecord) error {
+	if err := m.storage.Set(ctx, m.keyService.MFAKey(userID), record, 0); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveMFA, err)
+	}
+	return nil
+}
+
+// beginMFA 登录进入多因素认证阶段：保存登录请求并返回待验证Token
+func (e *Engine) beginMFA(ctx context.Context, req *core.LoginRequest) (*core.LoginResponse, error) {
+	token, err := e.tokenGenerator.Generate(map[string]interface{}{
+		core.TokenExtraKeyUserID: req.UserID,
+		core.TokenExtraKeyType:   core.TokenTypeMFA,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGenerateToken, err)
+	}
+
+	config := normalizeMFAConfig(e.config.MFA)
+	pending := &mfaPending{
+		Request:   req,
+		ExpiresAt: time.Now().Add(config.PendingTTL),
+	}
+	if err := e.storage.Set(ctx, e.keyService.MFAPendingKey(e.keyService.TokenRef(token)), pending, config.PendingTTL); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgSaveMFAPending, err)
+	}
+
+	return &core.LoginResponse{
+		ExpireTime:  pending.ExpiresAt,
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+// VerifyMFA 提交验证码（TOTP 或恢复码）完成登录，返回正式的访问Token
+// 验证码错误时待验证Token仍可重试，错误次数达到上限后失效，需要重新登录；
+// 同一用户跨待验证Token累计的错误次数达到 MaxUserAttempts 时锁定，锁定期内返回 *core.TooManyAttemptsError
+func (e *Engine) VerifyMFA(ctx context.Context, mfaToken, code string) (*core.LoginResponse, error) {
+	if mfaToken == "" {
+		return nil, errors.New(core.ErrMsgMFATokenEmpty)
+	}
+
+	ref := e.keyService.TokenRef(mfaToken)
+	key := e.keyService.MFAPendingKey(ref)
+	pending, err := e.getMFAPending(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	userID := pending.Request.UserID
+
+	if err := e.mfaAttempts.BeginAttempt(ctx, userID, ""); err != nil {
+		return nil, err
+	}
+	err = e.mfaService.VerifyCode(ctx, userID, code)
+	failed := errors.Is(err, core.ErrMFACodeInvalid)
+	if endErr := e.mfaAttempts.EndAttempt(ctx, userID, "", failed); endErr != nil {
+		var locked *core.TooManyAttemptsError
+		if errors.As(endErr, &locked) {
+			e.emitLoginLocked(ctx, locked, userID, pending.Request.IP)
+			return nil, locked
+		}
+		if !failed {
+			return nil, endErr
+		}
+	}
+	if err != nil {
+		if failed {
+			e.recordMFAFailure(ctx, ref, pending)
+		}
+		return nil, err
+	}
+
+	// 原子取出待验证状态，并发提交时只有一个请求完成登录
+	if data, err := storage.GetDel(ctx, e.storage, key); err != nil || data == nil {
+		return nil, core.ErrMFATokenInvalid
+	}
+	_ = e.storage.Delete(ctx, e.keyService.MFAPendingAttemptKey(ref))
+	if err := e.mfaAttempts.ResetAttempts(ctx, userID, ""); err != nil {
+		return nil, err
+	}
+	return e.authService.Login(ctx, pending.Request)
+}
+
+// recordMFAFailure 原子累计待验证Token的错误次数，达到上限时作废待验证Token
+func (e *Engine) recordMFAFailure(ctx context.Context, ref string, pending *mfaPending) {
+	key := e.keyService.MFAPendingKey(ref)
+	ttl := time.Until(pending.ExpiresAt)
+	if ttl <= 0 {
+		_ = e.storage.Delete(ctx, key)
+		return
+	}
+	n, err := storage.Incr(ctx, e.storage, e.keyService.MFAPendingAttemptKey(ref), 1, ttl)
+	if err != nil || n >= int64(normalizeMFAConfig(e.config.MFA).MaxAttempts) {
+		_ = e.storage.Delete(ctx, key)
+	}
+}
+
+// getMFAPending 读取待验证状态
+func (e *Engine) getMFAPending(ctx context.Context, key string) (*mfaPending, error) {
+	data, err := e.storage.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, core.ErrMFATokenInvalid
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var pending mfaPending
+	if err := json.Unmarshal(dataBytes, &pending); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseMFAPending, err)
+	}
+	if pending.Request == nil || time.Now().After(pending.ExpiresAt) {
+		return nil, core.ErrMFATokenInvalid
+	}
+	return &pending, nil
+}
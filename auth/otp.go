@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+const (
+	defaultOTPCodeLength     = 6
+	defaultOTPTTL            = 5 * time.Minute
+	defaultOTPMaxAttempts    = 5
+	defaultOTPResendInterval = time.Minute
+	defaultOTPMaxSends       = 10
+	defaultOTPSendWindow     = time.Hour
+)
+
+// otpRecord 已发送的验证码记录，仅保存验证码摘要
+// 错误次数以 ID 区分每次发送的验证码，使用独立的原子计数记录（OTPAttemptKey）
+type otpRecord struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CodeHash  string    `json:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OTPService 验证码登录服务默认实现，验证码与发送次数保存在存储中
+// 校验次数与发送频率均使用存储的原子计数（core.CounterStorage），并发请求不会超出限制
+type OTPService struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	config     core.OTPConfig
+	sender     core.CodeSender
+	resolver   core.IdentifierResolver
+}
+
+// NewOTPService 创建新的验证码登录服务，resolver 为空时登录标识即用户ID
+func NewOTPService(storage core.Storage, keyService *core.KeyService, config core.OTPConfig, sender core.CodeSender, resolver core.IdentifierResolver) core.OTPService {
+	if config.CodeLength <= 0 {
+		config.CodeLength = defaultOTPCodeLength
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultOTPTTL
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultOTPMaxAttempts
+	}
+	if config.ResendInterval <= 0 {
+		config.ResendInterval = defaultOTPResendInterval
+	}
+	if config.MaxSends <= 0 {
+		config.MaxSends = defaultOTPMaxSends
+	}
+	if config.SendWindow <= 0 {
+		config.SendWindow = defaultOTPSendWindow
+	}
+	return &OTPService{
+		storage:    storage,
+		keyService: keyService,
+		config:     config,
+		sender:     sender,
+		resolver:   resolver,
+	}
+}
+
+// RequestCode 生成并发送验证码，新验证码使之前未使用的验证码失效
+// 标识未注册时同样计入发送频率并返回成功，但不发送验证码，避免通过该接口枚举账号
+func (o *OTPService) RequestCode(ctx context.Context, identifier string) error {
+	if identifier == "" {
+		return errors.New(core.ErrMsgIdentifierEmpty)
+	}
+	if o.sender == nil {
+		return errors.New(core.ErrMsgCodeSenderEmpty)
+	}
+
+	if err := o.claimSend(ctx, identifier); err != nil {
+		return err
+	}
+
+	userID, err := o.resolve(ctx, identifier)
+	if err != nil && !errors.Is(err, core.ErrUserNotFound) {
+		return err
+	}
+	if userID == "" {
+		return nil
+	}
+
+	code, err := newNumericCode(o.config.CodeLength)
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGenerateOTP, err)
+	}
+	record := &otpRecord{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		CodeHash:  hashOTPCode(identifier, code),
+		ExpiresAt: time.Now().Add(o.config.TTL),
+	}
+	key := o.keyService.OTPKey(identifier)
+	if err := o.storage.Set(ctx, key, record, o.config.TTL); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveOTP, err)
+	}
+
+	if err := o.sender.SendCode(ctx, identifier, code, o.config.TTL); err != nil {
+		_ = o.storage.Delete(ctx, key)
+		return fmt.Errorf("%s: %w", core.ErrMsgSendOTP, err)
+	}
+	return nil
+}
+
+// VerifyCode 校验验证码并返回用户ID
+// 比对前先原子累加该验证码的校验次数，并发猜测时最多 MaxAttempts 次参与比对
+func (o *OTPService) VerifyCode(ctx context.Context, identifier, code string) (string, error) {
+	if identifier == "" {
+		return "", errors.New(core.ErrMsgIdentifierEmpty)
+	}
+	if code == "" {
+		return "", core.ErrOTPInvalid
+	}
+
+	key := o.keyService.OTPKey(identifier)
+	record, err := o.getRecord(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	attempts, err := storage.Incr(ctx, o.storage, o.keyService.OTPAttemptKey(identifier, record.ID), 1, time.Until(record.ExpiresAt))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveOTP, err)
+	}
+	if attempts > int64(o.config.MaxAttempts) {
+		return "", core.ErrOTPInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(hashOTPCode(identifier, code))) != 1 {
+		return "", core.ErrOTPInvalid
+	}
+
+	// 原子取出验证码记录，并发提交时只有一个请求成功；期间已发送新验证码时不接受旧验证码
+	data, err := storage.GetDel(ctx, o.storage, key)
+	if err != nil || data == nil {
+		return "", core.ErrOTPInvalid
+	}
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return "", core.ErrOTPInvalid
+	}
+	var consumed otpRecord
+	if err := json.Unmarshal(dataBytes, &consumed); err != nil || consumed.ID != record.ID {
+		return "", core.ErrOTPInvalid
+	}
+	return record.UserID, nil
+}
+
+// claimSend 原子占用一次发送：重发间隔内或发送窗口内次数已达上限时返回 *core.TooManyAttemptsError
+// 窗口从首次发送开始，RetryAfter 为剩余时长的上限
+func (o *OTPService) claimSend(ctx context.Context, identifier string) error {
+	n, err := storage.Incr(ctx, o.storage, o.keyService.OTPResendKey(identifier), 1, o.config.ResendInterval)
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveOTP, err)
+	}
+	if n > 1 {
+		return &core.TooManyAttemptsError{Kind: core.LoginAttemptKindOTP, Subject: identifier, RetryAfter: o.config.ResendInterval}
+	}
+
+	n, err = storage.Incr(ctx, o.storage, o.keyService.OTPSendKey(identifier), 1, o.config.SendWindow)
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveOTP, err)
+	}
+	if n > int64(o.config.MaxSends) {
+		return &core.TooManyAttemptsError{Kind: core.LoginAttemptKindOTP, Subject: identifier, RetryAfter: o.config.SendWindow}
+	}
+	return nil
+}
+
+// resolve 将登录标识解析为用户ID
+func (o *OTPService) resolve(ctx context.Context, identifier string) (string, error) {
+	if o.resolver == nil {
+		return identifier, nil
+	}
+	userID, err := o.resolver.ResolveIdentifier(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, core.ErrUserNotFound) {
+			return "", err
+		}
+		return "", fmt.Errorf("%s: %w", core.ErrMsgResolveIdentifier, err)
+	}
+	return userID, nil
+}
+
+// getRecord 读取验证码记录，不存在或已过期时返回 core.ErrOTPInvalid
+func (o *OTPService) getRecord(ctx context.Context, key string) (*otpRecord, error) {
+	data, err := o.storage.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, core.ErrOTPInvalid
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var record otpRecord
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseOTP, err)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, core.ErrOTPInvalid
+	}
+	return &record, nil
+}
+
+// newNumericCode 生成指定位数的随机数字验证码
+func newNumericCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateOTP, err)
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+// hashOTPCode 验证码摘要，绑定登录标识
+func hashOTPCode(identifier, code string) string {
+	sum := sha256.Sum256([]byte(identifier + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestLoginCode 为登录标识生成并发送验证码
+func (e *Engine) RequestLoginCode(ctx context.Context, identifier string) error {
+	return e.otpService.RequestCode(ctx, identifier)
+}
+
+// LoginWithCode 验证码登录：校验通过后按正常登录流程签发Token（已启用多因素认证时同样进入验证阶段）
+func (e *Engine) LoginWithCode(ctx context.Context, req *core.OTPLoginRequest) (*core.LoginResponse, error) {
+	if req == nil {
+		return nil, errors.New(core.ErrMsgOTPLoginEmpty)
+	}
+
+	userID, err := e.otpService.VerifyCode(ctx, req.Identifier, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Login(ctx, &core.LoginRequest{
+		UserID: userID,
+		Device: req.Device,
+		IP:     req.IP,
+		Extra:  req.Extra,
+	})
+}
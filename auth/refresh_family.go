@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// refreshFamily 刷新Token族
+// 同一次登录通过轮换产生的刷新Token共享一个族，记录当前有效的刷新Token以及由该族签发且仍有效的访问Token
+// 吊销状态保存在单独的标记键中，见 RefreshFamilyRevokedKey
+type refreshFamily struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	Device       string    `json:"device"`
+	Current      string    `json:"current"`       // 当前有效刷新Token的存储引用
+	AccessTokens []string  `json:"access_tokens"` // 该族签发的访问Token的存储引用
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// newRefreshFamily 创建刷新Token族
+func (s *Service) newRefreshFamily(ctx context.Context, userID, device, accessRef, refreshRef string) (string, error) {
+	family := &refreshFamily{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		Device:       device,
+		Current:      refreshRef,
+		AccessTokens: []string{accessRef},
+		CreatedAt:    time.Now(),
+	}
+	if err := s.saveRefreshFamily(ctx, family); err != nil {
+		return "", err
+	}
+	return family.ID, nil
+}
+
+// getRefreshFamily 获取刷新Token族
+func (s *Service) getRefreshFamily(ctx context.Context, familyID string) (*refreshFamily, error) {
+	data, err := s.storage.Get(ctx, s.keyService.RefreshFamilyKey(familyID))
+	if err != nil {
+		return nil, err
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var family refreshFamily
+	if err := json.Unmarshal(dataBytes, &family); err != nil {
+		return nil, err
+	}
+	return &family, nil
+}
+
+// saveRefreshFamily 保存刷新Token族，有效期与刷新Token一致
+func (s *Service) saveRefreshFamily(ctx context.Context, family *refreshFamily) error {
+	if err := s.storage.Set(ctx, s.keyService.RefreshFamilyKey(family.ID), family, s.config.RefreshExpireFor(family.Device)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgStoreRefreshFamily, err)
+	}
+	return nil
+}
+
+// rotateRefreshFamily 轮换刷新Token：新Token成为族内当前Token，已吊销的族拒绝轮换
+// 写入族记录与吊销并发时，调用方需在新Token写入存储后调用 checkRefreshFamily 再次确认
+func (s *Service) rotateRefreshFamily(ctx context.Context, oldInfo *core.RefreshTokenInfo, accessRef, refreshRef string) (string, error) {
+	if oldInfo.FamilyID == "" {
+		// 升级前签发的刷新Token没有族信息，从本次轮换开始建立新族
+		return s.newRefreshFamily(ctx, oldInfo.UserID, oldInfo.Device, accessRef, refreshRef)
+	}
+
+	if err := s.checkRefreshFamily(ctx, oldInfo.FamilyID); err != nil {
+		return "", err
+	}
+	family, err := s.getRefreshFamily(ctx, oldInfo.FamilyID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGetRefreshFamily, err)
+	}
+
+	family.Current = refreshRef
+	family.AccessTokens = append(s.liveAccessTokens(ctx, family.AccessTokens), accessRef)
+	if err := s.saveRefreshFamily(ctx, family); err != nil {
+		return "", err
+	}
+	return family.ID, nil
+}
+
+// checkRefreshFamily 检查刷新Token族是否已吊销，读取失败时按吊销处理
+func (s *Service) checkRefreshFamily(ctx context.Context, familyID string) error {
+	revoked, err := s.storage.Exists(ctx, s.keyService.RefreshFamilyRevokedKey(familyID))
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGetRefreshFamily, err)
+	}
+	if revoked {
+		return fmt.Errorf("%s: %w", core.ErrMsgRefreshFamilyRevoked, core.ErrRefreshTokenInvalid)
+	}
+	return nil
+}
+
+// liveAccessTokens 过滤掉登录信息已不存在（过期或登出）的访问Token，避免族记录随轮换无限增长
+// 读取失败时保留该Token，宁可多吊销也不遗漏
+func (s *Service) liveAccessTokens(ctx context.Context, accessRefs []string) []string {
+	live := make([]string, 0, len(accessRefs)+1)
+	for _, accessRef := range accessRefs {
+		exists, err := s.storage.Exists(ctx, s.keyService.LoginInfoKey(accessRef))
+		if err != nil || exists {
+			live = append(live, accessRef)
+		}
+	}
+	return live
+}
+
+// detectRefreshReuse 检查刷新Token是否为已轮换的旧Token，是则吊销整个族并发出安全事件
+func (s *Service) detectRefreshReuse(ctx context.Context, refreshRef string) error {
+	data, err := s.storage.Get(ctx, s.keyService.UsedRefreshTokenKey(refreshRef))
+	if err != nil || data == nil {
+		return nil
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil
+	}
+	var familyID string
+	if err := json.Unmarshal(dataBytes, &familyID); err != nil || familyID == "" {
+		return nil
+	}
+
+	family, err := s.revokeRefreshFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgRevokeRefreshFamily, err)
+	}
+
+	event := &core.SecurityEvent{
+		Type: core.SecurityEventRefreshTokenReuse,
+		Time: time.Now(),
+		Extra: map[string]interface{}{
+			"family_id": familyID,
+		},
+	}
+	if family != nil {
+		event.UserID = family.UserID
+	}
+	s.emitSecurityEvent(ctx, event)
+
+	return core.ErrRefreshTokenReused
+}
+
+// revokeRefreshFamily 吊销刷新Token族：写入吊销标记，删除当前刷新Token以及该族签发的全部访问Token
+// 先写标记再重新读取族记录：标记写入前完成的轮换已记录在族中，之后的轮换会在写入新Token后发现标记并自行撤销
+func (s *Service) revokeRefreshFamily(ctx context.Context, familyID string) (*refreshFamily, error) {
+	family, err := s.getRefreshFamily(ctx, familyID)
+	if err != nil {
+		// 族记录已过期，族内Token均已失效
+		return nil, nil
+	}
+
+	if err := s.storage.Set(ctx, s.keyService.RefreshFamilyRevokedKey(familyID), true, s.config.RefreshExpireFor(family.Device)); err != nil {
+		return family, err
+	}
+	if latest, err := s.getRefreshFamily(ctx, familyID); err == nil {
+		family = latest
+	}
+
+	for _, accessRef := range family.AccessTokens {
+		s.revokeAccessByRef(ctx, family.UserID, accessRef)
+	}
+	if family.Current != "" {
+		s.refreshIndex.Revoke(ctx, family.Current)
+	}
+	return family, nil
+}
+
+// emitSecurityEvent 通知安全事件监听器
+func (s *Service) emitSecurityEvent(ctx context.Context, event *core.SecurityEvent) {
+	if s.config.SecurityEventListener != nil {
+		s.config.SecurityEventListener.OnSecurityEvent(ctx, event)
+	}
+}
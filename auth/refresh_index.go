@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// refreshIndex 刷新Token索引
+// 维护用户到刷新Token的索引以及访问Token到刷新Token的配对，使登出和踢人下线能够同时吊销刷新Token
+type refreshIndex struct {
+	storage    core.Storage
+	config     *core.Config
+	keyService *core.KeyService
+}
+
+// newRefreshIndex 创建刷新Token索引
+func newRefreshIndex(storage core.Storage, config *core.Config, keyService *core.KeyService) *refreshIndex {
+	return &refreshIndex{
+		storage:    storage,
+		config:     config,
+		keyService: keyService,
+	}
+}
+
+// Add 登记刷新Token的用户索引与访问Token配对
+func (r *refreshIndex) Add(ctx context.Context, info *core.RefreshTokenInfo) error {
+	ttl := r.config.RefreshExpireFor(info.Device)
+	if err := r.storage.Set(ctx, r.keyService.UserRefreshKey(info.UserID, info.RefreshToken), info.RefreshToken, ttl); err != nil {
+		return err
+	}
+	if info.AccessToken != "" {
+		if err := r.storage.Set(ctx, r.keyService.AccessRefreshKey(info.AccessToken), info.RefreshToken, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revoke 吊销刷新Token并清理索引（refreshRef 为刷新Token的存储引用）
+func (r *refreshIndex) Revoke(ctx context.Context, refreshRef string) {
+	refreshKey := r.keyService.RefreshTokenKey(refreshRef)
+	if data, err := r.storage.Get(ctx, refreshKey); err == nil {
+		var info core.RefreshTokenInfo
+		if dataBytes, ok := data.([]byte); ok && json.Unmarshal(dataBytes, &info) == nil {
+			info.RefreshToken = refreshRef
+			r.Remove(ctx, &info)
+		}
+	}
+	r.storage.Delete(ctx, refreshKey)
+}
+
+// Remove 清理已删除的刷新Token的用户索引与访问Token配对
+func (r *refreshIndex) Remove(ctx context.Context, info *core.RefreshTokenInfo) {
+	r.storage.Delete(ctx, r.keyService.UserRefreshKey(info.UserID, info.RefreshToken))
+	if info.AccessToken != "" {
+		r.storage.Delete(ctx, r.keyService.AccessRefreshKey(info.AccessToken))
+	}
+}
+
+// RevokeByAccess 吊销与访问Token配对的刷新Token
+func (r *refreshIndex) RevokeByAccess(ctx context.Context, accessRef string) {
+	pairKey := r.keyService.AccessRefreshKey(accessRef)
+	refreshRef, err := readTokenRef(ctx, r.storage, pairKey)
+	if err != nil || refreshRef == "" {
+		return
+	}
+	r.Revoke(ctx, refreshRef)
+	r.storage.Delete(ctx, pairKey)
+}
+
+// RevokeUser 吊销用户的全部刷新Token
+func (r *refreshIndex) RevokeUser(ctx context.Context, userID string) {
+	keys, err := r.storage.Keys(ctx, r.keyService.UserRefreshPattern(userID))
+	if err != nil {
+		return
+	}
+	for _, key := range keys {
+		if refreshRef, err := readTokenRef(ctx, r.storage, key); err == nil && refreshRef != "" {
+			r.Revoke(ctx, refreshRef)
+		}
+		r.storage.Delete(ctx, key)
+	}
+}
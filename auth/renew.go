@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// shouldRenew 判断本次校验是否需要续期
+// 未配置节流策略时每次都续期；否则剩余有效期低于阈值比例或距上次续期超过间隔时才续期
+// 配置空闲超时时续期间隔不超过空闲超时的一半，持续访问的用户不会因最后访问时间未及时更新而被判定空闲
+func (e *Engine) shouldRenew(loginInfo *core.LoginInfo) bool {
+	threshold := e.config.RenewThreshold
+	interval := e.config.RenewInterval
+	if threshold <= 0 && interval <= 0 {
+		return true
+	}
+	if idleTimeout := e.config.IdleTimeoutFor(loginInfo.Device); idleTimeout > 0 && (interval <= 0 || interval > idleTimeout/2) {
+		interval = idleTimeout / 2
+	}
+
+	expire := e.config.TokenExpireFor(loginInfo.Device)
+	elapsed := time.Since(loginInfo.LastAccess)
+
+	if threshold > 0 && expire-elapsed < time.Duration(float64(expire)*threshold) {
+		return true
+	}
+	if interval > 0 && elapsed >= interval {
+		return true
+	}
+	return false
+}
+
+// renew 续期：只重置登录信息、会话与用户会话映射的TTL，不改写任何记录
+// 最后访问时间由登录信息的剩余有效期推算；登录信息已被并发登出删除时重置失败，不会复活已登出的Token
+// 存储不支持重置TTL时回退为重写记录；续期失败不影响已通过的验证结果
+func (e *Engine) renew(ctx context.Context, token string, loginInfo *core.LoginInfo) {
+	expire := e.config.TokenExpireFor(loginInfo.Device)
+	tokenRef := e.keyService.TokenRef(token)
+
+	touched, err := e.touch(ctx, e.keyService.LoginInfoKey(tokenRef), expire)
+	if errors.Is(err, core.ErrTouchNotSupported) {
+		e.rewrite(ctx, token, loginInfo, expire)
+		return
+	}
+	if err != nil || !touched {
+		return
+	}
+
+	e.touch(ctx, e.keyService.SessionKey(tokenRef), expire)
+	e.touch(ctx, e.keyService.UserSessionKey(loginInfo.UserID, tokenRef), expire)
+}
+
+// rewrite 存储不支持重置TTL时重写会话、登录信息与用户会话映射的最后访问时间
+func (e *Engine) rewrite(ctx context.Context, token string, loginInfo *core.LoginInfo, expire time.Duration) {
+	now := time.Now()
+	tokenRef := e.keyService.TokenRef(token)
+
+	session, err := e.sessionService.GetSession(ctx, token)
+	if err != nil {
+		return
+	}
+	session.Token = token
+	session.LastAccess = now
+	if err := e.sessionService.UpdateSession(ctx, session); err != nil {
+		return
+	}
+
+	loginInfo.LastAccess = now
+	if err := e.storage.Set(ctx, e.keyService.LoginInfoKey(tokenRef), loginInfo, expire); err != nil {
+		return
+	}
+	_ = e.storage.Set(ctx, e.keyService.UserSessionKey(loginInfo.UserID, tokenRef), tokenRef, expire)
+}
+
+// touch 使用存储的TTL重置操作，存储不支持时返回 core.ErrTouchNotSupported
+func (e *Engine) touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	ttlStorage, ok := e.storage.(core.TTLStorage)
+	if !ok {
+		return false, core.ErrTouchNotSupported
+	}
+	return ttlStorage.Touch(ctx, key, expire)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// revocationList 签名Token吊销列表
+// 按 jti 在存储中记录吊销标记，TTL 为Token剩余有效期，过期后自动清理
+type revocationList struct {
+	storage        core.Storage
+	tokenGenerator core.TokenGenerator
+	config         *core.Config
+	keyService     *core.KeyService
+}
+
+// newRevocationList 创建吊销列表
+func newRevocationList(storage core.Storage, tokenGenerator core.TokenGenerator, config *core.Config, keyService *core.KeyService) *revocationList {
+	return &revocationList{
+		storage:        storage,
+		tokenGenerator: tokenGenerator,
+		config:         config,
+		keyService:     keyService,
+	}
+}
+
+// Revoke 吊销签名Token；非自包含风格或已失效的Token无需记录
+func (r *revocationList) Revoke(ctx context.Context, token string) error {
+	if r.tokenGenerator == nil || !r.config.TokenStyle.SelfContained() {
+		return nil
+	}
+
+	tokenInfo, err := r.tokenGenerator.Parse(token)
+	if err != nil || tokenInfo.ID == "" {
+		return nil
+	}
+
+	return r.revokeID(ctx, tokenInfo.ID, tokenInfo.ExpireTime)
+}
+
+// RevokeSession 按会话中记录的 jti 吊销签名Token（存储中仅有Token摘要时使用）
+func (r *revocationList) RevokeSession(ctx context.Context, session *core.Session) error {
+	if session == nil || session.TokenID == "" || !r.config.TokenStyle.SelfContained() {
+		return nil
+	}
+	return r.revokeID(ctx, session.TokenID, session.LoginTime.Add(r.config.TokenExpireFor(session.Device)))
+}
+
+// revokeID 写入吊销标记，TTL 为Token剩余有效期
+func (r *revocationList) revokeID(ctx context.Context, tokenID string, expireTime time.Time) error {
+	ttl := time.Until(expireTime) + r.leeway()
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := r.storage.Set(ctx, r.keyService.RevokedTokenKey(tokenID), 1, ttl); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgRevokeToken, err)
+	}
+	return nil
+}
+
+// leeway 当前Token风格的时间校验容差
+func (r *revocationList) leeway() time.Duration {
+	switch r.config.TokenStyle {
+	case core.StylePasetoLocal, core.StylePasetoPublic:
+		return r.config.Paseto.Leeway
+	default:
+		return r.config.JWT.Leeway
+	}
+}
+
+// IsRevoked 检查签名Token是否已被吊销
+func (r *revocationList) IsRevoked(ctx context.Context, tokenInfo *core.TokenInfo) (bool, error) {
+	if tokenInfo.ID == "" {
+		return false, nil
+	}
+
+	revoked, err := r.storage.Exists(ctx, r.keyService.RevokedTokenKey(tokenInfo.ID))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", core.ErrMsgCheckRevocation, err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// OpenSafe 为Token开启二级认证窗口，在 duration 内访问该服务的敏感操作无需再次认证
+// 调用方应在用户重新输入密码等校验通过后调用
+func (e *Engine) OpenSafe(ctx context.Context, token, service string, duration time.Duration) error {
+	if token == "" {
+		return errors.New(core.ErrMsgTokenEmpty)
+	}
+	if duration <= 0 {
+		return errors.New(core.ErrMsgSafeDurationInvalid)
+	}
+
+	// 只为有效的登录会话开启
+	if _, err := e.authService.GetLoginInfo(ctx, token); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGetLoginInfo, err)
+	}
+
+	key := e.keyService.SafeKey(e.keyService.TokenRef(token), normalizeSafeService(service))
+	if err := e.storage.Set(ctx, key, time.Now().Add(duration), duration); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgOpenSafe, err)
+	}
+	return nil
+}
+
+// IsSafe 检查Token在指定服务上是否处于二级认证窗口内
+func (e *Engine) IsSafe(ctx context.Context, token, service string) (bool, error) {
+	if token == "" {
+		return false, errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	key := e.keyService.SafeKey(e.keyService.TokenRef(token), normalizeSafeService(service))
+	// 存储对不存在或已过期的键返回错误，视为未开启
+	data, err := e.storage.Get(ctx, key)
+	if err != nil || data == nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// CloseSafe 提前关闭Token在指定服务上的二级认证窗口
+func (e *Engine) CloseSafe(ctx context.Context, token, service string) error {
+	if token == "" {
+		return errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	key := e.keyService.SafeKey(e.keyService.TokenRef(token), normalizeSafeService(service))
+	if err := e.storage.Delete(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgCloseSafe, err)
+	}
+	return nil
+}
+
+// normalizeSafeService 空的服务视为默认服务
+func normalizeSafeService(service string) string {
+	if service == "" {
+		return core.SafeServiceDefault
+	}
+	return service
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
 )
 
 // Service 认证服务实现
@@ -17,6 +18,8 @@ type Service struct {
 	sessionService core.SessionService
 	config         *core.Config
 	keyService     *core.KeyService
+	refreshIndex   *refreshIndex
+	disableService core.DisableService
 }
 
 // NewAuthService 创建新的认证服务
@@ -27,17 +30,33 @@ func NewAuthService(storage core.Storage, tokenGenerator core.TokenGenerator, se
 		sessionService: sessionService,
 		config:         config,
 		keyService:     keyService,
+		refreshIndex:   newRefreshIndex(storage, config, keyService),
+		disableService: NewDisableService(storage, keyService),
 	}
 }
 
 // Login 用户登录
 func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.LoginResponse, error) {
+	return s.login(ctx, req, nil)
+}
+
+// login 登录实现；impersonator 不为空时为模拟登录：
+// 不执行登录模式与会话数量检查（避免踢出被模拟用户自己的会话），不签发刷新Token，并在会话中记录实际操作人
+func (s *Service) login(ctx context.Context, req *core.LoginRequest, impersonator *core.Impersonator) (*core.LoginResponse, error) {
+	// 被封禁的账号不允许登录
+	if err := s.disableService.CheckDisabled(ctx, req.UserID, core.DisableServiceAll); err != nil {
+		return nil, err
+	}
+
 	// 处理登录模式
-	if err := s.handleLoginMode(ctx, req); err != nil {
-		return nil, fmt.Errorf("处理登录模式失败: %w", err)
+	if impersonator == nil {
+		if err := s.handleLoginMode(ctx, req); err != nil {
+			return nil, fmt.Errorf("%s: %w", core.ErrMsgHandleLoginMode, err)
+		}
 	}
 
 	// 生成Token
+	now := time.Now()
 	tokenExtra := map[string]interface{}{
 		core.TokenExtraKeyUserID: req.UserID,
 		core.TokenExtraKeyDevice: req.Device,
@@ -48,6 +67,11 @@ func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.Logi
 	for k, v := range req.Extra {
 		tokenExtra[k] = v
 	}
+	if impersonator != nil {
+		tokenExtra[core.TokenExtraKeyImpersonator] = impersonator.UserID
+	}
+	// 签名Token携带首次认证时间，无状态校验时据此检查绝对有效期
+	tokenExtra[core.TokenExtraKeyAuthTime] = now
 
 	token, err := s.tokenGenerator.Generate(tokenExtra)
 	if err != nil {
@@ -56,16 +80,12 @@ func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.Logi
 
 	// 生成刷新Token（支持记住登录）
 	var refreshToken string
-	var refreshExpire time.Duration
-	if s.config.RefreshExpire > 0 {
-		refreshExpire = s.config.RefreshExpire
-	} else if s.config.RememberDays > 0 {
-		refreshExpire = time.Duration(s.config.RememberDays) * 24 * time.Hour
-	}
+	refreshExpire := s.config.RefreshExpireFor(req.Device)
 
-	if refreshExpire > 0 {
+	if refreshExpire > 0 && impersonator == nil {
 		refreshTokenExtra := map[string]interface{}{
 			core.TokenExtraKeyUserID: req.UserID,
+			core.TokenExtraKeyDevice: req.Device,
 			core.TokenExtraKeyType:   core.TokenTypeRefresh,
 		}
 		refreshToken, err = s.tokenGenerator.Generate(refreshTokenExtra)
@@ -74,17 +94,23 @@ func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.Logi
 		}
 	}
 
+	// 存储中仅使用Token引用（开启摘要存储时为Token摘要）
+	tokenRef := s.keyService.TokenRef(token)
+
 	// 创建会话
-	now := time.Now()
 	session := &core.Session{
-		ID:         token,
+		ID:         tokenRef,
 		UserID:     req.UserID,
 		Token:      token,
+		TokenID:    s.tokenID(token),
 		Device:     req.Device,
 		IP:         req.IP,
 		LoginTime:  now,
+		AuthTime:   now,
 		LastAccess: now,
 		Extra:      req.Extra,
+
+		Impersonator: impersonator,
 	}
 
 	if err := s.sessionService.CreateSession(ctx, session); err != nil {
@@ -94,36 +120,58 @@ func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.Logi
 	// 存储登录信息
 	loginInfo := &core.LoginInfo{
 		UserID:     req.UserID,
-		Token:      token,
+		Token:      tokenRef,
 		Device:     req.Device,
 		IP:         req.IP,
 		LoginTime:  now,
+		AuthTime:   now,
 		LastAccess: now,
 		Extra:      req.Extra,
+
+		Impersonator: impersonator,
 	}
 
-	if err := s.storeLoginInfo(ctx, token, loginInfo); err != nil {
+	if err := s.storeLoginInfo(ctx, tokenRef, loginInfo); err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreLoginInfo, err)
 	}
 
 	// 创建用户会话映射，用于根据userID查找Token
-	if err := s.storeUserSessionMapping(ctx, req.UserID, token); err != nil {
+	if err := s.storeUserSessionMapping(ctx, req.UserID, req.Device, tokenRef); err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreUserSessionMap, err)
 	}
 
+	// 并发登录可能同时通过会话数量预检查，写入后再次检查，超限时撤销本次登录
+	if impersonator == nil {
+		if err := s.recheckSessionLimit(ctx, req, tokenRef); err != nil {
+			s.revokeAccessByRef(ctx, req.UserID, tokenRef)
+			return nil, fmt.Errorf("%s: %w", core.ErrMsgHandleLoginMode, err)
+		}
+	}
+
 	// 存储刷新Token（如果生成了）
 	if refreshToken != "" {
 		exp := refreshExpire
+		refreshRef := s.keyService.TokenRef(refreshToken)
+
+		// 每次登录开启新的刷新Token族，用于轮换时的重放检测
+		familyID, err := s.newRefreshFamily(ctx, req.UserID, req.Device, tokenRef, refreshRef)
+		if err != nil {
+			return nil, err
+		}
+
 		refreshInfo := &core.RefreshTokenInfo{
-			RefreshToken: refreshToken,
+			RefreshToken: refreshRef,
+			AccessToken:  tokenRef,
+			FamilyID:     familyID,
 			UserID:       req.UserID,
 			Device:       req.Device,
 			CreatedAt:    now,
+			AuthTime:     now,
 			ExpiresAt:    now.Add(exp),
 			Extra:        req.Extra,
 		}
 		// 使用实际的过期时间写入存储
-		if err := s.storeRefreshToken(ctx, refreshToken, refreshInfo); err != nil {
+		if err := s.storeRefreshToken(ctx, refreshRef, refreshInfo); err != nil {
 			return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreRefreshToken, err)
 		}
 	}
@@ -132,24 +180,29 @@ func (s *Service) Login(ctx context.Context, req *core.LoginRequest) (*core.Logi
 	response := &core.LoginResponse{
 		Token:        token,
 		RefreshToken: refreshToken,
-		ExpireTime:   now.Add(s.config.TokenExpire),
+		ExpireTime:   now.Add(s.config.TokenExpireFor(req.Device)),
 		UserInfo: &core.UserInfo{
 			ID:       req.UserID,
 			Username: req.UserID, // 简化处理
 			Extra:    req.Extra,
 		},
 	}
+	if impersonator != nil {
+		response.UserInfo.ImpersonatorID = impersonator.UserID
+	}
 
 	return response, nil
 }
 
 // Logout 用户登出
 func (s *Service) Logout(ctx context.Context, token string) error {
+	tokenRef := s.keyService.TokenRef(token)
+
 	// 获取登录信息以便删除用户会话映射
 	loginInfo, err := s.GetLoginInfo(ctx, token)
 	if err == nil && loginInfo != nil {
 		// 删除用户会话映射
-		userSessionKey := s.keyService.UserSessionKey(loginInfo.UserID, token)
+		userSessionKey := s.keyService.UserSessionKey(loginInfo.UserID, tokenRef)
 		s.storage.Delete(ctx, userSessionKey)
 	}
 
@@ -158,8 +211,11 @@ func (s *Service) Logout(ctx context.Context, token string) error {
 		return fmt.Errorf("%s: %w", core.ErrMsgDeleteSession, err)
 	}
 
+	// 吊销配对的刷新Token，避免登出后通过刷新重新获得Token
+	s.refreshIndex.RevokeByAccess(ctx, tokenRef)
+
 	// 删除登录信息
-	loginKey := s.keyService.LoginInfoKey(token)
+	loginKey := s.keyService.LoginInfoKey(tokenRef)
 	if err := s.storage.Delete(ctx, loginKey); err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgDeleteLoginInfo, err)
 	}
@@ -167,6 +223,30 @@ func (s *Service) Logout(ctx context.Context, token string) error {
 	return nil
 }
 
+// LogoutByRef 根据Token的存储引用登出，用于只保存了Token引用的场景
+func (s *Service) LogoutByRef(ctx context.Context, tokenRef string) error {
+	// 获取登录信息以便删除用户会话映射
+	loginInfo, err := s.getLoginInfoByRef(ctx, tokenRef)
+	if err == nil && loginInfo != nil {
+		s.storage.Delete(ctx, s.keyService.UserSessionKey(loginInfo.UserID, tokenRef))
+	}
+
+	// 删除会话，签名Token按会话记录的 jti 吊销
+	if err := s.deleteSessionByRef(ctx, tokenRef); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDeleteSession, err)
+	}
+
+	// 吊销配对的刷新Token
+	s.refreshIndex.RevokeByAccess(ctx, tokenRef)
+
+	// 删除登录信息
+	if err := s.storage.Delete(ctx, s.keyService.LoginInfoKey(tokenRef)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDeleteLoginInfo, err)
+	}
+
+	return nil
+}
+
 // LogoutByUserID 根据用户ID登出所有会话
 func (s *Service) LogoutByUserID(ctx context.Context, userID string) error {
 	if userID == "" {
@@ -180,42 +260,38 @@ func (s *Service) LogoutByUserID(ctx context.Context, userID string) error {
 		return fmt.Errorf("%s: %w", core.ErrMsgGetUserSessionKeys, err)
 	}
 
-	// 删除每个Token对应的会话和登录信息
+	// 删除每个Token对应的会话和登录信息（映射中保存的是Token引用）
 	for _, sessionKey := range sessionKeys {
-		tokenData, err := s.storage.Get(ctx, sessionKey)
+		tokenRef, err := readTokenRef(ctx, s.storage, sessionKey)
 		if err != nil {
 			continue
 		}
 
-		tokenBytes, ok := tokenData.([]byte)
-		if !ok {
-			continue
-		}
-
-		// 反序列化 JSON 字符串
-		var token string
-		if err := json.Unmarshal(tokenBytes, &token); err != nil {
-			// 如果反序列化失败，尝试直接使用字节数组
-			token = string(tokenBytes)
-		}
-
 		// 删除会话
-		s.sessionService.DeleteSession(ctx, token)
+		s.deleteSessionByRef(ctx, tokenRef)
 
 		// 删除对应的登录信息
-		loginKey := s.keyService.LoginInfoKey(token)
+		loginKey := s.keyService.LoginInfoKey(tokenRef)
 		s.storage.Delete(ctx, loginKey)
 
 		// 删除用户会话映射
 		s.storage.Delete(ctx, sessionKey)
 	}
 
+	// 吊销用户的全部刷新Token
+	s.refreshIndex.RevokeUser(ctx, userID)
+
 	return nil
 }
 
 // GetLoginInfo 获取登录信息
 func (s *Service) GetLoginInfo(ctx context.Context, token string) (*core.LoginInfo, error) {
-	loginKey := s.keyService.LoginInfoKey(token)
+	return s.getLoginInfoByRef(ctx, s.keyService.TokenRef(token))
+}
+
+// getLoginInfoByRef 根据Token引用获取登录信息
+func (s *Service) getLoginInfoByRef(ctx context.Context, tokenRef string) (*core.LoginInfo, error) {
+	loginKey := s.keyService.LoginInfoKey(tokenRef)
 	data, err := s.storage.Get(ctx, loginKey)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetLoginInfo, err)
@@ -235,24 +311,38 @@ func (s *Service) GetLoginInfo(ctx context.Context, token string) (*core.LoginIn
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseLoginInfo, err)
 	}
 
+	// 续期只重置登录信息的TTL，最后访问时间由剩余有效期推算
+	if ttlStorage, ok := s.storage.(core.TTLStorage); ok {
+		if ttl, exists, err := ttlStorage.TTL(ctx, loginKey); err == nil && exists && ttl > 0 {
+			lastAccess := time.Now().Add(ttl - s.config.TokenExpireFor(loginInfo.Device))
+			if lastAccess.After(loginInfo.LastAccess) {
+				loginInfo.LastAccess = lastAccess
+			}
+		}
+	}
+
 	return &loginInfo, nil
 }
 
 // handleLoginMode 处理登录模式
 func (s *Service) handleLoginMode(ctx context.Context, req *core.LoginRequest) error {
-	switch s.config.LoginMode {
+	var err error
+	switch s.config.LoginModeFor(req.Device) {
 	case core.SingleLogin:
 		// 单端登录：踢出该用户的所有其他会话
-		return s.sessionService.KickOut(ctx, req.UserID)
+		err = s.sessionService.KickOut(ctx, req.UserID)
 	case core.MutexLogin:
 		// 同端互斥登录：踢出该用户在同一设备的其他会话
-		return s.kickOutSameDevice(ctx, req.UserID, req.Device)
+		err = s.kickOutSameDevice(ctx, req.UserID, req.Device)
 	case core.MultiLogin:
 		// 多端登录：不做处理
-		return nil
-	default:
-		return nil
 	}
+	if err != nil {
+		return err
+	}
+
+	// 会话数量限制
+	return s.enforceSessionLimit(ctx, req)
 }
 
 // kickOutSameDevice 踢出同一设备的会话
@@ -265,56 +355,79 @@ func (s *Service) kickOutSameDevice(ctx context.Context, userID, device string)
 	}
 
 	for _, key := range keys {
-		tokenData, err := s.storage.Get(ctx, key)
+		tokenRef, err := readTokenRef(ctx, s.storage, key)
 		if err != nil {
 			continue
 		}
 
-		tokenBytes, ok := tokenData.([]byte)
-		if !ok {
-			continue
-		}
-
-		// 反序列化 JSON 字符串
-		var token string
-		if err := json.Unmarshal(tokenBytes, &token); err != nil {
-			// 如果反序列化失败，尝试直接使用字节数组
-			token = string(tokenBytes)
-		}
-
 		// 获取会话详情
-		session, err := s.sessionService.GetSession(ctx, token)
+		session, err := s.getSessionByRef(ctx, tokenRef)
 		if err != nil {
 			continue
 		}
 
 		// 如果是同一设备，则删除会话
 		if session.Device == device {
-			s.sessionService.DeleteSession(ctx, session.Token)
+			s.deleteSessionByRef(ctx, session.Token)
 		}
 	}
 
 	return nil
 }
 
-// storeLoginInfo 存储登录信息
-func (s *Service) storeLoginInfo(ctx context.Context, token string, loginInfo *core.LoginInfo) error {
-	loginKey := s.keyService.LoginInfoKey(token)
+// getSessionByRef 根据Token引用获取会话
+func (s *Service) getSessionByRef(ctx context.Context, tokenRef string) (*core.Session, error) {
+	if accessor, ok := s.sessionService.(sessionRefAccessor); ok {
+		return accessor.GetSessionByRef(ctx, tokenRef)
+	}
+	// 自定义会话服务不区分Token与引用
+	return s.sessionService.GetSession(ctx, tokenRef)
+}
+
+// deleteSessionByRef 根据Token引用删除会话
+func (s *Service) deleteSessionByRef(ctx context.Context, tokenRef string) error {
+	if accessor, ok := s.sessionService.(sessionRefAccessor); ok {
+		return accessor.DeleteSessionByRef(ctx, tokenRef)
+	}
+	// 自定义会话服务不区分Token与引用
+	return s.sessionService.DeleteSession(ctx, tokenRef)
+}
+
+// tokenID 获取签名Token的 jti（非自包含风格返回空）
+func (s *Service) tokenID(token string) string {
+	if !s.config.TokenStyle.SelfContained() {
+		return ""
+	}
+	tokenInfo, err := s.tokenGenerator.Parse(token)
+	if err != nil {
+		return ""
+	}
+	return tokenInfo.ID
+}
+
+// storeLoginInfo 存储登录信息（tokenRef 为Token的存储引用）
+func (s *Service) storeLoginInfo(ctx context.Context, tokenRef string, loginInfo *core.LoginInfo) error {
+	loginKey := s.keyService.LoginInfoKey(tokenRef)
 	// 直接存储 loginInfo 对象，让 storage.Set 内部进行 JSON 序列化
-	return s.storage.Set(ctx, loginKey, loginInfo, s.config.TokenExpire)
+	return s.storage.Set(ctx, loginKey, loginInfo, s.config.TokenExpireFor(loginInfo.Device))
 }
 
-// storeRefreshToken 存储刷新Token信息
-func (s *Service) storeRefreshToken(ctx context.Context, refreshToken string, refreshInfo *core.RefreshTokenInfo) error {
-	refreshKey := s.keyService.RefreshTokenKey(refreshToken)
+// storeRefreshToken 存储刷新Token信息（refreshRef 为刷新Token的存储引用）
+func (s *Service) storeRefreshToken(ctx context.Context, refreshRef string, refreshInfo *core.RefreshTokenInfo) error {
+	refreshKey := s.keyService.RefreshTokenKey(refreshRef)
 	// 直接存储 refreshInfo 对象，让 storage.Set 内部进行 JSON 序列化
-	return s.storage.Set(ctx, refreshKey, refreshInfo, s.config.RefreshExpire)
+	if err := s.storage.Set(ctx, refreshKey, refreshInfo, s.config.RefreshExpireFor(refreshInfo.Device)); err != nil {
+		return err
+	}
+	// 登记用户索引与访问Token配对，登出和踢人下线时一并吊销
+	return s.refreshIndex.Add(ctx, refreshInfo)
 }
 
-// getRefreshTokenInfo 获取刷新Token信息
-func (s *Service) getRefreshTokenInfo(ctx context.Context, refreshToken string) (*core.RefreshTokenInfo, error) {
-	refreshKey := s.keyService.RefreshTokenKey(refreshToken)
-	data, err := s.storage.Get(ctx, refreshKey)
+// consumeRefreshToken 原子读取并删除刷新Token信息（refreshRef 为刷新Token的存储引用）
+// 并发使用同一刷新Token时只有一个请求能取得记录；取得后立即标记为已使用并清理索引
+func (s *Service) consumeRefreshToken(ctx context.Context, refreshRef string) (*core.RefreshTokenInfo, error) {
+	refreshKey := s.keyService.RefreshTokenKey(refreshRef)
+	data, err := storage.GetDel(ctx, s.storage, refreshKey)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetRefreshTokenInfo, err)
 	}
@@ -333,33 +446,94 @@ func (s *Service) getRefreshTokenInfo(ctx context.Context, refreshToken string)
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseRefreshTokenInfo, err)
 	}
 
+	// 旧Token标记保留到其原本的过期时间，期间再次出现即视为重放
+	if ttl := time.Until(refreshInfo.ExpiresAt); ttl > 0 && refreshInfo.FamilyID != "" {
+		if err := s.storage.Set(ctx, s.keyService.UsedRefreshTokenKey(refreshRef), refreshInfo.FamilyID, ttl); err != nil {
+			return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreRefreshFamily, err)
+		}
+	}
+	s.refreshIndex.Remove(ctx, &refreshInfo)
+
 	return &refreshInfo, nil
 }
 
 // RefreshAccessToken 刷新访问Token
 func (s *Service) RefreshAccessToken(ctx context.Context, refreshToken string) (*core.LoginResponse, error) {
-	if refreshToken == "" {
+	return s.Refresh(ctx, &core.RefreshRequest{RefreshToken: refreshToken})
+}
+
+// Refresh 使用刷新请求获取新的访问Token
+// 新Token沿用原登录的设备与额外信息，并与登录一样执行登录模式检查；与旧刷新Token配对的访问Token被吊销
+func (s *Service) Refresh(ctx context.Context, req *core.RefreshRequest) (*core.LoginResponse, error) {
+	if req == nil || req.RefreshToken == "" {
 		return nil, errors.New(core.ErrMsgRefreshTokenEmpty)
 	}
 
-	// 获取刷新Token信息
-	refreshInfo, err := s.getRefreshTokenInfo(ctx, refreshToken)
+	// 先消费刷新Token，之后的任何失败都不会使其恢复可用
+	refreshRef := s.keyService.TokenRef(req.RefreshToken)
+	refreshInfo, err := s.consumeRefreshToken(ctx, refreshRef)
 	if err != nil {
+		// 已轮换的旧Token再次出现，说明刷新Token可能被盗用，吊销整个Token族
+		if reuseErr := s.detectRefreshReuse(ctx, refreshRef); reuseErr != nil {
+			return nil, reuseErr
+		}
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetRefreshTokenInfo, err)
 	}
 
 	// 检查刷新Token是否过期
 	if time.Now().After(refreshInfo.ExpiresAt) {
-		// 删除过期的刷新Token
-		s.storage.Delete(ctx, s.keyService.RefreshTokenKey(refreshToken))
 		return nil, errors.New(core.ErrMsgRefreshTokenExpired)
 	}
 
+	// 刷新不延长会话的绝对有效期
+	authTime := refreshInfo.AuthTime
+	if authTime.IsZero() {
+		authTime = refreshInfo.CreatedAt
+	}
+	if maxLifetime := s.config.MaxLifetimeFor(refreshInfo.Device); maxLifetime > 0 && time.Now().After(authTime.Add(maxLifetime)) {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgRefreshTokenExpired, core.ErrSessionLifetimeExceeded)
+	}
+
+	// 被封禁的账号不允许刷新
+	if err := s.disableService.CheckDisabled(ctx, refreshInfo.UserID, core.DisableServiceAll); err != nil {
+		return nil, err
+	}
+
+	// 未携带IP时沿用原会话的IP
+	ip := req.IP
+	if ip == "" && refreshInfo.AccessToken != "" {
+		if oldSession, err := s.getSessionByRef(ctx, refreshInfo.AccessToken); err == nil {
+			ip = oldSession.IP
+		}
+	}
+
+	// 吊销与旧刷新Token配对的访问Token
+	if refreshInfo.AccessToken != "" {
+		s.revokeAccessByRef(ctx, refreshInfo.UserID, refreshInfo.AccessToken)
+	}
+
+	// 与登录一致地执行登录模式检查
+	loginReq := &core.LoginRequest{
+		UserID: refreshInfo.UserID,
+		Device: refreshInfo.Device,
+		IP:     ip,
+		Extra:  refreshInfo.Extra,
+	}
+	if err := s.handleLoginMode(ctx, loginReq); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgHandleLoginMode, err)
+	}
+
 	// 生成新的访问Token
 	tokenExtra := map[string]interface{}{
 		core.TokenExtraKeyUserID: refreshInfo.UserID,
-		core.TokenFlagRefresh:    true,
+		core.TokenExtraKeyDevice: refreshInfo.Device,
+		core.TokenExtraKeyIP:     ip,
+	}
+	for k, v := range refreshInfo.Extra {
+		tokenExtra[k] = v
 	}
+	tokenExtra[core.TokenFlagRefresh] = true
+	tokenExtra[core.TokenExtraKeyAuthTime] = authTime
 
 	newAccessToken, err := s.tokenGenerator.Generate(tokenExtra)
 	if err != nil {
@@ -369,6 +543,7 @@ func (s *Service) RefreshAccessToken(ctx context.Context, refreshToken string) (
 	// 生成新的刷新Token
 	newRefreshTokenExtra := map[string]interface{}{
 		core.TokenExtraKeyUserID: refreshInfo.UserID,
+		core.TokenExtraKeyDevice: refreshInfo.Device,
 		core.TokenExtraKeyType:   core.TokenTypeRefresh,
 	}
 
@@ -377,20 +552,27 @@ func (s *Service) RefreshAccessToken(ctx context.Context, refreshToken string) (
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgGenerateNewRefreshToken, err)
 	}
 
-	// 删除旧的刷新Token
-	s.storage.Delete(ctx, s.keyService.RefreshTokenKey(refreshToken))
+	// 轮换刷新Token族
+	newAccessRef := s.keyService.TokenRef(newAccessToken)
+	newRefreshRef := s.keyService.TokenRef(newRefreshToken)
+	familyID, err := s.rotateRefreshFamily(ctx, refreshInfo, newAccessRef, newRefreshRef)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建新的会话
 	now := time.Now()
 	session := &core.Session{
-		ID:         newAccessToken,
+		ID:         newAccessRef,
 		UserID:     refreshInfo.UserID,
 		Token:      newAccessToken,
-		Device:     "", // 刷新时设备信息可能不可用
-		IP:         "", // 刷新时IP信息可能不可用
+		TokenID:    s.tokenID(newAccessToken),
+		Device:     refreshInfo.Device,
+		IP:         ip,
 		LoginTime:  now,
+		AuthTime:   authTime,
 		LastAccess: now,
-		Extra:      make(map[string]interface{}),
+		Extra:      refreshInfo.Extra,
 	}
 
 	if err := s.sessionService.CreateSession(ctx, session); err != nil {
@@ -400,47 +582,67 @@ func (s *Service) RefreshAccessToken(ctx context.Context, refreshToken string) (
 	// 存储新的登录信息
 	loginInfo := &core.LoginInfo{
 		UserID:     refreshInfo.UserID,
-		Token:      newAccessToken,
-		Device:     "",
-		IP:         "",
+		Token:      newAccessRef,
+		Device:     refreshInfo.Device,
+		IP:         ip,
 		LoginTime:  now,
+		AuthTime:   authTime,
 		LastAccess: now,
+		Extra:      refreshInfo.Extra,
 	}
 
-	if err := s.storeLoginInfo(ctx, newAccessToken, loginInfo); err != nil {
+	if err := s.storeLoginInfo(ctx, newAccessRef, loginInfo); err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreNewLoginInfo, err)
 	}
 
 	// 存储新的刷新Token
 	newRefreshInfo := &core.RefreshTokenInfo{
-		RefreshToken: newRefreshToken,
+		RefreshToken: newRefreshRef,
+		AccessToken:  newAccessRef,
+		FamilyID:     familyID,
 		UserID:       refreshInfo.UserID,
 		Device:       refreshInfo.Device,
 		CreatedAt:    now,
-		ExpiresAt:    now.Add(s.config.RefreshExpire),
+		AuthTime:     authTime,
+		ExpiresAt:    now.Add(s.config.RefreshExpireFor(refreshInfo.Device)),
 		Extra:        refreshInfo.Extra,
 	}
 
-	if err := s.storeRefreshToken(ctx, newRefreshToken, newRefreshInfo); err != nil {
+	if err := s.storeRefreshToken(ctx, newRefreshRef, newRefreshInfo); err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgStoreNewRefreshToken, err)
 	}
 
+	// 轮换期间族被吊销时，吊销方可能没有读到本次写入的Token，由本次刷新自行撤销
+	if err := s.checkRefreshFamily(ctx, familyID); err != nil {
+		s.revokeAccessByRef(ctx, refreshInfo.UserID, newAccessRef)
+		s.refreshIndex.Revoke(ctx, newRefreshRef)
+		return nil, err
+	}
+
 	// 构造响应
 	response := &core.LoginResponse{
 		Token:        newAccessToken,
 		RefreshToken: newRefreshToken,
-		ExpireTime:   now.Add(s.config.TokenExpire),
+		ExpireTime:   now.Add(s.config.TokenExpireFor(refreshInfo.Device)),
 		UserInfo: &core.UserInfo{
 			ID:       refreshInfo.UserID,
 			Username: refreshInfo.UserID, // 简化处理
+			Extra:    refreshInfo.Extra,
 		},
 	}
 
 	return response, nil
 }
 
-// storeUserSessionMapping 存储用户会话映射
-func (s *Service) storeUserSessionMapping(ctx context.Context, userID, token string) error {
-	userSessionKey := s.keyService.UserSessionKey(userID, token)
-	return s.storage.Set(ctx, userSessionKey, token, s.config.TokenExpire)
+// revokeAccessByRef 根据Token引用吊销访问Token：删除会话、登录信息与用户会话映射
+func (s *Service) revokeAccessByRef(ctx context.Context, userID, accessRef string) {
+	s.deleteSessionByRef(ctx, accessRef)
+	s.storage.Delete(ctx, s.keyService.LoginInfoKey(accessRef))
+	s.storage.Delete(ctx, s.keyService.UserSessionKey(userID, accessRef))
+}
+
+// storeUserSessionMapping 存储用户会话映射（值为Token的存储引用）
+func (s *Service) storeUserSessionMapping(ctx context.Context, userID, device, tokenRef string) error {
+	userSessionKey := s.keyService.UserSessionKey(userID, tokenRef)
+	return s.storage.Set(ctx, userSessionKey, tokenRef, s.config.TokenExpireFor(device))
 }
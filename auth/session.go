@@ -9,23 +9,46 @@ import (
 	"github.com/luckxgo/gstoken/core"
 )
 
+// sessionRefAccessor 支持按Token存储引用访问会话的会话服务
+// 用户会话映射中仅保存Token引用，踢人下线等流程需按引用操作
+type sessionRefAccessor interface {
+	GetSessionByRef(ctx context.Context, ref string) (*core.Session, error)
+	DeleteSessionByRef(ctx context.Context, ref string) error
+}
+
 // SessionService 会话服务实现
 type SessionServiceImpl struct {
-	storage    core.Storage
-	config     *core.Config
-	keyService *core.KeyService
+	storage      core.Storage
+	config       *core.Config
+	keyService   *core.KeyService
+	revocation   *revocationList
+	refreshIndex *refreshIndex
+	onEnd        core.SessionEndFunc
 }
 
 // NewSessionService 创建新的会话服务
+// 未指定Token生成器时无法解析签名Token，删除会话不会写入吊销记录；自包含风格的Token请使用 NewSessionServiceWithGenerator
 func NewSessionService(storage core.Storage, config *core.Config, keyService *core.KeyService) core.SessionService {
+	return NewSessionServiceWithGenerator(storage, nil, config, keyService)
+}
+
+// NewSessionServiceWithGenerator 创建会话服务，使用Token生成器解析签名Token的 jti 以写入吊销记录
+func NewSessionServiceWithGenerator(storage core.Storage, tokenGenerator core.TokenGenerator, config *core.Config, keyService *core.KeyService) core.SessionService {
 	return &SessionServiceImpl{
-		storage:    storage,
-		config:     config,
-		keyService: keyService,
+		storage:      storage,
+		config:       config,
+		keyService:   keyService,
+		revocation:   newRevocationList(storage, tokenGenerator, config, keyService),
+		refreshIndex: newRefreshIndex(storage, config, keyService),
 	}
 }
 
-// CreateSession 创建会话
+// OnSessionEnd 设置会话删除后的回调，登出、踢出、同端互斥与会话数量限制删除会话时均会调用，需在使用前设置
+func (s *SessionServiceImpl) OnSessionEnd(fn core.SessionEndFunc) {
+	s.onEnd = fn
+}
+
+// CreateSession 创建会话，session.Token 为原始Token，存储时按 KeyService.TokenRef 转换为引用
 func (s *SessionServiceImpl) CreateSession(ctx context.Context, session *core.Session) error {
 	if session == nil {
 		return errors.New(core.ErrMsgSessionInfoEmpty)
@@ -40,14 +63,15 @@ func (s *SessionServiceImpl) CreateSession(ctx context.Context, session *core.Se
 	}
 
 	// 存储会话数据 - 直接存储 session 对象，让 storage.Set 内部进行 JSON 序列化
-	sessionKey := s.keyService.SessionKey(session.Token)
-	if err := s.storage.Set(ctx, sessionKey, session, s.config.TokenExpire); err != nil {
+	ref, stored := s.storedSession(session)
+	sessionKey := s.keyService.SessionKey(ref)
+	if err := s.storage.Set(ctx, sessionKey, stored, s.config.TokenExpireFor(session.Device)); err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgStoreSessionData, err)
 	}
 
-	// 存储用户会话映射（用于踢人下线）
-	userSessionKey := s.keyService.UserSessionKey(session.UserID, session.Token)
-	if err := s.storage.Set(ctx, userSessionKey, session.Token, s.config.TokenExpire); err != nil {
+	// 存储用户会话映射（用于踢人下线），映射中保存Token引用
+	userSessionKey := s.keyService.UserSessionKey(session.UserID, ref)
+	if err := s.storage.Set(ctx, userSessionKey, ref, s.config.TokenExpireFor(session.Device)); err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgStoreUserSessionMapping, err)
 	}
 
@@ -60,7 +84,16 @@ func (s *SessionServiceImpl) GetSession(ctx context.Context, token string) (*cor
 		return nil, errors.New(core.ErrMsgTokenEmpty)
 	}
 
-	sessionKey := s.keyService.SessionKey(token)
+	return s.GetSessionByRef(ctx, s.keyService.TokenRef(token))
+}
+
+// GetSessionByRef 根据Token的存储引用获取会话（引用见 KeyService.TokenRef）
+func (s *SessionServiceImpl) GetSessionByRef(ctx context.Context, ref string) (*core.Session, error) {
+	if ref == "" {
+		return nil, errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	sessionKey := s.keyService.SessionKey(ref)
 	data, err := s.storage.Get(ctx, sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetSessionData, err)
@@ -83,7 +116,7 @@ func (s *SessionServiceImpl) GetSession(ctx context.Context, token string) (*cor
 	return &session, nil
 }
 
-// UpdateSession 更新会话
+// UpdateSession 更新会话，session.Token 为原始Token
 func (s *SessionServiceImpl) UpdateSession(ctx context.Context, session *core.Session) error {
 	if session == nil {
 		return errors.New(core.ErrMsgSessionInfoEmpty)
@@ -94,7 +127,9 @@ func (s *SessionServiceImpl) UpdateSession(ctx context.Context, session *core.Se
 	}
 
 	// 检查会话是否存在
-	exists, err := s.storage.Exists(ctx, s.keyService.SessionKey(session.Token))
+	ref, stored := s.storedSession(session)
+	sessionKey := s.keyService.SessionKey(ref)
+	exists, err := s.storage.Exists(ctx, sessionKey)
 	if err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgCheckSessionExists, err)
 	}
@@ -104,39 +139,79 @@ func (s *SessionServiceImpl) UpdateSession(ctx context.Context, session *core.Se
 	}
 
 	// 更新会话数据 - 直接存储 session 对象，让 storage.Set 内部进行 JSON 序列化
-	sessionKey := s.keyService.SessionKey(session.Token)
-	if err := s.storage.Set(ctx, sessionKey, session, s.config.TokenExpire); err != nil {
+	if err := s.storage.Set(ctx, sessionKey, stored, s.config.TokenExpireFor(session.Device)); err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgUpdateSessionData, err)
 	}
 
 	return nil
 }
 
+// storedSession 返回Token引用与待存储的会话副本，副本中的 Token 与 ID 替换为引用，存储中不出现原始Token
+func (s *SessionServiceImpl) storedSession(session *core.Session) (string, *core.Session) {
+	ref := s.keyService.TokenRef(session.Token)
+	stored := *session
+	if stored.ID == "" || stored.ID == session.Token {
+		stored.ID = ref
+	}
+	stored.Token = ref
+	return ref, &stored
+}
+
 // DeleteSession 删除会话
 func (s *SessionServiceImpl) DeleteSession(ctx context.Context, token string) error {
 	if token == "" {
 		return errors.New(core.ErrMsgTokenEmpty)
 	}
 
+	// 签名Token无法通过删除存储数据失效，需要写入吊销记录
+	if err := s.revocation.Revoke(ctx, token); err != nil {
+		return err
+	}
+
+	return s.DeleteSessionByRef(ctx, s.keyService.TokenRef(token))
+}
+
+// DeleteSessionByRef 根据Token的存储引用删除会话（引用见 KeyService.TokenRef）
+func (s *SessionServiceImpl) DeleteSessionByRef(ctx context.Context, ref string) error {
+	if ref == "" {
+		return errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	// 吊销配对的刷新Token（会话已过期时刷新Token可能仍然有效）
+	s.refreshIndex.RevokeByAccess(ctx, ref)
+
+	// 删除登录信息，被踢出的Token在校验时立即失效，不依赖续期时的会话检查
+	if err := s.storage.Delete(ctx, s.keyService.LoginInfoKey(ref)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDeleteLoginInfo, err)
+	}
+
 	// 先获取会话信息以便删除用户会话映射
-	session, err := s.GetSession(ctx, token)
+	session, err := s.GetSessionByRef(ctx, ref)
 	if err != nil {
 		// 如果会话不存在，直接返回成功
 		return nil
 	}
 
+	// 仅持有Token引用时，按会话记录的 jti 吊销签名Token
+	if err := s.revocation.RevokeSession(ctx, session); err != nil {
+		return err
+	}
+
 	// 删除会话数据
-	sessionKey := s.keyService.SessionKey(token)
+	sessionKey := s.keyService.SessionKey(ref)
 	if err := s.storage.Delete(ctx, sessionKey); err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgDeleteSessionData, err)
 	}
 
 	// 删除用户会话映射
-	userSessionKey := s.keyService.UserSessionKey(session.UserID, token)
+	userSessionKey := s.keyService.UserSessionKey(session.UserID, ref)
 	if err := s.storage.Delete(ctx, userSessionKey); err != nil {
 		// 删除映射失败不影响主要操作
 	}
 
+	if s.onEnd != nil {
+		s.onEnd(ctx, session.UserID, ref)
+	}
 	return nil
 }
 
@@ -146,36 +221,27 @@ func (s *SessionServiceImpl) KickOut(ctx context.Context, userID string) error {
 		return errors.New(core.ErrMsgUserIDEmpty)
 	}
 
-	// 获取用户的所有会话Token
+	// 获取用户的所有会话Token引用
 	pattern := s.keyService.UserSessionPattern(userID)
 	keys, err := s.storage.Keys(ctx, pattern)
 	if err != nil {
 		return fmt.Errorf("%s: %w", core.ErrMsgGetUserSessionList, err)
 	}
 
-	// 删除所有会话
+	// 删除所有会话（映射中保存的是Token引用）
 	for _, key := range keys {
-		tokenData, err := s.storage.Get(ctx, key)
+		ref, err := readTokenRef(ctx, s.storage, key)
 		if err != nil {
 			continue
 		}
 
-		tokenBytes, ok := tokenData.([]byte)
-		if !ok {
-			continue
-		}
-
-		// 反序列化 JSON 字符串
-		var token string
-		if err := json.Unmarshal(tokenBytes, &token); err != nil {
-			// 如果反序列化失败，尝试直接使用字节数组
-			token = string(tokenBytes)
-		}
-
 		// 删除会话
-		s.DeleteSession(ctx, token)
+		s.DeleteSessionByRef(ctx, ref)
 	}
 
+	// 吊销用户的全部刷新Token
+	s.refreshIndex.RevokeUser(ctx, userID)
+
 	return nil
 }
 
@@ -183,3 +249,24 @@ func (s *SessionServiceImpl) KickOut(ctx context.Context, userID string) error {
 func (s *SessionServiceImpl) KickOutByToken(ctx context.Context, token string) error {
 	return s.DeleteSession(ctx, token)
 }
+
+// readTokenRef 读取用户会话映射中保存的Token引用
+func readTokenRef(ctx context.Context, storage core.Storage, key string) (string, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return "", errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	// 反序列化 JSON 字符串
+	var ref string
+	if err := json.Unmarshal(dataBytes, &ref); err != nil {
+		// 如果反序列化失败，尝试直接使用字节数组
+		ref = string(dataBytes)
+	}
+	return ref, nil
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"sort"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// enforceSessionLimit 执行会话数量限制
+// 先检查用户总会话数（MaxSessionsPerUser），再检查该设备的会话数（DeviceProfile.MaxSessions），
+// 超限时按淘汰策略踢出旧会话或拒绝新的登录
+func (s *Service) enforceSessionLimit(ctx context.Context, req *core.LoginRequest) error {
+	userLimit := s.config.MaxSessionsPerUser
+	deviceLimit := s.config.MaxSessionsFor(req.Device)
+	if userLimit <= 0 && deviceLimit <= 0 {
+		return nil
+	}
+
+	sessions, err := s.listUserSessions(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if userLimit > 0 {
+		remaining, err := s.evictSessions(ctx, sessions, userLimit, req.UserID, "")
+		if err != nil {
+			return err
+		}
+		sessions = remaining
+	}
+
+	if deviceLimit > 0 {
+		var deviceSessions []*core.Session
+		for _, session := range sessions {
+			if session.Device == req.Device {
+				deviceSessions = append(deviceSessions, session)
+			}
+		}
+		if _, err := s.evictSessions(ctx, deviceSessions, deviceLimit, req.UserID, req.Device); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evictSessions 为新会话腾出位置，返回淘汰后剩余的会话
+func (s *Service) evictSessions(ctx context.Context, sessions []*core.Session, limit int, userID, device string) ([]*core.Session, error) {
+	excess := len(sessions) - limit + 1
+	if excess <= 0 {
+		return sessions, nil
+	}
+
+	if s.config.SessionEvictPolicy == core.EvictReject {
+		return nil, &core.MaxSessionsError{UserID: userID, Device: device, Limit: limit}
+	}
+
+	s.sortForEviction(sessions)
+	for _, session := range sessions[:excess] {
+		s.revokeAccessByRef(ctx, userID, session.Token)
+	}
+	return sessions[excess:], nil
+}
+
+// recheckSessionLimit 新会话写入后再次检查会话数量
+// 并发登录都可能在预检查时看到未超限，最后写入的登录一定能看到其余会话：
+// 拒绝策略下超限即拒绝本次登录；淘汰策略下按统一顺序淘汰超出的会话，本次会话被淘汰时同样拒绝
+func (s *Service) recheckSessionLimit(ctx context.Context, req *core.LoginRequest, tokenRef string) error {
+	userLimit := s.config.MaxSessionsPerUser
+	deviceLimit := s.config.MaxSessionsFor(req.Device)
+	if userLimit <= 0 && deviceLimit <= 0 {
+		return nil
+	}
+
+	sessions, err := s.listUserSessions(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if userLimit > 0 {
+		remaining, err := s.trimSessions(ctx, sessions, userLimit, req.UserID, "", tokenRef)
+		if err != nil {
+			return err
+		}
+		sessions = remaining
+	}
+
+	if deviceLimit > 0 {
+		var deviceSessions []*core.Session
+		for _, session := range sessions {
+			if session.Device == req.Device {
+				deviceSessions = append(deviceSessions, session)
+			}
+		}
+		if _, err := s.trimSessions(ctx, deviceSessions, deviceLimit, req.UserID, req.Device, tokenRef); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trimSessions 淘汰超出上限的会话（已包含本次登录的会话 tokenRef），返回剩余的会话
+func (s *Service) trimSessions(ctx context.Context, sessions []*core.Session, limit int, userID, device, tokenRef string) ([]*core.Session, error) {
+	excess := len(sessions) - limit
+	if excess <= 0 {
+		return sessions, nil
+	}
+
+	limitErr := &core.MaxSessionsError{UserID: userID, Device: device, Limit: limit}
+	if s.config.SessionEvictPolicy == core.EvictReject {
+		return nil, limitErr
+	}
+
+	s.sortForEviction(sessions)
+	evictedSelf := false
+	for _, session := range sessions[:excess] {
+		if session.Token == tokenRef {
+			evictedSelf = true
+			continue
+		}
+		s.revokeAccessByRef(ctx, userID, session.Token)
+	}
+	if evictedSelf {
+		return nil, limitErr
+	}
+	return sessions[excess:], nil
+}
+
+// sortForEviction 按淘汰策略排序，最先被淘汰的会话在前；时间相同时按会话ID排序，保证并发登录得到相同顺序
+func (s *Service) sortForEviction(sessions []*core.Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, b := sessions[i].LoginTime, sessions[j].LoginTime
+		if s.config.SessionEvictPolicy == core.EvictLRU {
+			a, b = sessions[i].LastAccess, sessions[j].LastAccess
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+}
+
+// listUserSessions 获取用户当前的全部会话
+func (s *Service) listUserSessions(ctx context.Context, userID string) ([]*core.Session, error) {
+	keys, err := s.storage.Keys(ctx, s.keyService.UserSessionPattern(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*core.Session, 0, len(keys))
+	for _, key := range keys {
+		tokenRef, err := readTokenRef(ctx, s.storage, key)
+		if err != nil {
+			continue
+		}
+		session, err := s.getSessionByRef(ctx, tokenRef)
+		if err != nil {
+			// 会话已过期，清理残留的映射
+			s.storage.Delete(ctx, key)
+			continue
+		}
+		// 续期只重置会话TTL，最后访问时间以登录信息为准
+		if loginInfo, err := s.getLoginInfoByRef(ctx, tokenRef); err == nil && loginInfo.LastAccess.After(session.LastAccess) {
+			session.LastAccess = loginInfo.LastAccess
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+// tempTokenRecord 临时Token存储记录
+type tempTokenRecord struct {
+	Purpose   string    `json:"purpose"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TempTokenService 临时Token服务默认实现
+// Token由 core.TokenGenerator 按配置的风格生成，存储记录按用途隔离，消费时原子读取并删除
+type TempTokenService struct {
+	storage        core.Storage
+	tokenGenerator core.TokenGenerator
+	keyService     *core.KeyService
+}
+
+// NewTempTokenService 创建新的临时Token服务
+func NewTempTokenService(storage core.Storage, tokenGenerator core.TokenGenerator, keyService *core.KeyService) core.TempTokenService {
+	return &TempTokenService{
+		storage:        storage,
+		tokenGenerator: tokenGenerator,
+		keyService:     keyService,
+	}
+}
+
+// CreateTempToken 创建临时Token
+func (t *TempTokenService) CreateTempToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error) {
+	if purpose == "" {
+		return "", errors.New(core.ErrMsgPurposeEmpty)
+	}
+	if ttl <= 0 {
+		return "", errors.New(core.ErrMsgTempTTLInvalid)
+	}
+
+	token, err := t.tokenGenerator.Generate(map[string]interface{}{
+		core.TokenExtraKeyType:    core.TokenTypeTemp,
+		core.TokenExtraKeyPurpose: purpose,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateToken, err)
+	}
+
+	record := &tempTokenRecord{
+		Purpose:   purpose,
+		Value:     value,
+		CreatedAt: time.Now(),
+	}
+	key := t.keyService.TempTokenKey(purpose, t.keyService.TokenRef(token))
+	if err := t.storage.Set(ctx, key, record, ttl); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgStoreTempToken, err)
+	}
+
+	return token, nil
+}
+
+// ConsumeTempToken 消费临时Token，成功后Token立即失效
+func (t *TempTokenService) ConsumeTempToken(ctx context.Context, purpose, token string) (string, error) {
+	if purpose == "" {
+		return "", errors.New(core.ErrMsgPurposeEmpty)
+	}
+	if token == "" {
+		return "", errors.New(core.ErrMsgTokenEmpty)
+	}
+
+	data, err := storage.GetDel(ctx, t.storage, t.keyService.TempTokenKey(purpose, t.keyService.TokenRef(token)))
+	if err != nil || data == nil {
+		return "", core.ErrTempTokenInvalid
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return "", errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var record tempTokenRecord
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgConsumeTempToken, err)
+	}
+	if record.Purpose != purpose {
+		return "", core.ErrTempTokenInvalid
+	}
+
+	return record.Value, nil
+}
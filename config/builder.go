@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/ed25519"
 	"time"
 
 	"github.com/luckxgo/gstoken/core"
@@ -24,6 +25,49 @@ func (b *ConfigBuilder) WithUserRoleProvider(provider core.UserRoleProvider) *Co
 	return b
 }
 
+// WithSecurityEventListener 设置安全事件监听器
+func (b *ConfigBuilder) WithSecurityEventListener(listener core.SecurityEventListener) *ConfigBuilder {
+	b.config.SecurityEventListener = listener
+	return b
+}
+
+// WithCredentialVerifier 设置凭证校验器，启用密码登录
+func (b *ConfigBuilder) WithCredentialVerifier(verifier core.CredentialVerifier) *ConfigBuilder {
+	b.config.CredentialVerifier = verifier
+	return b
+}
+
+// WithLoginAttemptPolicy 设置登录失败限制策略
+func (b *ConfigBuilder) WithLoginAttemptPolicy(policy core.LoginAttemptPolicy) *ConfigBuilder {
+	b.config.LoginAttempt = policy
+	return b
+}
+
+// WithOTP 设置验证码登录配置
+func (b *ConfigBuilder) WithOTP(otp core.OTPConfig) *ConfigBuilder {
+	b.config.OTP = otp
+	return b
+}
+
+// WithCodeSender 设置验证码发送器与登录标识解析器，resolver 为空时登录标识即用户ID
+func (b *ConfigBuilder) WithCodeSender(sender core.CodeSender, resolver core.IdentifierResolver) *ConfigBuilder {
+	b.config.CodeSender = sender
+	b.config.IdentifierResolver = resolver
+	return b
+}
+
+// WithAPIKeyPrefix 设置 API Key 前缀
+func (b *ConfigBuilder) WithAPIKeyPrefix(prefix string) *ConfigBuilder {
+	b.config.APIKeyPrefix = prefix
+	return b
+}
+
+// WithMFA 设置 TOTP 多因素认证配置
+func (b *ConfigBuilder) WithMFA(mfa core.MFAConfig) *ConfigBuilder {
+	b.config.MFA = mfa
+	return b
+}
+
 // WithTokenExpire 设置Token过期时间
 func (b *ConfigBuilder) WithTokenExpire(expire time.Duration) *ConfigBuilder {
 	b.config.TokenExpire = expire
@@ -42,18 +86,147 @@ func (b *ConfigBuilder) WithTokenStyle(style core.TokenStyle) *ConfigBuilder {
 	return b
 }
 
+// WithVerifyMode 设置Token校验模式
+func (b *ConfigBuilder) WithVerifyMode(mode core.VerifyMode) *ConfigBuilder {
+	b.config.VerifyMode = mode
+	return b
+}
+
+// WithJWT 设置JWT签名配置，并切换为JWT风格
+func (b *ConfigBuilder) WithJWT(jwt core.JWTConfig) *ConfigBuilder {
+	b.config.TokenStyle = core.StyleJWT
+	b.config.JWT = jwt
+	return b
+}
+
+// WithJWTSecret 使用HS256密钥签发JWT
+func (b *ConfigBuilder) WithJWTSecret(secret []byte) *ConfigBuilder {
+	b.config.TokenStyle = core.StyleJWT
+	b.config.JWT.Algorithm = core.JWTAlgHS256
+	b.config.JWT.Secret = secret
+	return b
+}
+
+// WithPasetoLocal 使用 PASETO v4.local（32字节对称密钥）
+func (b *ConfigBuilder) WithPasetoLocal(key []byte) *ConfigBuilder {
+	b.config.TokenStyle = core.StylePasetoLocal
+	b.config.Paseto.LocalKey = key
+	return b
+}
+
+// WithPasetoPublic 使用 PASETO v4.public（Ed25519），仅校验方可只传公钥
+func (b *ConfigBuilder) WithPasetoPublic(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *ConfigBuilder {
+	b.config.TokenStyle = core.StylePasetoPublic
+	b.config.Paseto.PrivateKey = privateKey
+	b.config.Paseto.PublicKey = publicKey
+	return b
+}
+
+// WithPasetoAssertions 设置 PASETO 页脚与隐式断言
+func (b *ConfigBuilder) WithPasetoAssertions(footer, implicitAssertion string) *ConfigBuilder {
+	b.config.Paseto.Footer = footer
+	b.config.Paseto.ImplicitAssertion = implicitAssertion
+	return b
+}
+
+// WithChecksumToken 使用带HMAC校验码的随机Token
+func (b *ConfigBuilder) WithChecksumToken(secret []byte) *ConfigBuilder {
+	b.config.TokenStyle = core.StyleChecksum
+	b.config.ChecksumSecret = secret
+	return b
+}
+
+// WithTokenHashing 开启Token摘要存储，secret 为空时使用 SHA-256，否则使用 HMAC-SHA256
+func (b *ConfigBuilder) WithTokenHashing(secret []byte) *ConfigBuilder {
+	b.config.TokenHashing = true
+	b.config.TokenHashSecret = secret
+	return b
+}
+
+// WithEncryptionKeys 开启存储加密（AES-GCM），第一个密钥用于加密，其余密钥仅用于解密
+func (b *ConfigBuilder) WithEncryptionKeys(keys ...core.EncryptionKey) *ConfigBuilder {
+	b.config.EncryptionKeys = keys
+	return b
+}
+
+// WithEncryptionPlaintextFallback 设置开启存储加密后是否原样读取未加密的历史记录，仅建议在迁移期间开启
+func (b *ConfigBuilder) WithEncryptionPlaintextFallback(allow bool) *ConfigBuilder {
+	b.config.EncryptionAllowPlaintext = allow
+	return b
+}
+
+// WithSigningKeys 设置签名密钥环，第一个未计划启用的密钥作为签名密钥
+// 若当前不是签名类Token风格或校验码风格，则根据第一个密钥的算法自动切换风格
+func (b *ConfigBuilder) WithSigningKeys(keys ...core.SigningKey) *ConfigBuilder {
+	b.config.SigningKeys = keys
+	if len(keys) > 0 && !b.config.TokenStyle.SelfContained() && b.config.TokenStyle != core.StyleChecksum {
+		switch keys[0].Algorithm {
+		case core.KeyAlgPasetoLocal:
+			b.config.TokenStyle = core.StylePasetoLocal
+		case core.KeyAlgPasetoPublic:
+			b.config.TokenStyle = core.StylePasetoPublic
+		default:
+			b.config.TokenStyle = core.StyleJWT
+		}
+	}
+	return b
+}
+
 // WithLoginMode 设置登录模式
 func (b *ConfigBuilder) WithLoginMode(mode core.LoginMode) *ConfigBuilder {
 	b.config.LoginMode = mode
 	return b
 }
 
+// WithSessionLifetime 设置会话绝对有效期与空闲超时，0 表示不限制
+func (b *ConfigBuilder) WithSessionLifetime(maxLifetime, idleTimeout time.Duration) *ConfigBuilder {
+	b.config.MaxLifetime = maxLifetime
+	b.config.IdleTimeout = idleTimeout
+	return b
+}
+
+// WithMaxSessionsPerUser 设置每个用户的最大会话数及超限时的淘汰策略
+func (b *ConfigBuilder) WithMaxSessionsPerUser(max int, policy core.SessionEvictPolicy) *ConfigBuilder {
+	b.config.MaxSessionsPerUser = max
+	b.config.SessionEvictPolicy = policy
+	return b
+}
+
+// WithImpersonationPermission 设置模拟登录要求操作人拥有的权限，未设置时禁止模拟登录
+func (b *ConfigBuilder) WithImpersonationPermission(permission string) *ConfigBuilder {
+	b.config.ImpersonationPermission = permission
+	return b
+}
+
+// WithImpersonationBlockedRoles 设置禁止被模拟登录的角色
+func (b *ConfigBuilder) WithImpersonationBlockedRoles(roles ...string) *ConfigBuilder {
+	b.config.ImpersonationBlockedRoles = roles
+	return b
+}
+
+// WithDeviceProfile 设置设备配置，覆盖该设备的有效期、刷新、自动续期与登录模式
+func (b *ConfigBuilder) WithDeviceProfile(device string, profile core.DeviceProfile) *ConfigBuilder {
+	if b.config.DeviceProfiles == nil {
+		b.config.DeviceProfiles = make(map[string]core.DeviceProfile)
+	}
+	b.config.DeviceProfiles[device] = profile
+	return b
+}
+
 // WithAutoRenew 设置自动续期
 func (b *ConfigBuilder) WithAutoRenew(autoRenew bool) *ConfigBuilder {
 	b.config.AutoRenew = autoRenew
 	return b
 }
 
+// WithRenewPolicy 设置自动续期的节流策略
+// threshold 为剩余有效期占比阈值（0~1），interval 为两次续期的最小间隔，均为 0 时每次校验都续期
+func (b *ConfigBuilder) WithRenewPolicy(threshold float64, interval time.Duration) *ConfigBuilder {
+	b.config.RenewThreshold = threshold
+	b.config.RenewInterval = interval
+	return b
+}
+
 // WithRememberDays 设置记住登录天数
 func (b *ConfigBuilder) WithRememberDays(days int) *ConfigBuilder {
 	b.config.RememberDays = days
@@ -1,6 +1,10 @@
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // 认证相关错误
 var (
@@ -36,6 +40,27 @@ var (
 
 	// ErrRefreshTokenInvalid 刷新Token无效
 	ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+
+	// ErrTokenNotYetValid Token尚未生效
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+
+	// ErrTokenRevoked Token已被吊销
+	ErrTokenRevoked = errors.New("token revoked")
+
+	// ErrTokenSignatureInvalid Token签名无效
+	ErrTokenSignatureInvalid = errors.New("token signature invalid")
+
+	// ErrSessionLifetimeExceeded 会话超过绝对有效期
+	ErrSessionLifetimeExceeded = errors.New("session lifetime exceeded")
+
+	// ErrSessionIdleTimeout 会话空闲超时
+	ErrSessionIdleTimeout = errors.New("session idle timeout")
+
+	// ErrTempTokenInvalid 临时Token无效、已过期或已被使用
+	ErrTempTokenInvalid = errors.New("temp token invalid")
+
+	// ErrRefreshTokenReused 已轮换的刷新Token被重复使用
+	ErrRefreshTokenReused = errors.New("refresh token reused")
 )
 
 // 配置相关错误
@@ -48,6 +73,39 @@ var (
 
 	// ErrTokenGeneratorNotConfigured Token生成器未配置
 	ErrTokenGeneratorNotConfigured = errors.New("token generator not configured")
+
+	// ErrSigningKeyNotConfigured 签名密钥未配置
+	ErrSigningKeyNotConfigured = errors.New("signing key not configured")
+
+	// ErrKeyNotFound 密钥未找到
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrAlgorithmNotSupported 签名算法不支持
+	ErrAlgorithmNotSupported = errors.New("algorithm not supported")
+)
+
+// 存储加密相关错误
+var (
+	// ErrEncryptionKeyNotConfigured 存储加密密钥未配置
+	ErrEncryptionKeyNotConfigured = errors.New("encryption key not configured")
+
+	// ErrDecryptFailed 存储数据解密失败
+	ErrDecryptFailed = errors.New("decrypt failed")
+)
+
+// 存储相关错误
+var (
+	// ErrTouchNotSupported 存储不支持单独重置过期时间
+	ErrTouchNotSupported = errors.New("touch not supported")
+
+	// ErrGetDelNotSupported 存储不支持原子读取并删除
+	ErrGetDelNotSupported = errors.New("getdel not supported")
+
+	// ErrIncrNotSupported 存储不支持原子计数
+	ErrIncrNotSupported = errors.New("incr not supported")
+
+	// ErrDisableCheckNotSupported 适配器或认证引擎不支持封禁检查
+	ErrDisableCheckNotSupported = errors.New("disable check not supported")
 )
 
 // 业务逻辑错误
@@ -60,4 +118,141 @@ var (
 
 	// ErrDeviceNotSupported 设备类型不支持
 	ErrDeviceNotSupported = errors.New("device not supported")
+
+	// ErrMaxSessionsExceeded 会话数量超过上限
+	ErrMaxSessionsExceeded = errors.New("max sessions exceeded")
+
+	// ErrUserDisabled 账号已被封禁
+	ErrUserDisabled = errors.New("user disabled")
+
+	// ErrInvalidCredentials 用户名或密码错误（不区分用户不存在与密码错误，避免账号枚举）
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrImpersonationNotAllowed 不允许模拟登录
+	ErrImpersonationNotAllowed = errors.New("impersonation not allowed")
+
+	// ErrSafeAuthRequired 敏感操作需要二级认证
+	ErrSafeAuthRequired = errors.New("safe authentication required")
+
+	// ErrMFACodeInvalid 多因素认证验证码错误或已使用
+	ErrMFACodeInvalid = errors.New("mfa code invalid")
+
+	// ErrMFATokenInvalid 多因素认证待验证Token无效、已过期或错误次数过多
+	ErrMFATokenInvalid = errors.New("mfa token invalid")
+
+	// ErrMFANotEnrolled 未绑定多因素认证
+	ErrMFANotEnrolled = errors.New("mfa not enrolled")
+
+	// ErrAPIKeyInvalid API Key 无效或已被吊销
+	ErrAPIKeyInvalid = errors.New("api key invalid")
+
+	// ErrAPIKeyExpired API Key 已过期
+	ErrAPIKeyExpired = errors.New("api key expired")
+
+	// ErrAPIKeyNotFound API Key 不存在
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrOAuthInvalidRequest OAuth2 请求缺少参数或参数无效
+	ErrOAuthInvalidRequest = errors.New("oauth invalid request")
+
+	// ErrOAuthInvalidClient OAuth2 客户端不存在或认证失败
+	ErrOAuthInvalidClient = errors.New("oauth invalid client")
+
+	// ErrOAuthClientExists OAuth2 客户端ID已被注册
+	ErrOAuthClientExists = errors.New("oauth client already exists")
+
+	// ErrOAuthInvalidGrant 授权码或刷新Token无效、已过期、已使用或与客户端不匹配
+	ErrOAuthInvalidGrant = errors.New("oauth invalid grant")
+
+	// ErrOAuthUnauthorizedClient 客户端不允许使用该授权类型
+	ErrOAuthUnauthorizedClient = errors.New("oauth unauthorized client")
+
+	// ErrOAuthUnsupportedGrantType 不支持的授权类型
+	ErrOAuthUnsupportedGrantType = errors.New("oauth unsupported grant type")
+
+	// ErrOAuthUnsupportedResponseType 不支持的授权响应类型
+	ErrOAuthUnsupportedResponseType = errors.New("oauth unsupported response type")
+
+	// ErrOAuthInvalidScope 申请的 scope 超出客户端允许范围
+	ErrOAuthInvalidScope = errors.New("oauth invalid scope")
+
+	// ErrOAuthAccessDenied 用户拒绝授权
+	ErrOAuthAccessDenied = errors.New("oauth access denied")
+
+	// ErrOAuthTokenInvalid OAuth2 访问Token无效、已过期或已被吊销
+	ErrOAuthTokenInvalid = errors.New("oauth token invalid")
+
+	// ErrSSOTicketInvalid SSO 票据无效、已过期、已使用或不属于该应用
+	ErrSSOTicketInvalid = errors.New("sso ticket invalid")
+
+	// ErrSSOAppInvalid SSO 应用未注册或应用密钥错误
+	ErrSSOAppInvalid = errors.New("sso app invalid")
+
+	// ErrSSORedirectNotAllowed SSO 回调地址不在应用允许的范围内
+	ErrSSORedirectNotAllowed = errors.New("sso redirect not allowed")
+
+	// ErrOTPInvalid 验证码错误、已过期或错误次数过多
+	ErrOTPInvalid = errors.New("otp invalid")
+
+	// ErrTooManyAttempts 登录失败次数过多，已被临时锁定
+	ErrTooManyAttempts = errors.New("too many attempts")
 )
+
+// MaxSessionsError 会话数量超过上限（EvictReject 策略下拒绝登录）
+type MaxSessionsError struct {
+	UserID string
+	Device string // 按设备限制时为设备名，按用户限制时为空
+	Limit  int
+}
+
+func (e *MaxSessionsError) Error() string {
+	if e.Device != "" {
+		return fmt.Sprintf("%s: user %s device %s limit %d", ErrMaxSessionsExceeded, e.UserID, e.Device, e.Limit)
+	}
+	return fmt.Sprintf("%s: user %s limit %d", ErrMaxSessionsExceeded, e.UserID, e.Limit)
+}
+
+// Is 支持 errors.Is(err, ErrMaxSessionsExceeded)
+func (e *MaxSessionsError) Is(target error) bool {
+	return target == ErrMaxSessionsExceeded
+}
+
+// UserDisabledError 账号已被封禁，携带封禁范围、原因与解封时间
+type UserDisabledError struct {
+	UserID     string
+	Service    string
+	Reason     string
+	ExpireTime time.Time // 零值表示永久封禁
+}
+
+func (e *UserDisabledError) Error() string {
+	msg := fmt.Sprintf("%s: user %s service %s", ErrUserDisabled, e.UserID, e.Service)
+	if e.Reason != "" {
+		msg += ", reason: " + e.Reason
+	}
+	if !e.ExpireTime.IsZero() {
+		msg += ", until " + e.ExpireTime.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// Is 支持 errors.Is(err, ErrUserDisabled)
+func (e *UserDisabledError) Is(target error) bool {
+	return target == ErrUserDisabled
+}
+
+// TooManyAttemptsError 登录失败次数过多被锁定，携带锁定维度与剩余锁定时长
+type TooManyAttemptsError struct {
+	Kind       string // 锁定维度：LoginAttemptKindUser、LoginAttemptKindIP 或 LoginAttemptKindMFA
+	Subject    string // 被锁定的登录名、IP或用户ID
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s: %s %s, retry after %s", ErrTooManyAttempts, e.Kind, e.Subject, e.RetryAfter.Round(time.Second))
+}
+
+// Is 支持 errors.Is(err, ErrTooManyAttempts)
+func (e *TooManyAttemptsError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
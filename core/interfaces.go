@@ -62,6 +62,31 @@ type Storage interface {
 	Keys(ctx context.Context, pattern string) ([]string, error)
 }
 
+// TTLStorage 支持单独读取与重置过期时间的存储（可选实现）
+// 自动续期时只重置TTL而不改写数据，最后访问时间由剩余有效期推算，已删除的记录不会被续期写回
+type TTLStorage interface {
+	// Touch 重置键的过期时间，键不存在时返回 false
+	Touch(ctx context.Context, key string, expire time.Duration) (bool, error)
+
+	// TTL 获取键的剩余有效期，键不存在时返回 false，键没有过期时间时返回 0
+	TTL(ctx context.Context, key string) (time.Duration, bool, error)
+}
+
+// GetDelStorage 支持原子读取并删除的存储（可选实现）
+// 一次性凭证依赖该操作保证并发消费时只有一个请求成功
+type GetDelStorage interface {
+	// GetDel 读取键的值并删除该键，键不存在时返回错误
+	GetDel(ctx context.Context, key string) (interface{}, error)
+}
+
+// CounterStorage 支持原子计数的存储（可选实现）
+// 失败次数、验证码尝试次数等限制依赖该操作保证并发请求不会少计
+type CounterStorage interface {
+	// Incr 将键的整数值增加 delta 并返回增加后的值，键不存在时从 0 开始；键没有过期时间时设置为 expire
+	// 计数器的值不加密，只能通过 Incr 读取（delta 为 0 时只读取）
+	Incr(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error)
+}
+
 // AuthService 认证服务接口
 type AuthService interface {
 	// Login 用户登录，处理登录逻辑并返回Token
@@ -80,6 +105,13 @@ type AuthService interface {
 	RefreshAccessToken(ctx context.Context, refreshToken string) (*LoginResponse, error)
 }
 
+// RefreshRequestService 支持使用刷新请求刷新Token的认证服务（可选实现）
+// 未实现时退化为 AuthService.RefreshAccessToken，请求中的IP不生效
+type RefreshRequestService interface {
+	// Refresh 使用刷新请求获取新的访问Token，可携带调用方当前IP
+	Refresh(ctx context.Context, req *RefreshRequest) (*LoginResponse, error)
+}
+
 // UserRoleProvider 用户角色提供者接口（由用户实现）
 type UserRoleProvider interface {
 	// GetUserRoles 获取指定用户的所有角色信息
@@ -87,6 +119,43 @@ type UserRoleProvider interface {
 	GetUserRoles(ctx context.Context, userID string) ([]Role, error)
 }
 
+// SecurityEventListener 安全事件监听器接口（由用户实现）
+type SecurityEventListener interface {
+	// OnSecurityEvent 处理安全事件，如刷新Token重放等，可用于告警或审计
+	OnSecurityEvent(ctx context.Context, event *SecurityEvent)
+}
+
+// CredentialVerifier 凭证校验接口
+// 密码登录时先由校验器确认凭证，再签发Token；auth.PasswordVerifier 为基于 CredentialStore 的默认实现
+type CredentialVerifier interface {
+	// VerifyCredential 校验登录名与密码，成功返回用户ID，失败返回 ErrInvalidCredentials
+	VerifyCredential(ctx context.Context, username, password string) (string, error)
+}
+
+// CredentialStore 凭证存储接口（由用户实现）
+type CredentialStore interface {
+	// GetPasswordHash 根据登录名获取用户ID与密码哈希，用户不存在时返回 ErrUserNotFound
+	GetPasswordHash(ctx context.Context, username string) (userID, hash string, err error)
+
+	// UpdatePasswordHash 更新密码哈希，哈希参数变化时登录成功后自动调用
+	UpdatePasswordHash(ctx context.Context, userID, hash string) error
+}
+
+// PasswordHasher 密码哈希接口
+type PasswordHasher interface {
+	// Hash 生成密码哈希
+	Hash(password string) (string, error)
+
+	// Verify 校验密码与哈希是否匹配
+	Verify(password, hash string) (bool, error)
+
+	// Supports 是否为该算法生成的哈希
+	Supports(hash string) bool
+
+	// NeedsRehash 哈希参数是否与当前配置不一致，需要重新哈希
+	NeedsRehash(hash string) bool
+}
+
 // PermissionService 权限服务接口
 type PermissionService interface {
 	// CheckPermission 检查用户是否拥有指定权限
@@ -101,6 +170,100 @@ type PermissionService interface {
 	SetUserRoleProvider(provider UserRoleProvider)
 }
 
+// DisableService 账号封禁服务接口
+// service 为封禁范围，如 "comments"；为空或 DisableServiceAll 时表示全部服务
+type DisableService interface {
+	// Disable 封禁账号，duration <= 0 表示永久封禁
+	Disable(ctx context.Context, userID, service string, duration time.Duration, reason string) error
+
+	// IsDisabled 检查账号在指定服务上是否被封禁（全部服务的封禁同样生效）
+	IsDisabled(ctx context.Context, userID, service string) (bool, error)
+
+	// CheckDisabled 账号被封禁时返回 *UserDisabledError，否则返回 nil
+	CheckDisabled(ctx context.Context, userID, service string) error
+
+	// GetDisableInfo 获取生效中的封禁信息，未封禁时返回 nil
+	GetDisableInfo(ctx context.Context, userID, service string) (*DisableInfo, error)
+
+	// GetDisableTime 获取剩余封禁时长，未封禁返回 0，永久封禁返回 DisablePermanent
+	GetDisableTime(ctx context.Context, userID, service string) (time.Duration, error)
+
+	// Untie 解除账号在指定服务上的封禁
+	Untie(ctx context.Context, userID, service string) error
+}
+
+// LoginAttemptService 登录失败限制服务接口
+// 按登录名与客户端IP分别在窗口内统计失败次数，超限后锁定，锁定时长按连续锁定次数指数增长
+type LoginAttemptService interface {
+	// CheckAttempts 登录名或IP处于锁定期时返回 *TooManyAttemptsError，参数为空时跳过对应维度
+	CheckAttempts(ctx context.Context, username, ip string) error
+
+	// BeginAttempt 在校验凭证前预占一次尝试，进行中的尝试同样计入失败次数
+	// 处于锁定期或窗口内的尝试次数已达上限时返回 *TooManyAttemptsError，预占成功后必须调用 EndAttempt
+	BeginAttempt(ctx context.Context, username, ip string) error
+
+	// EndAttempt 结束 BeginAttempt 预占的尝试，failed 为 true 时计为失败，本次失败触发锁定时返回 *TooManyAttemptsError
+	EndAttempt(ctx context.Context, username, ip string, failed bool) error
+
+	// RecordFailure 记录一次登录失败，本次失败触发锁定时返回 *TooManyAttemptsError
+	RecordFailure(ctx context.Context, username, ip string) error
+
+	// ResetAttempts 清除登录名或IP的失败记录与锁定（登录成功或管理员解锁），参数为空时跳过对应维度
+	ResetAttempts(ctx context.Context, username, ip string) error
+}
+
+// CodeSender 验证码发送器，由业务实现邮件或短信投递
+type CodeSender interface {
+	// SendCode 向登录标识（邮箱、手机号）发送验证码，ttl 为验证码有效期
+	SendCode(ctx context.Context, identifier, code string, ttl time.Duration) error
+}
+
+// IdentifierResolver 登录标识解析器，将邮箱、手机号解析为用户ID
+// 标识未注册时返回 ErrUserNotFound，此时不发送验证码；需要"验证码即注册"时可在此创建用户
+type IdentifierResolver interface {
+	ResolveIdentifier(ctx context.Context, identifier string) (string, error)
+}
+
+// OTPService 验证码登录服务接口
+type OTPService interface {
+	// RequestCode 生成验证码并通过 CodeSender 发送，超过发送频率时返回 *TooManyAttemptsError
+	RequestCode(ctx context.Context, identifier string) error
+
+	// VerifyCode 校验验证码并返回用户ID，验证码只能使用一次，错误次数达到上限后失效
+	VerifyCode(ctx context.Context, identifier, code string) (string, error)
+}
+
+// MFAService 多因素认证服务接口（RFC 6238 TOTP 与一次性恢复码）
+type MFAService interface {
+	// Enroll 生成新的 TOTP 密钥与恢复码，确认前不生效；已启用时返回错误
+	Enroll(ctx context.Context, userID, accountName string) (*TOTPEnrollment, error)
+
+	// Confirm 使用验证器应用生成的验证码确认绑定，确认后登录需要多因素认证
+	Confirm(ctx context.Context, userID, code string) error
+
+	// IsEnabled 用户是否已确认绑定
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+
+	// VerifyCode 校验 TOTP 验证码或恢复码，验证码不可重复使用，恢复码使用后失效
+	VerifyCode(ctx context.Context, userID, code string) error
+
+	// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+	RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error)
+
+	// Disable 解除绑定
+	Disable(ctx context.Context, userID string) error
+}
+
+// TempTokenService 临时Token服务接口
+// 临时Token绑定用途、有效期短且只能使用一次，用于下载链接、邮箱验证、重置密码等场景
+type TempTokenService interface {
+	// CreateTempToken 创建临时Token，value 为消费时返回的业务数据（如用户ID、文件路径）
+	CreateTempToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error)
+
+	// ConsumeTempToken 消费临时Token并返回业务数据，用途不匹配、已过期或已使用时返回 ErrTempTokenInvalid
+	ConsumeTempToken(ctx context.Context, purpose, token string) (string, error)
+}
+
 // SessionService 会话服务接口
 type SessionService interface {
 	// CreateSession 创建新的用户会话
@@ -121,3 +284,6 @@ type SessionService interface {
 	// KickOutByToken 踢出指定Token的会话
 	KickOutByToken(ctx context.Context, token string) error
 }
+
+// SessionEndFunc 会话结束回调，userID 为会话所属用户，tokenRef 为Token的存储引用（见 KeyService.TokenRef）
+type SessionEndFunc func(ctx context.Context, userID, tokenRef string)
@@ -1,10 +1,34 @@
 package core
 
-import "fmt"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
 
 // KeyService 键生成服务
 type KeyService struct {
-	prefix string
+	prefix      string
+	tokenHasher TokenHasher
+}
+
+// TokenHasher Token摘要函数，摘要值作为Token在存储中的引用
+type TokenHasher func(token string) string
+
+// NewTokenHasher 创建Token摘要函数：配置密钥时使用 HMAC-SHA256，否则使用 SHA-256
+func NewTokenHasher(secret []byte) TokenHasher {
+	if len(secret) > 0 {
+		return func(token string) string {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(token))
+			return hex.EncodeToString(mac.Sum(nil))
+		}
+	}
+	return func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
 }
 
 // NewKeyService 创建键生成服务
@@ -17,6 +41,25 @@ func NewKeyService(prefix string) *KeyService {
 	}
 }
 
+// SetTokenHasher 设置Token摘要函数，nil 表示存储中直接使用原始Token
+func (k *KeyService) SetTokenHasher(hasher TokenHasher) {
+	k.tokenHasher = hasher
+}
+
+// TokenHashing 是否在存储中仅保存Token摘要
+func (k *KeyService) TokenHashing() bool {
+	return k.tokenHasher != nil
+}
+
+// TokenRef 获取Token在存储键和持久化记录中的引用
+// 开启摘要时返回Token摘要，否则返回原始Token
+func (k *KeyService) TokenRef(token string) string {
+	if token == "" || k.tokenHasher == nil {
+		return token
+	}
+	return k.tokenHasher(token)
+}
+
 // 登录相关键
 func (k *KeyService) LoginInfoKey(token string) string {
 	return fmt.Sprintf("%s:login:%s", k.prefix, token)
@@ -26,6 +69,40 @@ func (k *KeyService) RefreshTokenKey(refreshToken string) string {
 	return fmt.Sprintf("%s:refresh:%s", k.prefix, refreshToken)
 }
 
+// UserRefreshKey 用户刷新Token索引键（用于按用户吊销刷新Token）
+func (k *KeyService) UserRefreshKey(userID, refreshToken string) string {
+	return fmt.Sprintf("%s:user_refresh:%s:%s", k.prefix, userID, refreshToken)
+}
+
+func (k *KeyService) UserRefreshPattern(userID string) string {
+	return fmt.Sprintf("%s:user_refresh:%s:*", k.prefix, userID)
+}
+
+// AccessRefreshKey 访问Token与刷新Token的配对键，值为刷新Token
+func (k *KeyService) AccessRefreshKey(token string) string {
+	return fmt.Sprintf("%s:access_refresh:%s", k.prefix, token)
+}
+
+// RefreshFamilyKey 刷新Token族记录键
+func (k *KeyService) RefreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("%s:refresh_family:%s", k.prefix, familyID)
+}
+
+// RefreshFamilyRevokedKey 刷新Token族吊销标记键，与族记录分开存储，避免轮换写回族记录时覆盖吊销状态
+func (k *KeyService) RefreshFamilyRevokedKey(familyID string) string {
+	return fmt.Sprintf("%s:refresh_family_revoked:%s", k.prefix, familyID)
+}
+
+// UsedRefreshTokenKey 已轮换刷新Token的标记键，值为所属Token族ID
+func (k *KeyService) UsedRefreshTokenKey(refreshToken string) string {
+	return fmt.Sprintf("%s:refresh_used:%s", k.prefix, refreshToken)
+}
+
+// RevokedTokenKey 签名Token吊销记录键（按 jti）
+func (k *KeyService) RevokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("%s:revoked:%s", k.prefix, tokenID)
+}
+
 // 会话相关键
 func (k *KeyService) SessionKey(token string) string {
 	return fmt.Sprintf("%s:session:%s", k.prefix, token)
@@ -39,6 +116,100 @@ func (k *KeyService) UserSessionPattern(userID string) string {
 	return fmt.Sprintf("%s:user_session:%s:*", k.prefix, userID)
 }
 
+// 封禁相关键
+func (k *KeyService) DisableKey(userID, service string) string {
+	return fmt.Sprintf("%s:disable:%s:%s", k.prefix, userID, service)
+}
+
+// 登录失败计数键（按维度、固定窗口序号与登录名或IP）
+func (k *KeyService) LoginAttemptKey(kind, subject string, window int64) string {
+	return fmt.Sprintf("%s:attempt:%s:%d:%s", k.prefix, kind, window, subject)
+}
+
+// 登录锁定记录键（按维度与登录名或IP）
+func (k *KeyService) LoginLockKey(kind, subject string) string {
+	return fmt.Sprintf("%s:attempt_lock:%s:%s", k.prefix, kind, subject)
+}
+
+// API Key 相关键（按Key ID 与所有者）
+func (k *KeyService) APIKeyKey(id string) string {
+	return fmt.Sprintf("%s:apikey:%s", k.prefix, id)
+}
+
+func (k *KeyService) APIKeyLastUsedKey(id string) string {
+	return fmt.Sprintf("%s:apikey_used:%s", k.prefix, id)
+}
+
+func (k *KeyService) UserAPIKeyKey(userID, id string) string {
+	return fmt.Sprintf("%s:user_apikey:%s:%s", k.prefix, userID, id)
+}
+
+func (k *KeyService) UserAPIKeyPattern(userID string) string {
+	return fmt.Sprintf("%s:user_apikey:%s:*", k.prefix, userID)
+}
+
+// OAuth2 相关键（客户端按ID，授权码与Token按引用）
+func (k *KeyService) OAuthClientKey(clientID string) string {
+	return fmt.Sprintf("%s:oauth_client:%s", k.prefix, clientID)
+}
+
+func (k *KeyService) OAuthCodeKey(code string) string {
+	return fmt.Sprintf("%s:oauth_code:%s", k.prefix, code)
+}
+
+func (k *KeyService) OAuthAccessTokenKey(token string) string {
+	return fmt.Sprintf("%s:oauth_access:%s", k.prefix, token)
+}
+
+func (k *KeyService) OAuthRefreshTokenKey(token string) string {
+	return fmt.Sprintf("%s:oauth_refresh:%s", k.prefix, token)
+}
+
+// 验证码登录相关键（按登录标识）
+func (k *KeyService) OTPKey(identifier string) string {
+	return fmt.Sprintf("%s:otp:%s", k.prefix, identifier)
+}
+
+func (k *KeyService) OTPAttemptKey(identifier, id string) string {
+	return fmt.Sprintf("%s:otp_attempt:%s:%s", k.prefix, identifier, id)
+}
+
+func (k *KeyService) OTPSendKey(identifier string) string {
+	return fmt.Sprintf("%s:otp_send:%s", k.prefix, identifier)
+}
+
+func (k *KeyService) OTPResendKey(identifier string) string {
+	return fmt.Sprintf("%s:otp_resend:%s", k.prefix, identifier)
+}
+
+// 多因素认证相关键
+func (k *KeyService) MFAKey(userID string) string {
+	return fmt.Sprintf("%s:mfa:%s", k.prefix, userID)
+}
+
+func (k *KeyService) MFAPendingKey(token string) string {
+	return fmt.Sprintf("%s:mfa_pending:%s", k.prefix, token)
+}
+
+func (k *KeyService) MFAPendingAttemptKey(token string) string {
+	return fmt.Sprintf("%s:mfa_pending_attempt:%s", k.prefix, token)
+}
+
+// 已使用的 TOTP 时间步或恢复码标记键（按用户与标记）
+func (k *KeyService) MFAUsedKey(userID, marker string) string {
+	return fmt.Sprintf("%s:mfa_used:%s:%s", k.prefix, userID, marker)
+}
+
+// 临时Token相关键（按用途与Token引用）
+func (k *KeyService) TempTokenKey(purpose, token string) string {
+	return fmt.Sprintf("%s:temp:%s:%s", k.prefix, purpose, token)
+}
+
+// 二级认证相关键（按Token引用与服务）
+func (k *KeyService) SafeKey(token, service string) string {
+	return fmt.Sprintf("%s:safe:%s:%s", k.prefix, token, service)
+}
+
 // 权限相关键
 func (k *KeyService) RoleKey(roleID string) string {
 	return fmt.Sprintf("%s:role:%s", k.prefix, roleID)
@@ -75,6 +246,24 @@ func (k *KeyService) SSOSessionKey(sessionID string) string {
 	return fmt.Sprintf("%s:sso:session:%s", k.prefix, sessionID)
 }
 
+// SSOSessionAppKey SSO 中心会话中已签发过票据的应用，每个应用单独一个键，并发签发票据时不会互相覆盖
+func (k *KeyService) SSOSessionAppKey(sessionID, appID string) string {
+	return fmt.Sprintf("%s:sso:session:%s:app:%s", k.prefix, sessionID, appID)
+}
+
+func (k *KeyService) SSOSessionAppPattern(sessionID string) string {
+	return fmt.Sprintf("%s:sso:session:%s:app:*", k.prefix, sessionID)
+}
+
+// SSOClientTokenKey SSO 应用端会话与本地Token的映射，按应用区分以便多个应用共用存储，每个Token单独一个键
+func (k *KeyService) SSOClientTokenKey(appID, sessionID, tokenRef string) string {
+	return fmt.Sprintf("%s:sso:client:%s:%s:%s", k.prefix, appID, sessionID, tokenRef)
+}
+
+func (k *KeyService) SSOClientTokenPattern(appID, sessionID string) string {
+	return fmt.Sprintf("%s:sso:client:%s:%s:*", k.prefix, appID, sessionID)
+}
+
 // 自定义键生成
 func (k *KeyService) CustomKey(category string, keys ...string) string {
 	key := fmt.Sprintf("%s:%s", k.prefix, category)
@@ -1,20 +1,33 @@
 package core
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"strings"
 	"time"
 )
 
 // Token 相关常量
 const (
 	// Token 生成时的额外参数键
-	TokenExtraKeyUserID = "user_id"
-	TokenExtraKeyDevice = "device"
-	TokenExtraKeyIP     = "ip"
-	TokenExtraKeyType   = "type"
+	TokenExtraKeyUserID       = "user_id"
+	TokenExtraKeyDevice       = "device"
+	TokenExtraKeyIP           = "ip"
+	TokenExtraKeyType         = "type"
+	TokenExtraKeyPurpose      = "purpose"
+	TokenExtraKeyImpersonator = "impersonator"
+	TokenExtraKeyAuthTime     = "auth_time" // 首次认证时间，签名Token中写入 auth_time 声明
 
 	// Token 类型值
 	TokenTypeRefresh = "refresh"
 	TokenTypeAccess  = "access"
+	TokenTypeTemp    = "temp"
+	TokenTypeMFA     = "mfa_pending"
+	TokenTypeOAuth   = "oauth"
+
+	// OAuth2 Token 额外参数键
+	TokenExtraKeyClientID = "client_id"
 
 	// Token 额外标识
 	TokenFlagRefresh = "refresh"
@@ -22,6 +35,12 @@ const (
 	// 权限相关常量
 	PermissionWildcard = "*"
 
+	// 封禁相关常量
+	DisableServiceAll = "all" // 封禁全部服务，Disable 的 service 为空时等同于该值
+
+	// 二级认证相关常量
+	SafeServiceDefault = "default" // OpenSafe 等方法的 service 为空时使用的默认服务
+
 	// 存储类型常量
 	StorageTypeRedis    = "redis"
 	StorageTypeMemory   = "memory"
@@ -94,6 +113,7 @@ const (
 	ErrMsgStoreNewRefreshToken    = "存储新刷新Token失败"
 	ErrMsgUserIDEmpty             = "用户ID不能为空"
 	ErrMsgRefreshTokenEmpty       = "刷新Token不能为空"
+	ErrMsgLogoutByRefUnavailable  = "认证服务不支持按Token引用登出"
 
 	// 会话相关错误消息
 	ErrMsgSessionInfoEmpty        = "会话信息不能为空"
@@ -114,6 +134,136 @@ const (
 	ErrMsgTokenExpired      = "Token已过期"
 	ErrMsgGetSessionInfo    = "获取会话信息失败"
 	ErrMsgPermissionEmpty   = "权限标识不能为空"
+	ErrMsgParseToken        = "解析Token失败"
+	ErrMsgStatelessStyle    = "无状态校验要求使用自包含的签名Token风格"
+	ErrMsgRefreshAsAccess   = "刷新Token不能作为访问Token使用"
+	ErrMsgTempAsAccess      = "临时Token不能作为访问Token使用"
+	ErrMsgMFAAsAccess       = "多因素认证待验证Token不能作为访问Token使用"
+	ErrMsgOAuthAsAccess     = "OAuth2 Token不能作为登录会话Token使用"
+	ErrMsgRevokeToken       = "写入Token吊销记录失败"
+	ErrMsgCheckRevocation   = "查询Token吊销记录失败"
+
+	// 存储加密相关错误消息
+	ErrMsgEncryptRecord = "加密存储记录失败"
+	ErrMsgDecryptRecord = "解密存储记录失败"
+
+	// 刷新Token族相关错误消息
+	ErrMsgStoreRefreshFamily   = "存储刷新Token族失败"
+	ErrMsgRevokeRefreshFamily  = "吊销刷新Token族失败"
+	ErrMsgGetRefreshFamily     = "获取刷新Token族失败"
+	ErrMsgRefreshFamilyRevoked = "刷新Token族已吊销"
+
+	// 封禁相关错误消息
+	ErrMsgDisableUser      = "封禁账号失败"
+	ErrMsgUntieUser        = "解除封禁失败"
+	ErrMsgParseDisableInfo = "解析封禁信息失败"
+	ErrMsgGetDisableInfo   = "获取封禁信息失败"
+
+	// 临时Token相关错误消息
+	ErrMsgPurposeEmpty     = "临时Token用途不能为空"
+	ErrMsgTempTTLInvalid   = "临时Token有效期必须大于0"
+	ErrMsgStoreTempToken   = "存储临时Token失败"
+	ErrMsgConsumeTempToken = "消费临时Token失败"
+
+	// 登录失败限制相关错误消息
+	ErrMsgParseLoginAttempt = "解析登录失败记录失败"
+	ErrMsgSaveLoginAttempt  = "保存登录失败记录失败"
+	ErrMsgResetLoginAttempt = "重置登录失败记录失败"
+	ErrMsgGetLoginAttempt   = "获取登录失败记录失败"
+
+	// 多因素认证相关错误消息
+	ErrMsgMFAAlreadyEnabled    = "已启用多因素认证，请先关闭后再重新绑定"
+	ErrMsgMFACodeEmpty         = "验证码不能为空"
+	ErrMsgMFATokenEmpty        = "多因素认证待验证Token不能为空"
+	ErrMsgGenerateTOTPSecret   = "生成TOTP密钥失败"
+	ErrMsgInvalidTOTPSecret    = "TOTP密钥格式错误"
+	ErrMsgGenerateRecoveryCode = "生成恢复码失败"
+	ErrMsgSaveMFA              = "保存多因素认证信息失败"
+	ErrMsgParseMFA             = "解析多因素认证信息失败"
+	ErrMsgDeleteMFA            = "删除多因素认证信息失败"
+	ErrMsgSaveMFAPending       = "保存多因素认证待验证状态失败"
+	ErrMsgParseMFAPending      = "解析多因素认证待验证状态失败"
+	ErrMsgGetMFA               = "获取多因素认证信息失败"
+
+	// 验证码登录相关错误消息
+	ErrMsgCodeSenderEmpty   = "验证码发送器未设置，请调用 WithCodeSender 方法"
+	ErrMsgIdentifierEmpty   = "登录标识不能为空"
+	ErrMsgOTPLoginEmpty     = "验证码登录请求不能为空"
+	ErrMsgGenerateOTP       = "生成验证码失败"
+	ErrMsgSaveOTP           = "保存验证码失败"
+	ErrMsgParseOTP          = "解析验证码记录失败"
+	ErrMsgSendOTP           = "发送验证码失败"
+	ErrMsgResolveIdentifier = "解析登录标识失败"
+
+	// API Key 相关错误消息
+	ErrMsgGenerateAPIKey = "生成API Key失败"
+	ErrMsgSaveAPIKey     = "保存API Key失败"
+	ErrMsgParseAPIKey    = "解析API Key记录失败"
+	ErrMsgRevokeAPIKey   = "吊销API Key失败"
+	ErrMsgListAPIKeys    = "获取API Key列表失败"
+	ErrMsgAPIKeyTTL      = "API Key有效期不能为负数"
+	ErrMsgAPIKeyIDTaken  = "API Key ID多次生成均已被占用"
+
+	// OAuth2 相关错误消息
+	ErrMsgOAuthClientEmpty     = "OAuth2客户端信息不能为空"
+	ErrMsgOAuthRedirectURIs    = "使用授权码模式的客户端至少需要一个回调地址"
+	ErrMsgOAuthPublicGrant     = "公开客户端不能使用客户端凭证模式"
+	ErrMsgSaveOAuthClient      = "保存OAuth2客户端失败"
+	ErrMsgGetOAuthClient       = "读取OAuth2客户端失败"
+	ErrMsgParseOAuthClient     = "解析OAuth2客户端失败"
+	ErrMsgDeleteOAuthClient    = "删除OAuth2客户端失败"
+	ErrMsgGenerateOAuthToken   = "生成OAuth2凭证失败"
+	ErrMsgSaveOAuthGrant       = "保存OAuth2授权记录失败"
+	ErrMsgGetOAuthGrant        = "读取OAuth2授权记录失败"
+	ErrMsgParseOAuthGrant      = "解析OAuth2授权记录失败"
+	ErrMsgOAuthMissingParam    = "缺少必要参数"
+	ErrMsgOAuthRedirectURI     = "回调地址未注册"
+	ErrMsgOAuthPKCERequired    = "公开客户端必须使用 PKCE"
+	ErrMsgOAuthPKCEMethod      = "不支持的 code_challenge_method"
+	ErrMsgOAuthPKCEPlain       = "公开客户端必须使用 S256 code_challenge_method"
+	ErrMsgOAuthPKCEVerifier    = "code_verifier 校验失败"
+	ErrMsgOAuthCodeInvalid     = "授权码无效、已过期或已使用"
+	ErrMsgOAuthRefreshInvalid  = "刷新Token无效、已过期或已使用"
+	ErrMsgOAuthClientMismatch  = "授权不属于该客户端"
+	ErrMsgOAuthRedirectChanged = "回调地址与授权请求不一致"
+	ErrMsgOAuthLoginRequired   = "用户未登录"
+	ErrMsgOAuthConsentRequired = "授权服务器未配置授权确认"
+
+	// SSO 相关错误消息
+	ErrMsgGenerateSSOTicket = "生成SSO票据失败"
+	ErrMsgSaveSSOTicket     = "保存SSO票据失败"
+	ErrMsgParseSSOTicket    = "解析SSO票据失败"
+	ErrMsgSaveSSOSession    = "保存SSO会话失败"
+	ErrMsgGetSSOSession     = "读取SSO会话失败"
+	ErrMsgParseSSOSession   = "解析SSO会话失败"
+	ErrMsgDeleteSSOSession  = "删除SSO会话失败"
+	ErrMsgSSOLoginRequired  = "用户未登录"
+	ErrMsgSSOServerRequest  = "请求SSO服务端失败"
+	ErrMsgSSOLogoutNotify   = "通知应用登出失败"
+
+	// 凭证校验相关错误消息
+	ErrMsgCredentialVerifierEmpty = "凭证校验器未设置，请调用 WithCredentialVerifier 方法"
+	ErrMsgPasswordLoginEmpty      = "密码登录请求不能为空"
+	ErrMsgHashPassword            = "密码哈希失败"
+	ErrMsgUnknownHashFormat       = "无法识别的密码哈希格式"
+	ErrMsgInvalidHashParams       = "密码哈希参数无效"
+	ErrMsgGetPasswordHash         = "获取密码哈希失败"
+
+	// 模拟登录相关错误消息
+	ErrMsgImpersonateRequestEmpty = "模拟登录请求不能为空"
+	ErrMsgImpersonateNested       = "模拟登录期间不能再次模拟其他用户"
+	ErrMsgImpersonateSelf         = "不能模拟自己"
+	ErrMsgImpersonateBlockedRole  = "目标用户拥有禁止模拟的角色"
+	ErrMsgNotImpersonating        = "当前Token不是模拟登录"
+	ErrMsgOriginalSessionExpired  = "操作人原会话已失效"
+	ErrMsgImpersonateUnavailable  = "模拟登录功能不可用"
+	ErrMsgImpersonateNotEnabled   = "未配置模拟登录权限，请调用 WithImpersonationPermission 方法"
+	ErrMsgImpersonateNoPermission = "操作人没有模拟登录权限"
+
+	// 二级认证相关错误消息
+	ErrMsgSafeDurationInvalid = "二级认证有效期必须大于0"
+	ErrMsgOpenSafe            = "开启二级认证失败"
+	ErrMsgCloseSafe           = "关闭二级认证失败"
 
 	// 权限服务相关错误消息
 	ErrMsgRoleIDEmpty           = "角色ID不能为空"
@@ -125,13 +275,48 @@ const (
 type TokenStyle int
 
 const (
-	StyleUUID       TokenStyle = iota // UUID风格 (默认风格)
-	StyleUUIDSimple                   // UUID风格, 只不过去掉了中划线
-	StyleRandom32                     // 随机32位字符串
-	StyleRandom64                     // 随机64位字符串
-	StyleRandom128                    // 随机128位字符串
-	StyleTik                          // tik风格
-	StyleCustom                       // 自定义风格
+	StyleUUID         TokenStyle = iota // UUID风格 (默认风格)
+	StyleUUIDSimple                     // UUID风格, 只不过去掉了中划线
+	StyleRandom32                       // 随机32位字符串
+	StyleRandom64                       // 随机64位字符串
+	StyleRandom128                      // 随机128位字符串
+	StyleTik                            // tik风格
+	StyleCustom                         // 自定义风格
+	StyleJWT                            // JWT风格（签名Token，可无状态校验）
+	StylePasetoLocal                    // PASETO v4.local 风格（对称加密Token）
+	StylePasetoPublic                   // PASETO v4.public 风格（Ed25519 签名Token）
+	StyleChecksum                       // 带HMAC校验码的随机Token（不透明、可吊销，伪造Token在访问存储前即被拒绝）
+)
+
+// SelfContained 是否为自包含的签名Token风格（可脱离存储完成校验）
+func (s TokenStyle) SelfContained() bool {
+	switch s {
+	case StyleJWT, StylePasetoLocal, StylePasetoPublic:
+		return true
+	default:
+		return false
+	}
+}
+
+// VerifyMode Token校验模式
+type VerifyMode int
+
+const (
+	VerifyStorage   VerifyMode = iota // 存储校验（默认），每次校验读取存储中的登录信息
+	VerifyStateless                   // 无状态校验，仅校验签名Token本身，不访问存储
+	VerifyHybrid                      // 混合校验，本地校验签名后按 jti 查询存储中的吊销记录
+)
+
+// JWT 签名算法
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgES256 = "ES256"
+	JWTAlgEdDSA = "EdDSA"
+
+	// PASETO 密钥用途（用于密钥环中的 Algorithm 字段）
+	KeyAlgPasetoLocal  = "v4.local"
+	KeyAlgPasetoPublic = "v4.public"
 )
 
 // CustomTokenFunc 自定义Token生成函数类型
@@ -146,6 +331,134 @@ const (
 	MutexLogin                   // 同端互斥登录
 )
 
+// DeviceProfile 设备配置，未设置的字段沿用全局配置
+type DeviceProfile struct {
+	TokenExpire    time.Duration `json:"token_expire,omitempty"`    // Token有效期
+	RefreshExpire  time.Duration `json:"refresh_expire,omitempty"`  // 刷新Token有效期
+	DisableRefresh bool          `json:"disable_refresh,omitempty"` // 不签发刷新Token
+	AutoRenew      *bool         `json:"auto_renew,omitempty"`      // 自动续期
+	LoginMode      *LoginMode    `json:"login_mode,omitempty"`      // 登录模式
+	MaxSessions    int           `json:"max_sessions,omitempty"`    // 该设备上的最大会话数，0 表示不限制
+	MaxLifetime    time.Duration `json:"max_lifetime,omitempty"`    // 绝对有效期
+	IdleTimeout    time.Duration `json:"idle_timeout,omitempty"`    // 空闲超时
+}
+
+// profile 获取设备配置
+func (c *Config) profile(device string) (DeviceProfile, bool) {
+	if device == "" || c.DeviceProfiles == nil {
+		return DeviceProfile{}, false
+	}
+	p, ok := c.DeviceProfiles[device]
+	return p, ok
+}
+
+// TokenExpireFor 获取设备的Token有效期
+func (c *Config) TokenExpireFor(device string) time.Duration {
+	if p, ok := c.profile(device); ok && p.TokenExpire > 0 {
+		return p.TokenExpire
+	}
+	return c.TokenExpire
+}
+
+// RefreshExpireFor 获取设备的刷新Token有效期，返回0表示不签发刷新Token
+// 未设置 RefreshExpire 时使用记住登录天数
+func (c *Config) RefreshExpireFor(device string) time.Duration {
+	p, ok := c.profile(device)
+	if ok && p.DisableRefresh {
+		return 0
+	}
+	if ok && p.RefreshExpire > 0 {
+		return p.RefreshExpire
+	}
+	if c.RefreshExpire > 0 {
+		return c.RefreshExpire
+	}
+	if c.RememberDays > 0 {
+		return time.Duration(c.RememberDays) * 24 * time.Hour
+	}
+	return 0
+}
+
+// AutoRenewFor 获取设备是否自动续期
+func (c *Config) AutoRenewFor(device string) bool {
+	if p, ok := c.profile(device); ok && p.AutoRenew != nil {
+		return *p.AutoRenew
+	}
+	return c.AutoRenew
+}
+
+// MaxSessionsFor 获取设备上的最大会话数，0 表示不限制
+func (c *Config) MaxSessionsFor(device string) int {
+	if p, ok := c.profile(device); ok {
+		return p.MaxSessions
+	}
+	return 0
+}
+
+// MaxLifetimeFor 获取设备的会话绝对有效期，0 表示不限制
+func (c *Config) MaxLifetimeFor(device string) time.Duration {
+	if p, ok := c.profile(device); ok && p.MaxLifetime > 0 {
+		return p.MaxLifetime
+	}
+	return c.MaxLifetime
+}
+
+// IdleTimeoutFor 获取设备的会话空闲超时，0 表示不限制
+func (c *Config) IdleTimeoutFor(device string) time.Duration {
+	if p, ok := c.profile(device); ok && p.IdleTimeout > 0 {
+		return p.IdleTimeout
+	}
+	return c.IdleTimeout
+}
+
+// LoginModeFor 获取设备的登录模式
+func (c *Config) LoginModeFor(device string) LoginMode {
+	if p, ok := c.profile(device); ok && p.LoginMode != nil {
+		return *p.LoginMode
+	}
+	return c.LoginMode
+}
+
+// Validate 校验配置，无效时返回包装 ErrConfigInvalid 的错误
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.EncryptionKeys))
+	for _, key := range c.EncryptionKeys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return fmt.Errorf("%w: 加密密钥标识不能为空且不能包含冒号", ErrConfigInvalid)
+		}
+		if n := len(key.Key); n != 16 && n != 24 && n != 32 {
+			return fmt.Errorf("%w: 加密密钥 %s 长度必须为 16/24/32 字节，实际为 %d", ErrConfigInvalid, key.ID, n)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("%w: 重复的加密密钥 %s", ErrConfigInvalid, key.ID)
+		}
+		seen[key.ID] = true
+	}
+	if c.VerifyMode == VerifyStateless || c.VerifyMode == VerifyHybrid {
+		if c.IdleTimeout > 0 {
+			return fmt.Errorf("%w: 无状态与混合校验模式不记录最后访问时间，不支持空闲超时", ErrConfigInvalid)
+		}
+		for device, p := range c.DeviceProfiles {
+			if p.IdleTimeout > 0 {
+				return fmt.Errorf("%w: 无状态与混合校验模式不支持空闲超时（设备 %s）", ErrConfigInvalid, device)
+			}
+		}
+	}
+	if c.MFA.Digits != 0 && (c.MFA.Digits < 6 || c.MFA.Digits > 8) {
+		return fmt.Errorf("%w: TOTP 验证码位数必须为 6~8，实际为 %d", ErrConfigInvalid, c.MFA.Digits)
+	}
+	return nil
+}
+
+// SessionEvictPolicy 会话数量超限时的淘汰策略
+type SessionEvictPolicy int
+
+const (
+	EvictOldest SessionEvictPolicy = iota // 踢出登录时间最早的会话
+	EvictLRU                              // 踢出最近最少访问的会话
+	EvictReject                           // 拒绝新的登录
+)
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	UserID string                 `json:"user_id"`
@@ -154,26 +467,96 @@ type LoginRequest struct {
 	Extra  map[string]interface{} `json:"extra,omitempty"`
 }
 
+// PasswordLoginRequest 密码登录请求
+type PasswordLoginRequest struct {
+	Username string                 `json:"username"` // 登录名（用户名、邮箱、手机号等，由 CredentialVerifier 解析为用户ID）
+	Password string                 `json:"-"`
+	Device   string                 `json:"device,omitempty"`
+	IP       string                 `json:"ip,omitempty"`
+	Extra    map[string]interface{} `json:"extra,omitempty"`
+}
+
+// ImpersonateRequest 模拟登录请求
+type ImpersonateRequest struct {
+	ActorToken   string `json:"actor_token"`      // 操作人（如客服）当前的访问Token
+	TargetUserID string `json:"target_user_id"`   // 被模拟的用户ID
+	Reason       string `json:"reason,omitempty"` // 模拟原因，写入审计事件
+	IP           string `json:"ip,omitempty"`
+}
+
+// Impersonator 模拟登录的实际操作人
+type Impersonator struct {
+	UserID    string    `json:"user_id"`
+	Token     string    `json:"token"` // 操作人原Token的存储引用，结束模拟时据此恢复
+	Reason    string    `json:"reason,omitempty"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// RefreshRequest 刷新请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	IP           string `json:"ip,omitempty"` // 调用方当前IP，为空时沿用原会话IP
+}
+
 // LoginResponse 登录响应
 type LoginResponse struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	ExpireTime   time.Time `json:"expire_time"`
 	UserInfo     *UserInfo `json:"user_info"`
+
+	// 多因素认证：用户已启用时 Token 为空，MFAToken 为待验证Token，ExpireTime 为其过期时间，UserInfo 为 nil
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
 }
 
 // UserInfo 用户信息
 type UserInfo struct {
-	ID       string                 `json:"id"`
-	Username string                 `json:"username"`
-	Roles    []string               `json:"roles"`
-	Extra    map[string]interface{} `json:"extra,omitempty"`
+	ID             string                 `json:"id"`
+	Username       string                 `json:"username"`
+	Roles          []string               `json:"roles"`
+	Extra          map[string]interface{} `json:"extra,omitempty"`
+	ImpersonatorID string                 `json:"impersonator_id,omitempty"` // 模拟登录时为实际操作人的用户ID
+	APIKeyID       string                 `json:"api_key_id,omitempty"`      // 通过 API Key 认证时为 Key 的ID
+	ClientID       string                 `json:"client_id,omitempty"`       // 通过 OAuth2 访问Token认证时为第三方应用的客户端ID
+	Scopes         []string               `json:"scopes,omitempty"`          // 通过 API Key 或 OAuth2 访问Token认证时为授权范围
+}
+
+// ScopeAllows 权限是否在授权范围内：登录会话不受限制，API Key 与 OAuth2 访问Token的授权范围为 "*" 时允许全部权限
+func (u *UserInfo) ScopeAllows(permission string) bool {
+	if u.APIKeyID == "" && u.ClientID == "" {
+		return true
+	}
+	for _, scope := range u.Scopes {
+		if scope == permission || scope == PermissionWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeAllowsRoles 是否可以按角色鉴权：登录会话不受限制，API Key 与 OAuth2 访问Token仅在授权范围为 "*" 时使用所有者的角色
+// 授权范围受限的Key或Token只能通过其范围内的权限访问，避免以所有者的全部角色越权
+func (u *UserInfo) ScopeAllowsRoles() bool {
+	if u.APIKeyID == "" && u.ClientID == "" {
+		return true
+	}
+	for _, scope := range u.Scopes {
+		if scope == PermissionWildcard {
+			return true
+		}
+	}
+	return false
 }
 
 // TokenInfo Token信息
 type TokenInfo struct {
+	ID         string                 `json:"id,omitempty"` // Token唯一标识（签名Token的 jti）
 	UserID     string                 `json:"user_id"`
 	ExpireTime time.Time              `json:"expire_time"`
+	IssuedAt   time.Time              `json:"issued_at,omitempty"` // 签发时间（签名Token的 iat）
+	AuthTime   time.Time              `json:"auth_time,omitempty"` // 首次认证时间（签名Token的 auth_time），刷新后保持不变
+	Footer     string                 `json:"footer,omitempty"`    // PASETO 页脚（明文，已认证）
 	Extra      map[string]interface{} `json:"extra,omitempty"`
 }
 
@@ -184,16 +567,101 @@ type LoginInfo struct {
 	Device     string                 `json:"device"`
 	IP         string                 `json:"ip"`
 	LoginTime  time.Time              `json:"login_time"`
+	AuthTime   time.Time              `json:"auth_time,omitempty"` // 首次认证时间，刷新后保持不变，用于计算绝对有效期
 	LastAccess time.Time              `json:"last_access"`
 	Extra      map[string]interface{} `json:"extra,omitempty"`
+
+	Impersonator *Impersonator `json:"impersonator,omitempty"` // 模拟登录时记录实际操作人
+}
+
+// DisablePermanent 永久封禁时 GetDisableTime 返回的剩余时长
+const DisablePermanent time.Duration = -1
+
+// DisableInfo 账号封禁信息
+type DisableInfo struct {
+	UserID      string    `json:"user_id"`
+	Service     string    `json:"service"` // 封禁的服务范围，DisableServiceAll 表示全部服务
+	Reason      string    `json:"reason"`
+	DisableTime time.Time `json:"disable_time"`
+	ExpireTime  time.Time `json:"expire_time,omitempty"` // 零值表示永久封禁
+}
+
+// Permanent 是否为永久封禁
+func (d *DisableInfo) Permanent() bool {
+	return d.ExpireTime.IsZero()
+}
+
+// 登录失败计数维度
+const (
+	LoginAttemptKindUser = "user" // 按登录名计数
+	LoginAttemptKindIP   = "ip"   // 按客户端IP计数
+	LoginAttemptKindOTP  = "otp"  // 按标识发送验证码的频率限制
+	LoginAttemptKindMFA  = "mfa"  // 按用户统计多因素认证验证码错误
+)
+
+// LoginAttemptPolicy 登录失败限制策略
+type LoginAttemptPolicy struct {
+	MaxAttempts     int           `json:"max_attempts"`      // 窗口内允许的最大失败次数，0 表示不限制
+	MaxIPAttempts   int           `json:"max_ip_attempts"`   // 同一IP在窗口内允许的最大失败次数，0 表示不按IP限制
+	Window          time.Duration `json:"window"`            // 滑动窗口时长，统计此前该时长内的失败次数，默认 15 分钟
+	LockDuration    time.Duration `json:"lock_duration"`     // 首次锁定时长，默认与窗口相同；窗口内再次被锁定时翻倍
+	MaxLockDuration time.Duration `json:"max_lock_duration"` // 锁定时长上限，默认 24 小时
+}
+
+// LoginAttemptInfo 登录锁定记录，窗口内的失败次数由原子计数器单独保存
+type LoginAttemptInfo struct {
+	LockCount int       `json:"lock_count,omitempty"` // 连续锁定次数，用于计算指数退避
+	LockUntil time.Time `json:"lock_until,omitempty"` // 锁定截止时间
+}
+
+// OTPLoginRequest 验证码登录请求
+type OTPLoginRequest struct {
+	Identifier string                 `json:"identifier"` // 接收验证码的邮箱或手机号，由 IdentifierResolver 解析为用户ID
+	Code       string                 `json:"-"`
+	Device     string                 `json:"device,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	Extra      map[string]interface{} `json:"extra,omitempty"`
+}
+
+// OTPConfig 验证码登录配置，零值字段使用默认值
+type OTPConfig struct {
+	CodeLength     int           `json:"code_length"`     // 验证码位数，默认 6
+	TTL            time.Duration `json:"ttl"`             // 验证码有效期，默认 5 分钟
+	MaxAttempts    int           `json:"max_attempts"`    // 每个验证码允许的错误次数，默认 5
+	ResendInterval time.Duration `json:"resend_interval"` // 同一标识两次发送的最小间隔，默认 60 秒
+	MaxSends       int           `json:"max_sends"`       // 同一标识在发送窗口内的最多发送次数，默认 10
+	SendWindow     time.Duration `json:"send_window"`     // 发送次数统计窗口，从窗口内首次发送开始，默认 1 小时
+}
+
+// MFAConfig TOTP 多因素认证配置，零值字段使用默认值
+type MFAConfig struct {
+	Issuer            string        `json:"issuer"`              // otpauth URI 中的签发方，显示在验证器应用中
+	Digits            int           `json:"digits"`              // 验证码位数，取值 6~8，默认 6
+	Period            time.Duration `json:"period"`              // 验证码时间步长，默认 30 秒
+	Skew              int           `json:"skew"`                // 允许的时间偏移步数，默认 1（前后各一个步长）
+	PendingTTL        time.Duration `json:"pending_ttl"`         // 待验证Token有效期，默认 5 分钟
+	MaxAttempts       int           `json:"max_attempts"`        // 每个待验证Token允许的错误次数，默认 5
+	MaxUserAttempts   int           `json:"max_user_attempts"`   // 同一用户跨待验证Token累计允许的错误次数，重新登录不清零，默认 10
+	LockDuration      time.Duration `json:"lock_duration"`       // 用户错误次数达到上限后的首次锁定时长，默认 15 分钟，再次锁定时翻倍
+	RecoveryCodeCount int           `json:"recovery_code_count"` // 恢复码数量，默认 10
+}
+
+// TOTPEnrollment TOTP 绑定信息，恢复码仅在绑定或重新生成时返回一次
+type TOTPEnrollment struct {
+	Secret        string   `json:"secret"` // Base32 编码的密钥
+	URI           string   `json:"uri"`    // otpauth:// URI，用于生成二维码
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 // RefreshTokenInfo 刷新Token信息
 type RefreshTokenInfo struct {
 	RefreshToken string                 `json:"refresh_token"`
+	AccessToken  string                 `json:"access_token,omitempty"` // 与该刷新Token配对的访问Token存储引用
+	FamilyID     string                 `json:"family_id"`              // Token族ID，同一次登录轮换出的刷新Token共享
 	UserID       string                 `json:"user_id"`
 	Device       string                 `json:"device"`
 	CreatedAt    time.Time              `json:"created_at"`
+	AuthTime     time.Time              `json:"auth_time,omitempty"` // 首次认证时间
 	ExpiresAt    time.Time              `json:"expires_at"`
 	Extra        map[string]interface{} `json:"extra"`
 }
@@ -209,12 +677,16 @@ type Role struct {
 type Session struct {
 	ID         string                 `json:"id"`
 	UserID     string                 `json:"user_id"`
-	Token      string                 `json:"token"`
+	Token      string                 `json:"token"`              // Token的存储引用（开启摘要存储时为Token摘要）
+	TokenID    string                 `json:"token_id,omitempty"` // 签名Token的 jti，用于按会话吊销
 	Device     string                 `json:"device"`
 	IP         string                 `json:"ip"`
 	LoginTime  time.Time              `json:"login_time"`
+	AuthTime   time.Time              `json:"auth_time,omitempty"` // 首次认证时间，刷新后保持不变，用于计算绝对有效期
 	LastAccess time.Time              `json:"last_access"`
 	Extra      map[string]interface{} `json:"extra,omitempty"`
+
+	Impersonator *Impersonator `json:"impersonator,omitempty"` // 模拟登录时记录实际操作人
 }
 
 // Config 配置信息
@@ -223,12 +695,61 @@ type Config struct {
 	TokenExpire   time.Duration `json:"token_expire"`
 	RefreshExpire time.Duration `json:"refresh_expire"`
 	TokenStyle    TokenStyle    `json:"token_style"`
+	VerifyMode    VerifyMode    `json:"verify_mode"` // Token校验模式
+	JWT           JWTConfig     `json:"jwt"`         // JWT签名配置（TokenStyle 为 StyleJWT 时生效）
+	Paseto        PasetoConfig  `json:"paseto"`      // PASETO配置（TokenStyle 为 PASETO 风格时生效）
+	SigningKeys   []SigningKey  `json:"-"`           // 签名密钥环（配置后按 kid 选择密钥，优先于 JWT/Paseto 中的单密钥）
+
+	// 校验码Token密钥（TokenStyle 为 StyleChecksum 时生效，不序列化到JSON）
+	// 配置 SigningKeys 时使用当前签名密钥的 Secret 签发，密钥环中的其余对称密钥与 ChecksumSecret 仍可校验
+	ChecksumSecret []byte `json:"-"`
+
+	// Token摘要存储：开启后存储键与持久化记录仅保存Token摘要，不保存原始Token
+	TokenHashing    bool   `json:"token_hashing"`
+	TokenHashSecret []byte `json:"-"` // 摘要密钥，配置后使用 HMAC-SHA256，否则使用 SHA-256
+
+	// 存储加密密钥（配置后使用 AES-GCM 加密存储记录，第一个密钥用于加密，不序列化到JSON）
+	EncryptionKeys []EncryptionKey `json:"-"`
+	// 是否原样读取未加密的历史记录，仅用于开启加密前已有数据的迁移，默认关闭
+	EncryptionAllowPlaintext bool `json:"encryption_allow_plaintext"`
 
 	// 登录配置
 	LoginMode    LoginMode `json:"login_mode"`
 	AutoRenew    bool      `json:"auto_renew"`    // 自动续期
 	RememberDays int       `json:"remember_days"` // 记住登录天数
 
+	// 续期节流：两者均为 0 时每次校验都续期，否则满足任一条件才续期
+	RenewThreshold float64       `json:"renew_threshold"` // 剩余有效期低于Token有效期的该比例时续期，如 0.5
+	RenewInterval  time.Duration `json:"renew_interval"`  // 距上次续期超过该时长时续期
+
+	// 会话时长限制：0 表示不限制
+	MaxLifetime time.Duration `json:"max_lifetime"` // 绝对有效期，从首次登录开始计算，自动续期与刷新均不延长
+	IdleTimeout time.Duration `json:"idle_timeout"` // 空闲超时，超过该时长未访问即失效
+
+	// 会话数量限制：0 表示不限制，超出时按淘汰策略处理
+	MaxSessionsPerUser int                `json:"max_sessions_per_user"`
+	SessionEvictPolicy SessionEvictPolicy `json:"session_evict_policy"`
+
+	// 登录失败限制：按登录名与客户端IP统计密码登录失败次数，超限后临时锁定
+	LoginAttempt LoginAttemptPolicy `json:"login_attempt"`
+
+	// 验证码登录（邮箱、短信）
+	OTP OTPConfig `json:"otp"`
+
+	// 多因素认证：用户绑定并确认 TOTP 后，登录需要再提交验证码
+	MFA MFAConfig `json:"mfa"`
+
+	// API Key 前缀，便于识别Key的来源（如 "gsk"），默认为 "gsk"
+	APIKeyPrefix string `json:"api_key_prefix,omitempty"`
+
+	// 模拟登录：操作人必须拥有该权限，为空时禁止模拟登录（需要配置 UserRoleProvider）
+	ImpersonationPermission string `json:"impersonation_permission,omitempty"`
+	// 模拟登录：目标用户拥有其中任一角色时禁止模拟（需要配置 UserRoleProvider）
+	ImpersonationBlockedRoles []string `json:"impersonation_blocked_roles,omitempty"`
+
+	// 设备配置：按 LoginRequest.Device 覆盖有效期、刷新、自动续期与登录模式
+	DeviceProfiles map[string]DeviceProfile `json:"device_profiles,omitempty"`
+
 	// 存储配置
 	Storage  StorageConfig  `json:"storage"`
 	Redis    RedisConfig    `json:"redis"`
@@ -239,6 +760,92 @@ type Config struct {
 
 	// 用户角色提供者（不序列化到JSON）
 	UserRoleProvider UserRoleProvider `json:"-"`
+
+	// 安全事件监听器（不序列化到JSON）
+	SecurityEventListener SecurityEventListener `json:"-"`
+
+	// 凭证校验器（不序列化到JSON），密码登录时使用
+	CredentialVerifier CredentialVerifier `json:"-"`
+
+	// 验证码发送器与登录标识解析器（不序列化到JSON），验证码登录时使用
+	CodeSender         CodeSender         `json:"-"`
+	IdentifierResolver IdentifierResolver `json:"-"`
+}
+
+// SecurityEventType 安全事件类型
+type SecurityEventType string
+
+const (
+	// SecurityEventRefreshTokenReuse 已轮换的刷新Token被重复使用，整个Token族已被吊销
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+
+	// SecurityEventLoginLocked 登录失败次数过多被锁定，Extra 中包含锁定维度与锁定时长
+	SecurityEventLoginLocked SecurityEventType = "login_locked"
+
+	// SecurityEventImpersonationStart 开始模拟登录，UserID 为被模拟用户，Extra 中包含操作人与原因
+	SecurityEventImpersonationStart SecurityEventType = "impersonation_start"
+
+	// SecurityEventImpersonationEnd 结束模拟登录
+	SecurityEventImpersonationEnd SecurityEventType = "impersonation_end"
+
+	// SecurityEventImpersonationDenied 模拟登录被拒绝
+	SecurityEventImpersonationDenied SecurityEventType = "impersonation_denied"
+)
+
+// SecurityEvent 安全事件
+type SecurityEvent struct {
+	Type   SecurityEventType      `json:"type"`
+	UserID string                 `json:"user_id"`
+	Time   time.Time              `json:"time"`
+	Extra  map[string]interface{} `json:"extra,omitempty"`
+}
+
+// JWTConfig JWT签名配置
+type JWTConfig struct {
+	Algorithm string        `json:"algorithm"` // 签名算法：HS256/RS256/ES256/EdDSA
+	Issuer    string        `json:"issuer"`    // 签发者（iss），为空则不写入也不校验
+	Audience  string        `json:"audience"`  // 受众（aud），为空则不写入也不校验
+	Leeway    time.Duration `json:"leeway"`    // 时间校验容差（exp/nbf/iat）
+
+	// 密钥材料（不序列化到JSON）
+	Secret     []byte           `json:"-"` // HS256 密钥
+	PrivateKey crypto.Signer    `json:"-"` // RS256/ES256/EdDSA 私钥（签发方需要）
+	PublicKey  crypto.PublicKey `json:"-"` // RS256/ES256/EdDSA 公钥（仅校验方可只配置公钥）
+}
+
+// EncryptionKey 存储加密密钥
+type EncryptionKey struct {
+	ID  string `json:"kid"` // 密钥标识，写入密文信封用于解密时选择密钥
+	Key []byte `json:"-"`   // AES 密钥，长度为 16/24/32 字节
+}
+
+// SigningKey 密钥环中的签名/加密密钥
+type SigningKey struct {
+	ID        string `json:"kid"`       // 密钥标识（kid），写入Token头部或页脚
+	Algorithm string `json:"algorithm"` // JWT算法或 PASETO 用途（v4.local/v4.public）
+
+	// 密钥材料
+	Secret     []byte           `json:"-"` // HS256 密钥或 v4.local 对称密钥
+	PrivateKey crypto.Signer    `json:"-"` // 非对称私钥（仅签发方需要）
+	PublicKey  crypto.PublicKey `json:"-"` // 非对称公钥（仅校验方可只配置公钥）
+
+	// 轮换时间
+	ActivateAt  time.Time `json:"activate_at"`  // 计划启用时间，到期后自动成为签名密钥；零值表示不参与计划轮换
+	VerifyUntil time.Time `json:"verify_until"` // 校验截止时间，之后该密钥签发的Token不再被接受；零值表示不限
+}
+
+// PasetoConfig PASETO v4 配置
+type PasetoConfig struct {
+	Issuer            string        `json:"issuer"`             // 签发者（iss），为空则不写入也不校验
+	Audience          string        `json:"audience"`           // 受众（aud），为空则不写入也不校验
+	Leeway            time.Duration `json:"leeway"`             // 时间校验容差（exp/nbf/iat）
+	Footer            string        `json:"footer"`             // 页脚，随Token明文传输并参与认证
+	ImplicitAssertion string        `json:"implicit_assertion"` // 隐式断言，不随Token传输，签发与校验双方需一致
+
+	// 密钥材料（不序列化到JSON）
+	LocalKey   []byte             `json:"-"` // v4.local 32字节对称密钥
+	PrivateKey ed25519.PrivateKey `json:"-"` // v4.public 签名私钥（签发方需要）
+	PublicKey  ed25519.PublicKey  `json:"-"` // v4.public 校验公钥（仅校验方可只配置公钥）
 }
 
 // StorageConfig 存储配置
@@ -3,9 +3,13 @@ package gstoken
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/luckxgo/gstoken/apikey"
 	"github.com/luckxgo/gstoken/auth"
 	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/oauth2"
+	"github.com/luckxgo/gstoken/sso"
 	"github.com/luckxgo/gstoken/storage"
 	"github.com/luckxgo/gstoken/token"
 	"github.com/luckxgo/gstoken/web"
@@ -18,10 +22,17 @@ type GSToken struct {
 	generator  core.TokenGenerator
 	engine     core.AuthEngine
 	keyService *core.KeyService
+	apiKeys    *apikey.Service
+	oauth2     *oauth2.Server
 }
 
 // New 创建新的GSToken实例
+// 配置无效时 panic，需要以错误形式处理时可先调用 config.Validate()
 func New(config *core.Config) *GSToken {
+	if err := config.Validate(); err != nil {
+		panic(fmt.Sprintf("gstoken: %v", err))
+	}
+
 	gs := &GSToken{
 		config: config,
 	}
@@ -33,11 +44,14 @@ func New(config *core.Config) *GSToken {
 	gs.initStorage()
 
 	// 初始化Token生成器
-	gs.generator = token.NewGenerator(config.TokenStyle)
+	gs.generator = token.NewGeneratorWithConfig(config)
 
 	// 初始化认证引擎
 	gs.engine = auth.NewEngine(config, gs.storage, gs.generator, gs.keyService)
 
+	// 初始化 API Key 服务
+	gs.apiKeys = apikey.NewService(gs.storage, gs.keyService, config.APIKeyPrefix)
+
 	// 如果配置中设置了用户角色提供者，自动配置
 	if config.UserRoleProvider != nil {
 		gs.engine.GetPermissionService().SetUserRoleProvider(config.UserRoleProvider)
@@ -57,6 +71,13 @@ func (gs *GSToken) initStorage() {
 		// 默认使用内存存储
 		gs.storage = storage.NewMemoryStorage()
 	}
+
+	// 配置了加密密钥时，记录加密后再写入存储
+	if len(gs.config.EncryptionKeys) > 0 {
+		encrypted := storage.NewEncryptedStorage(gs.storage, gs.config.EncryptionKeys...)
+		encrypted.SetAllowPlaintext(gs.config.EncryptionAllowPlaintext)
+		gs.storage = encrypted
+	}
 }
 
 // GetConfig 获取配置
@@ -74,6 +95,14 @@ func (gs *GSToken) GetTokenGenerator() core.TokenGenerator {
 	return gs.generator
 }
 
+// GetKeyring 获取签名密钥环（未配置 SigningKeys 时为 nil）
+func (gs *GSToken) GetKeyring() *token.Keyring {
+	if generator, ok := gs.generator.(*token.Generator); ok {
+		return generator.Keyring()
+	}
+	return nil
+}
+
 // GetAuthEngine 获取认证引擎
 func (gs *GSToken) GetAuthEngine() core.AuthEngine {
 	return gs.engine
@@ -149,6 +178,15 @@ func (gs *GSToken) RefreshToken(ctx context.Context, refreshToken string) (*core
 	return nil, fmt.Errorf("RefreshToken功能不可用")
 }
 
+// Refresh 使用刷新请求刷新Token（可携带调用方当前IP）
+func (gs *GSToken) Refresh(ctx context.Context, req *core.RefreshRequest) (*core.LoginResponse, error) {
+	// 直接通过引擎实现调用
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.Refresh(ctx, req)
+	}
+	return nil, fmt.Errorf("Refresh功能不可用")
+}
+
 // CheckRole 检查用户角色
 func (gs *GSToken) CheckRole(ctx context.Context, userID string, roleID string) (bool, error) {
 	return gs.engine.CheckRole(ctx, userID, roleID)
@@ -158,3 +196,281 @@ func (gs *GSToken) CheckRole(ctx context.Context, userID string, roleID string)
 func (gs *GSToken) GetPermissionService() core.PermissionService {
 	return gs.engine.GetPermissionService()
 }
+
+// Disable 封禁账号：service 为空表示封禁全部服务，duration <= 0 表示永久封禁
+func (gs *GSToken) Disable(ctx context.Context, userID, service string, duration time.Duration, reason string) error {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.Disable(ctx, userID, service, duration, reason)
+	}
+	return fmt.Errorf("封禁功能不可用")
+}
+
+// IsDisabled 检查账号在指定服务上是否被封禁
+func (gs *GSToken) IsDisabled(ctx context.Context, userID, service string) (bool, error) {
+	disableService, err := gs.disableService()
+	if err != nil {
+		return false, err
+	}
+	return disableService.IsDisabled(ctx, userID, service)
+}
+
+// GetDisableTime 获取剩余封禁时长，未封禁返回 0，永久封禁返回 core.DisablePermanent
+func (gs *GSToken) GetDisableTime(ctx context.Context, userID, service string) (time.Duration, error) {
+	disableService, err := gs.disableService()
+	if err != nil {
+		return 0, err
+	}
+	return disableService.GetDisableTime(ctx, userID, service)
+}
+
+// Untie 解除账号在指定服务上的封禁
+func (gs *GSToken) Untie(ctx context.Context, userID, service string) error {
+	disableService, err := gs.disableService()
+	if err != nil {
+		return err
+	}
+	return disableService.Untie(ctx, userID, service)
+}
+
+// GetDisableService 获取封禁服务
+func (gs *GSToken) GetDisableService() core.DisableService {
+	disableService, _ := gs.disableService()
+	return disableService
+}
+
+// disableService 通过引擎实现获取封禁服务
+func (gs *GSToken) disableService() (core.DisableService, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.GetDisableService(), nil
+	}
+	return nil, fmt.Errorf("封禁功能不可用")
+}
+
+// OpenSafe 为Token开启二级认证窗口，service 为空时使用默认服务
+func (gs *GSToken) OpenSafe(ctx context.Context, token, service string, duration time.Duration) error {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.OpenSafe(ctx, token, service, duration)
+	}
+	return fmt.Errorf("二级认证功能不可用")
+}
+
+// IsSafe 检查Token在指定服务上是否处于二级认证窗口内
+func (gs *GSToken) IsSafe(ctx context.Context, token, service string) (bool, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.IsSafe(ctx, token, service)
+	}
+	return false, fmt.Errorf("二级认证功能不可用")
+}
+
+// CloseSafe 关闭Token在指定服务上的二级认证窗口
+func (gs *GSToken) CloseSafe(ctx context.Context, token, service string) error {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.CloseSafe(ctx, token, service)
+	}
+	return fmt.Errorf("二级认证功能不可用")
+}
+
+// CreateTempToken 创建绑定用途的一次性临时Token，value 为消费时返回的业务数据
+func (gs *GSToken) CreateTempToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.CreateTempToken(ctx, purpose, value, ttl)
+	}
+	return "", fmt.Errorf("临时Token功能不可用")
+}
+
+// ConsumeTempToken 消费临时Token并返回业务数据，只能成功一次
+func (gs *GSToken) ConsumeTempToken(ctx context.Context, purpose, token string) (string, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.ConsumeTempToken(ctx, purpose, token)
+	}
+	return "", fmt.Errorf("临时Token功能不可用")
+}
+
+// Impersonate 模拟登录：操作人以目标用户身份签发访问Token，会话中记录实际操作人
+func (gs *GSToken) Impersonate(ctx context.Context, req *core.ImpersonateRequest) (*core.LoginResponse, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.Impersonate(ctx, req)
+	}
+	return nil, fmt.Errorf("模拟登录功能不可用")
+}
+
+// EndImpersonation 结束模拟登录，返回操作人原会话的登录信息
+func (gs *GSToken) EndImpersonation(ctx context.Context, token string) (*core.LoginInfo, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.EndImpersonation(ctx, token)
+	}
+	return nil, fmt.Errorf("模拟登录功能不可用")
+}
+
+// LoginWithPassword 密码登录：校验凭证通过后签发Token，需要配置 CredentialVerifier
+func (gs *GSToken) LoginWithPassword(ctx context.Context, req *core.PasswordLoginRequest) (*core.LoginResponse, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.LoginWithPassword(ctx, req)
+	}
+	return nil, fmt.Errorf("密码登录功能不可用")
+}
+
+// ResetLoginAttempts 清除登录名或IP的登录失败记录与锁定，参数为空时跳过对应维度
+func (gs *GSToken) ResetLoginAttempts(ctx context.Context, username, ip string) error {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.ResetLoginAttempts(ctx, username, ip)
+	}
+	return fmt.Errorf("登录失败限制功能不可用")
+}
+
+// GetLoginAttemptService 获取登录失败限制服务，自定义登录流程可直接调用 RecordFailure 计数
+func (gs *GSToken) GetLoginAttemptService() core.LoginAttemptService {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.GetLoginAttemptService()
+	}
+	return nil
+}
+
+// EnrollTOTP 为用户生成 TOTP 密钥、otpauth URI 与恢复码，调用 ConfirmTOTP 确认后生效
+func (gs *GSToken) EnrollTOTP(ctx context.Context, userID, accountName string) (*core.TOTPEnrollment, error) {
+	mfaService, err := gs.mfaService()
+	if err != nil {
+		return nil, err
+	}
+	return mfaService.Enroll(ctx, userID, accountName)
+}
+
+// ConfirmTOTP 使用验证器应用生成的验证码确认绑定，之后登录需要多因素认证
+func (gs *GSToken) ConfirmTOTP(ctx context.Context, userID, code string) error {
+	mfaService, err := gs.mfaService()
+	if err != nil {
+		return err
+	}
+	return mfaService.Confirm(ctx, userID, code)
+}
+
+// IsMFAEnabled 用户是否已启用多因素认证
+func (gs *GSToken) IsMFAEnabled(ctx context.Context, userID string) (bool, error) {
+	mfaService, err := gs.mfaService()
+	if err != nil {
+		return false, err
+	}
+	return mfaService.IsEnabled(ctx, userID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (gs *GSToken) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	mfaService, err := gs.mfaService()
+	if err != nil {
+		return nil, err
+	}
+	return mfaService.RegenerateRecoveryCodes(ctx, userID)
+}
+
+// DisableMFA 解除用户的多因素认证绑定
+func (gs *GSToken) DisableMFA(ctx context.Context, userID string) error {
+	mfaService, err := gs.mfaService()
+	if err != nil {
+		return err
+	}
+	return mfaService.Disable(ctx, userID)
+}
+
+// VerifyMFA 提交验证码（TOTP 或恢复码）完成两阶段登录，返回正式的访问Token
+func (gs *GSToken) VerifyMFA(ctx context.Context, mfaToken, code string) (*core.LoginResponse, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.VerifyMFA(ctx, mfaToken, code)
+	}
+	return nil, fmt.Errorf("多因素认证功能不可用")
+}
+
+// GetMFAService 获取多因素认证服务
+func (gs *GSToken) GetMFAService() core.MFAService {
+	mfaService, _ := gs.mfaService()
+	return mfaService
+}
+
+// mfaService 通过引擎实现获取多因素认证服务
+func (gs *GSToken) mfaService() (core.MFAService, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.GetMFAService(), nil
+	}
+	return nil, fmt.Errorf("多因素认证功能不可用")
+}
+
+// RequestLoginCode 为登录标识（邮箱、手机号）生成并发送登录验证码，需要配置 CodeSender
+func (gs *GSToken) RequestLoginCode(ctx context.Context, identifier string) error {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.RequestLoginCode(ctx, identifier)
+	}
+	return fmt.Errorf("验证码登录功能不可用")
+}
+
+// LoginWithCode 验证码登录：校验验证码通过后签发Token
+func (gs *GSToken) LoginWithCode(ctx context.Context, req *core.OTPLoginRequest) (*core.LoginResponse, error) {
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		return engine.LoginWithCode(ctx, req)
+	}
+	return nil, fmt.Errorf("验证码登录功能不可用")
+}
+
+// GetAPIKeyService 获取 API Key 服务，用于创建、列出、吊销与轮换机器客户端的 API Key
+func (gs *GSToken) GetAPIKeyService() *apikey.Service {
+	return gs.apiKeys
+}
+
+// VerifyAPIKey 校验 API Key 并返回所有者的用户信息，所有者被封禁时同样拒绝
+func (gs *GSToken) VerifyAPIKey(ctx context.Context, key string) (*core.UserInfo, error) {
+	userInfo, err := gs.apiKeys.VerifyAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		if err := engine.CheckDisabled(ctx, userInfo.ID, core.DisableServiceAll); err != nil {
+			return nil, err
+		}
+	}
+	return userInfo, nil
+}
+
+// EnableOAuth2 创建 OAuth2 授权服务器，复用 GSToken 的存储与Token生成器
+// 启用后 OAuth2 访问Token可通过 web 中间件认证，权限检查受Token的 scope 限制
+func (gs *GSToken) EnableOAuth2(config *oauth2.Config) *oauth2.Server {
+	gs.oauth2 = oauth2.NewServer(gs.storage, gs.keyService, gs.generator, config)
+	return gs.oauth2
+}
+
+// GetOAuth2Server 获取 OAuth2 授权服务器，未调用 EnableOAuth2 时为 nil
+func (gs *GSToken) GetOAuth2Server() *oauth2.Server {
+	return gs.oauth2
+}
+
+// VerifyAccessToken 校验 OAuth2 访问Token并返回资源所有者的用户信息，用户被封禁时同样拒绝
+func (gs *GSToken) VerifyAccessToken(ctx context.Context, token string) (*core.UserInfo, error) {
+	if gs.oauth2 == nil {
+		return nil, core.ErrOAuthTokenInvalid
+	}
+	userInfo, err := gs.oauth2.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if engine, ok := gs.engine.(*auth.Engine); ok {
+		if err := engine.CheckDisabled(ctx, userInfo.ID, core.DisableServiceAll); err != nil {
+			return nil, err
+		}
+	}
+	return userInfo, nil
+}
+
+// NewSSOServer 创建 SSO 服务端（认证中心），复用 GSToken 的存储与认证引擎
+// 未设置 SessionTTL 时使用登录Token有效期
+func (gs *GSToken) NewSSOServer(config *sso.ServerConfig) *sso.Server {
+	var cfg sso.ServerConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = gs.config.TokenExpire
+	}
+	return sso.NewServer(gs.storage, gs.keyService, gs.engine, &cfg)
+}
+
+// NewSSOClient 创建 SSO 应用端，用票据换取的本地Token由 GSToken 签发
+func (gs *GSToken) NewSSOClient(config *sso.ClientConfig) *sso.Client {
+	return sso.NewClient(gs.storage, gs.keyService, gs.engine, config)
+}
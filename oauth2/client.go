@@ -0,0 +1,228 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// 授权类型
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+const (
+	// clientIDSize 自动生成的客户端ID随机字节数
+	clientIDSize = 12
+
+	// clientSecretSize 客户端密钥随机字节数
+	clientSecretSize = 32
+)
+
+// Client OAuth2 客户端（第三方应用），存储中只保存密钥摘要
+type Client struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"secret_hash,omitempty"`
+	RedirectURIs []string  `json:"redirect_uris,omitempty"` // 已注册的回调地址，授权请求中的 redirect_uri 必须完全一致
+	Scopes       []string  `json:"scopes,omitempty"`        // 允许申请的 scope，对应权限标识，"*" 表示不限制
+	GrantTypes   []string  `json:"grant_types,omitempty"`   // 允许的授权类型，为空时允许授权码与刷新Token
+	Public       bool      `json:"public,omitempty"`        // 公开客户端（单页应用、移动端）没有密钥，必须使用 PKCE
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AllowsGrant 是否允许使用指定授权类型
+func (c *Client) AllowsGrant(grantType string) bool {
+	if len(c.GrantTypes) == 0 {
+		return grantType == GrantTypeAuthorizationCode || grantType == GrantTypeRefreshToken
+	}
+	for _, t := range c.GrantTypes {
+		if t == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsScope 是否允许申请指定 scope
+func (c *Client) allowsScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope || s == core.PermissionWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRedirectURI 回调地址是否已注册
+func (c *Client) hasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitized 返回不含密钥摘要的副本
+func (c *Client) sanitized() *Client {
+	copied := *c
+	copied.SecretHash = ""
+	return &copied
+}
+
+// ClientRegistry 基于存储的客户端注册表
+type ClientRegistry struct {
+	storage    core.Storage
+	keyService *core.KeyService
+}
+
+// NewClientRegistry 创建客户端注册表
+func NewClientRegistry(storage core.Storage, keyService *core.KeyService) *ClientRegistry {
+	return &ClientRegistry{
+		storage:    storage,
+		keyService: keyService,
+	}
+}
+
+// Register 注册客户端，ID 为空时自动生成，ID 已被注册时返回 core.ErrOAuthClientExists
+// 机密客户端返回新生成的密钥（只返回一次），公开客户端返回空字符串
+func (r *ClientRegistry) Register(ctx context.Context, client *Client) (string, error) {
+	if client == nil {
+		return "", errors.New(core.ErrMsgOAuthClientEmpty)
+	}
+	if client.AllowsGrant(GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return "", errors.New(core.ErrMsgOAuthRedirectURIs)
+	}
+	if client.Public && client.AllowsGrant(GrantTypeClientCredentials) {
+		return "", errors.New(core.ErrMsgOAuthPublicGrant)
+	}
+
+	registered := *client
+	if registered.ID == "" {
+		id, err := randomString(clientIDSize, hex.EncodeToString)
+		if err != nil {
+			return "", err
+		}
+		registered.ID = id
+	}
+	exists, err := r.storage.Exists(ctx, r.keyService.OAuthClientKey(registered.ID))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGetOAuthClient, err)
+	}
+	if exists {
+		return "", core.ErrOAuthClientExists
+	}
+	registered.CreatedAt = time.Now()
+	registered.SecretHash = ""
+
+	var secret string
+	if !registered.Public {
+		var err error
+		secret, err = randomString(clientSecretSize, base64.RawURLEncoding.EncodeToString)
+		if err != nil {
+			return "", err
+		}
+		registered.SecretHash = hashSecret(secret)
+	}
+
+	if err := r.storage.Set(ctx, r.keyService.OAuthClientKey(registered.ID), &registered, 0); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveOAuthClient, err)
+	}
+	client.ID = registered.ID
+	return secret, nil
+}
+
+// Get 获取客户端，不含密钥摘要
+func (r *ClientRegistry) Get(ctx context.Context, id string) (*Client, error) {
+	client, err := r.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return client.sanitized(), nil
+}
+
+// Delete 删除客户端，之后该客户端的授权码与刷新Token不能再换取Token，已签发的访问Token在过期前仍然有效
+func (r *ClientRegistry) Delete(ctx context.Context, id string) error {
+	if err := r.storage.Delete(ctx, r.keyService.OAuthClientKey(id)); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgDeleteOAuthClient, err)
+	}
+	return nil
+}
+
+// Authenticate 认证客户端：机密客户端校验密钥，公开客户端不能携带密钥
+func (r *ClientRegistry) Authenticate(ctx context.Context, id, secret string) (*Client, error) {
+	client, err := r.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, core.ErrOAuthInvalidClient
+		}
+		return client.sanitized(), nil
+	}
+	if secret == "" || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return nil, core.ErrOAuthInvalidClient
+	}
+	return client.sanitized(), nil
+}
+
+// get 读取客户端记录，不存在时返回 core.ErrOAuthInvalidClient
+func (r *ClientRegistry) get(ctx context.Context, id string) (*Client, error) {
+	if id == "" {
+		return nil, core.ErrOAuthInvalidClient
+	}
+
+	// 存储对不存在的键返回错误，先判断是否存在以区分存储故障
+	key := r.keyService.OAuthClientKey(id)
+	exists, err := r.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetOAuthClient, err)
+	}
+	if !exists {
+		return nil, core.ErrOAuthInvalidClient
+	}
+	data, err := r.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetOAuthClient, err)
+	}
+
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var client Client
+	if err := json.Unmarshal(dataBytes, &client); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseOAuthClient, err)
+	}
+	return &client, nil
+}
+
+// hashSecret 客户端密钥与授权码的 SHA-256 摘要（均为高熵随机值，无需慢哈希）
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString 生成随机字节并编码
+func randomString(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateOAuthToken, err)
+	}
+	return encode(buf), nil
+}
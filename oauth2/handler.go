@@ -0,0 +1,303 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// 错误码（RFC 6749 第 4.1.2.1 与 5.2 节）
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorLoginRequired           = "login_required"
+	ErrorServerError             = "server_error"
+)
+
+// errorCodes 错误与错误码的对应关系
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{core.ErrOAuthInvalidRequest, ErrorInvalidRequest},
+	{core.ErrOAuthInvalidClient, ErrorInvalidClient},
+	{core.ErrOAuthInvalidGrant, ErrorInvalidGrant},
+	{core.ErrOAuthUnauthorizedClient, ErrorUnauthorizedClient},
+	{core.ErrOAuthUnsupportedGrantType, ErrorUnsupportedGrantType},
+	{core.ErrOAuthUnsupportedResponseType, ErrorUnsupportedResponseType},
+	{core.ErrOAuthInvalidScope, ErrorInvalidScope},
+	{core.ErrOAuthAccessDenied, ErrorAccessDenied},
+}
+
+// ConsentFunc 授权确认钩子，在签发授权码之前调用
+// 返回用户同意授予的 scope（申请范围的子集，为空时授予全部申请范围）；ok 为 false 表示钩子已自行写入响应
+// （如渲染授权确认页，确认页应以相同的参数提交回授权端点）；返回 core.ErrOAuthAccessDenied 表示用户拒绝授权
+type ConsentFunc func(w http.ResponseWriter, r *http.Request, req *AuthorizeRequest, userID string) (scopes []string, ok bool, err error)
+
+// userIDContextKey 请求上下文中当前登录用户ID的键
+type userIDContextKey struct{}
+
+// WithUserID 将当前登录用户ID写入请求上下文，供授权端点识别用户
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext 读取 WithUserID 写入的用户ID
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey{}).(string)
+	return userID
+}
+
+// AuthorizeHandler 授权端点，GET 与 POST（授权确认页提交）均可
+func (s *Server) AuthorizeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, core.ErrOAuthInvalidRequest)
+			return
+		}
+
+		req, err := s.ParseAuthorizeRequest(r.Context(), r.Form)
+		if err != nil {
+			if req == nil {
+				// 客户端或回调地址不可信，不能重定向
+				status := http.StatusBadRequest
+				if errorCode(err) == ErrorServerError {
+					status = http.StatusInternalServerError
+				}
+				writeError(w, status, err)
+				return
+			}
+			redirectError(w, r, req, err)
+			return
+		}
+
+		userID := s.currentUser(r)
+		if userID == "" {
+			if s.config.LoginURL != "" {
+				http.Redirect(w, r, redirectURL(s.config.LoginURL, url.Values{"return_to": {r.URL.RequestURI()}}), http.StatusFound)
+				return
+			}
+			writeJSON(w, http.StatusUnauthorized, map[string]string{
+				"error":             ErrorLoginRequired,
+				"error_description": core.ErrMsgOAuthLoginRequired,
+			})
+			return
+		}
+
+		var scopes []string
+		switch {
+		case s.config.Consent != nil:
+			var ok bool
+			scopes, ok, err = s.config.Consent(w, r, req, userID)
+			if err != nil {
+				redirectError(w, r, req, err)
+				return
+			}
+			if !ok {
+				return
+			}
+		case !s.config.AutoApprove:
+			// 未配置授权确认时不能替用户同意授权
+			redirectError(w, r, req, fmt.Errorf("%s: %w", core.ErrMsgOAuthConsentRequired, core.ErrOAuthAccessDenied))
+			return
+		}
+
+		location, err := s.Authorize(r.Context(), req, userID, scopes)
+		if err != nil {
+			redirectError(w, r, req, err)
+			return
+		}
+		http.Redirect(w, r, location, http.StatusFound)
+	}
+}
+
+// TokenHandler 令牌端点，仅接受 POST 表单；客户端可通过 HTTP Basic 或表单参数 client_id、client_secret 认证
+func (s *Server) TokenHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, core.ErrOAuthInvalidRequest)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, core.ErrOAuthInvalidRequest)
+			return
+		}
+
+		clientID, clientSecret := clientCredentials(r)
+		req := &TokenRequest{
+			GrantType:    r.PostForm.Get("grant_type"),
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			Scopes:       strings.Fields(r.PostForm.Get("scope")),
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		resp, err := s.Token(r.Context(), req)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// RevokeHandler Token吊销端点（RFC 7009），仅接受 POST 表单，客户端认证方式与令牌端点相同
+func (s *Server) RevokeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, core.ErrOAuthInvalidRequest)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, core.ErrOAuthInvalidRequest)
+			return
+		}
+
+		clientID, clientSecret := clientCredentials(r)
+		err := s.Revoke(r.Context(), &RevokeRequest{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Token:        r.PostForm.Get("token"),
+		})
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Register 在 ServeMux 上挂载 <prefix>/authorize、<prefix>/token 与 <prefix>/revoke
+func (s *Server) Register(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.Handle(prefix+"/authorize", s.AuthorizeHandler())
+	mux.Handle(prefix+"/token", s.TokenHandler())
+	mux.Handle(prefix+"/revoke", s.RevokeHandler())
+}
+
+// GinAuthorizeHandler Gin 授权端点，从上下文读取登录用户，需在其前挂载 OptionalAuth 或 RequireAuth
+// 只接受登录会话，通过 API Key 或 OAuth2 访问Token认证的请求视为未登录
+func (s *Server) GinAuthorizeHandler() gin.HandlerFunc {
+	handler := s.AuthorizeHandler()
+	return func(c *gin.Context) {
+		r := c.Request
+		if value, ok := c.Get(web.ContextKeyUserInfo); ok {
+			if userInfo, ok := value.(*core.UserInfo); ok && userInfo.APIKeyID == "" && userInfo.ClientID == "" {
+				r = r.WithContext(WithUserID(r.Context(), userInfo.ID))
+			}
+		}
+		handler(c.Writer, r)
+	}
+}
+
+// GinTokenHandler Gin 令牌端点
+func (s *Server) GinTokenHandler() gin.HandlerFunc {
+	handler := s.TokenHandler()
+	return func(c *gin.Context) {
+		handler(c.Writer, c.Request)
+	}
+}
+
+// GinRevokeHandler Gin Token吊销端点
+func (s *Server) GinRevokeHandler() gin.HandlerFunc {
+	handler := s.RevokeHandler()
+	return func(c *gin.Context) {
+		handler(c.Writer, c.Request)
+	}
+}
+
+// clientCredentials 读取客户端ID与密钥，HTTP Basic 优先于表单参数
+// HTTP Basic 中的客户端ID与密钥按表单编码（RFC 6749 第 2.3.1 节）
+func clientCredentials(r *http.Request) (id, secret string) {
+	if basicID, basicSecret, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(basicID)
+		secret, _ = url.QueryUnescape(basicSecret)
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// writeClientError 写入令牌端点与吊销端点的错误响应，客户端认证失败返回 401
+func writeClientError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch errorCode(err) {
+	case ErrorInvalidClient:
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	case ErrorServerError:
+		status = http.StatusInternalServerError
+	}
+	writeError(w, status, err)
+}
+
+// currentUser 获取当前登录用户ID
+func (s *Server) currentUser(r *http.Request) string {
+	if s.config.CurrentUser != nil {
+		return s.config.CurrentUser(r)
+	}
+	return UserIDFromContext(r.Context())
+}
+
+// errorCode 获取错误对应的错误码，未知错误为 server_error
+func errorCode(err error) string {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return ErrorServerError
+}
+
+// errorDescription 错误描述，内部错误不向客户端暴露细节
+func errorDescription(err error) string {
+	if errorCode(err) == ErrorServerError {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	return err.Error()
+}
+
+// redirectError 通过回调地址返回授权错误
+func redirectError(w http.ResponseWriter, r *http.Request, req *AuthorizeRequest, err error) {
+	http.Redirect(w, r, redirectURL(req.RedirectURI, url.Values{
+		"error":             {errorCode(err)},
+		"error_description": {errorDescription(err)},
+		"state":             {req.State},
+	}), http.StatusFound)
+}
+
+// writeError 返回 JSON 错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{
+		"error":             errorCode(err),
+		"error_description": errorDescription(err),
+	})
+}
+
+// writeJSON 返回 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,536 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+const (
+	defaultAccessTokenTTL  = time.Hour
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultCodeTTL         = time.Minute
+
+	// codeSize 授权码随机字节数
+	codeSize = 32
+
+	// TokenTypeBearer 令牌响应中的 token_type
+	TokenTypeBearer = "Bearer"
+
+	// ClientSubjectPrefix 客户端凭证模式Token的用户ID前缀，权限提供者按 "client:<客户端ID>" 为客户端分配角色
+	ClientSubjectPrefix = "client:"
+)
+
+// PKCE 方法（RFC 7636）
+const (
+	PKCEMethodS256  = "S256"
+	PKCEMethodPlain = "plain"
+)
+
+// Config 授权服务器配置，零值字段使用默认值
+type Config struct {
+	AccessTokenTTL  time.Duration // 访问Token有效期，默认 1 小时
+	RefreshTokenTTL time.Duration // 刷新Token有效期，默认 30 天
+	CodeTTL         time.Duration // 授权码有效期，默认 1 分钟
+
+	// LoginURL 授权端点遇到未登录用户时重定向的登录页，原授权地址通过 return_to 参数传递；为空时返回 401
+	LoginURL string
+
+	// CurrentUser 获取当前登录用户ID，为空时读取 WithUserID 写入请求上下文的用户ID
+	CurrentUser func(r *http.Request) string
+
+	// Consent 授权确认钩子，为空且未开启 AutoApprove 时授权端点拒绝授权
+	Consent ConsentFunc
+
+	// AutoApprove 未设置 Consent 时直接授予申请的 scope，仅适用于全部客户端均为受信任的第一方应用的场景
+	AutoApprove bool
+}
+
+// AuthorizeRequest 已校验的授权请求
+type AuthorizeRequest struct {
+	Client              *Client
+	RedirectURI         string // 生效的回调地址，请求未携带且客户端只注册了一个时为该地址
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+
+	redirectURIParam string // 请求中实际携带的 redirect_uri，换取Token时需一致
+}
+
+// TokenRequest 令牌请求
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scopes       []string // 刷新Token与客户端凭证模式可申请更小的范围，为空时沿用原授权范围
+}
+
+// RevokeRequest Token吊销请求（RFC 7009）
+type RevokeRequest struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
+}
+
+// TokenResponse 令牌响应（RFC 6749 第 5.1 节）
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// grant 授权记录，授权码、访问Token与刷新Token共用
+type grant struct {
+	ClientID            string    `json:"client_id"`
+	UserID              string    `json:"user_id,omitempty"` // 客户端凭证模式为空
+	Scopes              []string  `json:"scopes,omitempty"`
+	RedirectURI         string    `json:"redirect_uri,omitempty"`
+	CodeChallenge       string    `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string    `json:"code_challenge_method,omitempty"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+// Server OAuth2 授权服务器，支持授权码（PKCE）、刷新Token与客户端凭证模式
+// 访问Token与刷新Token由 TokenGenerator 生成并标记为 OAuth2 类型，授权记录保存在存储中；
+// 访问Token绑定客户端与 scope，不能作为登录会话Token使用
+type Server struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	generator  core.TokenGenerator
+	config     Config
+	clients    *ClientRegistry
+}
+
+// NewServer 创建授权服务器
+func NewServer(storage core.Storage, keyService *core.KeyService, generator core.TokenGenerator, config *Config) *Server {
+	var cfg Config
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		cfg.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = defaultCodeTTL
+	}
+	return &Server{
+		storage:    storage,
+		keyService: keyService,
+		generator:  generator,
+		config:     cfg,
+		clients:    NewClientRegistry(storage, keyService),
+	}
+}
+
+// Clients 获取客户端注册表
+func (s *Server) Clients() *ClientRegistry {
+	return s.clients
+}
+
+// ParseAuthorizeRequest 校验授权请求参数（RFC 6749 第 4.1.1 节与 RFC 7636）
+// 客户端或回调地址无效时返回的请求为 nil，错误不能重定向给客户端；其余错误返回请求，应通过回调地址告知客户端
+func (s *Server) ParseAuthorizeRequest(ctx context.Context, params url.Values) (*AuthorizeRequest, error) {
+	clientID := params.Get("client_id")
+	if clientID == "" {
+		return nil, fmt.Errorf("%s client_id: %w", core.ErrMsgOAuthMissingParam, core.ErrOAuthInvalidRequest)
+	}
+	client, err := s.clients.Get(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &AuthorizeRequest{
+		Client:           client,
+		RedirectURI:      params.Get("redirect_uri"),
+		State:            params.Get("state"),
+		redirectURIParam: params.Get("redirect_uri"),
+	}
+	switch {
+	case req.RedirectURI == "" && len(client.RedirectURIs) == 1:
+		req.RedirectURI = client.RedirectURIs[0]
+	case req.RedirectURI == "" || !client.hasRedirectURI(req.RedirectURI):
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthRedirectURI, core.ErrOAuthInvalidRequest)
+	}
+
+	if params.Get("response_type") != "code" {
+		return req, core.ErrOAuthUnsupportedResponseType
+	}
+	if !client.AllowsGrant(GrantTypeAuthorizationCode) {
+		return req, core.ErrOAuthUnauthorizedClient
+	}
+
+	req.Scopes, err = resolveScopes(client, strings.Fields(params.Get("scope")))
+	if err != nil {
+		return req, err
+	}
+
+	req.CodeChallenge = params.Get("code_challenge")
+	req.CodeChallengeMethod = params.Get("code_challenge_method")
+	if req.CodeChallenge == "" {
+		if client.Public {
+			return req, fmt.Errorf("%s: %w", core.ErrMsgOAuthPKCERequired, core.ErrOAuthInvalidRequest)
+		}
+		req.CodeChallengeMethod = ""
+		return req, nil
+	}
+	if req.CodeChallengeMethod == "" {
+		req.CodeChallengeMethod = PKCEMethodPlain
+	}
+	if req.CodeChallengeMethod != PKCEMethodS256 && req.CodeChallengeMethod != PKCEMethodPlain {
+		return req, fmt.Errorf("%s: %w", core.ErrMsgOAuthPKCEMethod, core.ErrOAuthInvalidRequest)
+	}
+	// 公开客户端必须使用 S256，plain 方式的 code_challenge 即为 code_verifier 本身
+	if client.Public && req.CodeChallengeMethod != PKCEMethodS256 {
+		return req, fmt.Errorf("%s: %w", core.ErrMsgOAuthPKCEPlain, core.ErrOAuthInvalidRequest)
+	}
+	return req, nil
+}
+
+// Authorize 用户同意后签发授权码，返回携带 code 与 state 的回调地址
+// scopes 为用户同意授予的范围，必须是申请范围的子集，为空时授予全部申请范围
+func (s *Server) Authorize(ctx context.Context, req *AuthorizeRequest, userID string, scopes []string) (string, error) {
+	if userID == "" {
+		return "", errors.New(core.ErrMsgUserIDEmpty)
+	}
+	if scopes == nil {
+		scopes = req.Scopes
+	} else if !subset(scopes, req.Scopes) {
+		return "", core.ErrOAuthInvalidScope
+	}
+
+	code, err := randomString(codeSize, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	record := &grant{
+		ClientID:            req.Client.ID,
+		UserID:              userID,
+		Scopes:              scopes,
+		RedirectURI:         req.redirectURIParam,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(s.config.CodeTTL),
+	}
+	if err := s.storage.Set(ctx, s.keyService.OAuthCodeKey(hashSecret(code)), record, s.config.CodeTTL); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveOAuthGrant, err)
+	}
+
+	return redirectURL(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// Token 处理令牌请求：认证客户端后按授权类型签发Token
+func (s *Server) Token(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	client, err := s.clients.Authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials:
+		if !client.AllowsGrant(req.GrantType) {
+			return nil, core.ErrOAuthUnauthorizedClient
+		}
+	case "":
+		return nil, fmt.Errorf("%s grant_type: %w", core.ErrMsgOAuthMissingParam, core.ErrOAuthInvalidRequest)
+	default:
+		return nil, core.ErrOAuthUnsupportedGrantType
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, client, req)
+	case GrantTypeRefreshToken:
+		return s.refresh(ctx, client, req)
+	default:
+		scopes, err := resolveScopes(client, req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		return s.issue(ctx, client, "", scopes, false)
+	}
+}
+
+// VerifyAccessToken 校验访问Token，返回资源所有者的用户信息
+// 客户端凭证模式签发的Token以客户端自身的身份访问，用户ID为 ClientSubjectPrefix 加客户端ID，不会与用户ID混淆
+func (s *Server) VerifyAccessToken(ctx context.Context, token string) (*core.UserInfo, error) {
+	record, err := s.getGrant(ctx, s.keyService.OAuthAccessTokenKey(s.keyService.TokenRef(token)))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, core.ErrOAuthTokenInvalid
+	}
+
+	userID := record.UserID
+	if userID == "" {
+		userID = ClientSubjectPrefix + record.ClientID
+	}
+	return &core.UserInfo{
+		ID:       userID,
+		Username: userID,
+		Roles:    []string{},
+		ClientID: record.ClientID,
+		Scopes:   record.Scopes,
+	}, nil
+}
+
+// Revoke 吊销访问Token或刷新Token（RFC 7009），需先认证客户端，且只能吊销签发给该客户端的Token
+// Token 无效或已过期时同样返回成功
+func (s *Server) Revoke(ctx context.Context, req *RevokeRequest) error {
+	client, err := s.clients.Authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return err
+	}
+	if req.Token == "" {
+		return fmt.Errorf("%s token: %w", core.ErrMsgOAuthMissingParam, core.ErrOAuthInvalidRequest)
+	}
+
+	ref := s.keyService.TokenRef(req.Token)
+	for _, key := range []string{s.keyService.OAuthAccessTokenKey(ref), s.keyService.OAuthRefreshTokenKey(ref)} {
+		record, err := s.getGrant(ctx, key)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			continue
+		}
+		if record.ClientID != client.ID {
+			return fmt.Errorf("%s: %w", core.ErrMsgOAuthClientMismatch, core.ErrOAuthUnauthorizedClient)
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("%s: %w", core.ErrMsgSaveOAuthGrant, err)
+		}
+	}
+	return nil
+}
+
+// exchangeCode 授权码换取Token，授权码只能使用一次
+func (s *Server) exchangeCode(ctx context.Context, client *Client, req *TokenRequest) (*TokenResponse, error) {
+	if req.Code == "" {
+		return nil, fmt.Errorf("%s code: %w", core.ErrMsgOAuthMissingParam, core.ErrOAuthInvalidRequest)
+	}
+
+	// 原子取出授权码，并发提交时只有一个请求成功
+	data, err := storage.GetDel(ctx, s.storage, s.keyService.OAuthCodeKey(hashSecret(req.Code)))
+	if err != nil || data == nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthCodeInvalid, core.ErrOAuthInvalidGrant)
+	}
+	record, err := decodeGrant(data)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthCodeInvalid, core.ErrOAuthInvalidGrant)
+	}
+	if record.ClientID != client.ID {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthClientMismatch, core.ErrOAuthInvalidGrant)
+	}
+	if record.RedirectURI != "" && record.RedirectURI != req.RedirectURI {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthRedirectChanged, core.ErrOAuthInvalidGrant)
+	}
+	if record.CodeChallenge != "" && !verifyPKCE(record.CodeChallenge, record.CodeChallengeMethod, req.CodeVerifier) {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthPKCEVerifier, core.ErrOAuthInvalidGrant)
+	}
+
+	return s.issue(ctx, client, record.UserID, record.Scopes, client.AllowsGrant(GrantTypeRefreshToken))
+}
+
+// refresh 刷新Token换取新的Token，刷新Token每次使用后轮换
+func (s *Server) refresh(ctx context.Context, client *Client, req *TokenRequest) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, fmt.Errorf("%s refresh_token: %w", core.ErrMsgOAuthMissingParam, core.ErrOAuthInvalidRequest)
+	}
+
+	key := s.keyService.OAuthRefreshTokenKey(s.keyService.TokenRef(req.RefreshToken))
+	record, err := s.getGrant(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthRefreshInvalid, core.ErrOAuthInvalidGrant)
+	}
+	if record.ClientID != client.ID {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthClientMismatch, core.ErrOAuthInvalidGrant)
+	}
+
+	scopes := record.Scopes
+	if len(req.Scopes) > 0 {
+		if !subset(req.Scopes, record.Scopes) {
+			return nil, core.ErrOAuthInvalidScope
+		}
+		scopes = req.Scopes
+	}
+
+	if data, err := storage.GetDel(ctx, s.storage, key); err != nil || data == nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgOAuthRefreshInvalid, core.ErrOAuthInvalidGrant)
+	}
+	return s.issue(ctx, client, record.UserID, scopes, true)
+}
+
+// issue 签发访问Token（及刷新Token）并保存授权记录
+func (s *Server) issue(ctx context.Context, client *Client, userID string, scopes []string, withRefresh bool) (*TokenResponse, error) {
+	accessToken, err := s.saveToken(ctx, client.ID, userID, scopes, s.config.AccessTokenTTL, s.keyService.OAuthAccessTokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(s.config.AccessTokenTTL / time.Second),
+		Scope:       strings.Join(scopes, " "),
+	}
+	if withRefresh {
+		resp.RefreshToken, err = s.saveToken(ctx, client.ID, userID, scopes, s.config.RefreshTokenTTL, s.keyService.OAuthRefreshTokenKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// saveToken 生成Token并按引用保存授权记录
+func (s *Server) saveToken(ctx context.Context, clientID, userID string, scopes []string, ttl time.Duration, keyFunc func(string) string) (string, error) {
+	extra := map[string]interface{}{
+		core.TokenExtraKeyType:     core.TokenTypeOAuth,
+		core.TokenExtraKeyClientID: clientID,
+	}
+	if userID != "" {
+		extra[core.TokenExtraKeyUserID] = userID
+	}
+	token, err := s.generator.Generate(extra)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateOAuthToken, err)
+	}
+
+	record := &grant{
+		ClientID:  clientID,
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.storage.Set(ctx, keyFunc(s.keyService.TokenRef(token)), record, ttl); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveOAuthGrant, err)
+	}
+	return token, nil
+}
+
+// getGrant 读取授权记录，不存在或已过期时返回 nil
+func (s *Server) getGrant(ctx context.Context, key string) (*grant, error) {
+	// 存储对不存在的键返回错误，先判断是否存在以区分存储故障
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetOAuthGrant, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetOAuthGrant, err)
+	}
+	record, err := decodeGrant(data)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// decodeGrant 解析存储中的授权记录
+func decodeGrant(data interface{}) (*grant, error) {
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+
+	var record grant
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseOAuthGrant, err)
+	}
+	return &record, nil
+}
+
+// resolveScopes 校验申请的 scope，为空时使用客户端允许的全部 scope
+func resolveScopes(client *Client, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !client.allowsScope(scope) {
+			return nil, core.ErrOAuthInvalidScope
+		}
+	}
+	return requested, nil
+}
+
+// subset scopes 是否都在 allowed 范围内
+func subset(scopes, allowed []string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, a := range allowed {
+			if scope == a || a == core.PermissionWildcard {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyPKCE 校验 code_verifier 与授权请求中的 code_challenge 是否匹配
+func verifyPKCE(challenge, method, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	expected := verifier
+	if method == PKCEMethodS256 {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(expected)) == 1
+}
+
+// redirectURL 在回调地址上追加查询参数，保留原有参数，值为空的参数不追加
+func redirectURL(base string, params url.Values) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := u.Query()
+	for k, values := range params {
+		for _, v := range values {
+			if v != "" {
+				query.Add(k, v)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
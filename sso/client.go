@@ -0,0 +1,218 @@
+package sso
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// ExtraKeySessionID 本地Token的 Extra 中记录中心会话ID的键
+const ExtraKeySessionID = "sso_session_id"
+
+// ClientConfig SSO 应用端配置
+type ClientConfig struct {
+	AppID  string
+	Secret string
+
+	// ServerURL SSO 服务端端点前缀，即服务端 Register 挂载的地址，如 https://sso.example.com/sso
+	ServerURL string
+
+	// Device 本地登录使用的设备类型，为空时使用默认设备
+	Device string
+
+	// HTTPClient 请求服务端使用的客户端，默认超时 5 秒
+	HTTPClient *http.Client
+}
+
+// refLogouter 支持按Token存储引用登出的认证引擎，映射中只保存Token引用，不保存原始Token
+type refLogouter interface {
+	LogoutByRef(ctx context.Context, ref string) error
+}
+
+// Client SSO 应用端
+// 用票据向服务端换取用户身份后在本地登录，并记录中心会话与本地Token的映射，收到登出通知时注销对应的本地Token
+// 认证引擎需支持按Token引用登出（auth.Engine 已实现）
+type Client struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	engine     core.AuthEngine
+	config     ClientConfig
+}
+
+// NewClient 创建 SSO 应用端
+func NewClient(storage core.Storage, keyService *core.KeyService, engine core.AuthEngine, config *ClientConfig) *Client {
+	var cfg ClientConfig
+	if config != nil {
+		cfg = *config
+	}
+	cfg.ServerURL = strings.TrimSuffix(cfg.ServerURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &Client{
+		storage:    storage,
+		keyService: keyService,
+		engine:     engine,
+		config:     cfg,
+	}
+}
+
+// AuthURL 服务端认证地址，redirect 为接收票据的回调地址
+func (c *Client) AuthURL(redirect string) string {
+	return appendQuery(c.config.ServerURL+"/auth", url.Values{"app_id": {c.config.AppID}, "redirect": {redirect}})
+}
+
+// LogoutURL 服务端单点登出地址，redirect 为登出后返回的地址
+func (c *Client) LogoutURL(redirect string) string {
+	return appendQuery(c.config.ServerURL+"/logout", url.Values{"redirect": {redirect}})
+}
+
+// CheckTicket 向服务端校验票据
+func (c *Client) CheckTicket(ctx context.Context, ticket string) (*TicketInfo, error) {
+	form := url.Values{"ticket": {ticket}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.ServerURL+"/check-ticket", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgSSOServerRequest, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.config.AppID, c.config.Secret)
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgSSOServerRequest, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgSSOServerRequest, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, core.ErrSSOAppInvalid
+	case http.StatusBadRequest:
+		return nil, core.ErrSSOTicketInvalid
+	default:
+		return nil, fmt.Errorf("%s: status %d", core.ErrMsgSSOServerRequest, resp.StatusCode)
+	}
+
+	var info TicketInfo
+	if err := json.Unmarshal(body, &info); err != nil || info.UserID == "" {
+		return nil, fmt.Errorf("%s: %s", core.ErrMsgSSOServerRequest, body)
+	}
+	return &info, nil
+}
+
+// Redeem 用票据换取本地登录Token
+func (c *Client) Redeem(ctx context.Context, ticket string) (*core.LoginResponse, error) {
+	info, err := c.CheckTicket(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.engine.Login(ctx, &core.LoginRequest{
+		UserID: info.UserID,
+		Device: c.config.Device,
+		Extra:  map[string]interface{}{ExtraKeySessionID: info.SessionID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 启用多因素认证的用户 Token 为空，由应用完成验证后再调用 Bind
+	if resp.Token != "" {
+		if err := c.Bind(ctx, info.SessionID, resp.Token, resp.ExpireTime); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Bind 记录中心会话对应的本地Token，收到该会话的登出通知时一并注销
+// 每个Token单独保存，并发绑定时不会互相覆盖；映射中只保存Token引用，存储泄露时不会暴露可用的Token
+func (c *Client) Bind(ctx context.Context, sessionID, token string, expire time.Time) error {
+	// 映射随绑定的Token过期
+	ttl := time.Until(expire)
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	ref := c.keyService.TokenRef(token)
+	key := c.keyService.SSOClientTokenKey(c.config.AppID, sessionID, ref)
+	if err := c.storage.Set(ctx, key, ref, ttl); err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgSaveSSOSession, err)
+	}
+	return nil
+}
+
+// LogoutSession 注销中心会话对应的所有本地Token，读取映射失败时返回错误
+func (c *Client) LogoutSession(ctx context.Context, sessionID string) error {
+	logouter, ok := c.engine.(refLogouter)
+	if !ok {
+		return errors.New(core.ErrMsgLogoutByRefUnavailable)
+	}
+
+	keys, err := c.storage.Keys(ctx, c.keyService.SSOClientTokenPattern(c.config.AppID, sessionID))
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGetSSOSession, err)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		ref, ok, err := takeValue(ctx, c.storage, key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			errs = append(errs, logouter.LogoutByRef(ctx, ref))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CallbackHandler 票据回调端点，换取本地Token后调用 onLogin（如写入 Cookie 并跳转）
+// onLogin 为空时以 JSON 返回登录结果
+func (c *Client) CallbackHandler(onLogin func(w http.ResponseWriter, r *http.Request, resp *core.LoginResponse)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := c.Redeem(r.Context(), r.URL.Query().Get("ticket"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if onLogin == nil {
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+		onLogin(w, r, resp)
+	}
+}
+
+// LogoutCallbackHandler 单点登出通知端点，对应服务端 App.LogoutURL，仅接受服务端以应用ID与密钥认证的 POST 请求
+func (c *Client) LogoutCallbackHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		appID, secret, ok := r.BasicAuth()
+		if !ok || appID != c.config.AppID || subtle.ConstantTimeCompare([]byte(secret), []byte(c.config.Secret)) != 1 {
+			writeError(w, core.ErrSSOAppInvalid)
+			return
+		}
+		if err := c.LogoutSession(r.Context(), r.PostFormValue("session_id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,150 @@
+package sso
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// 错误码
+const (
+	ErrorInvalidApp         = "invalid_app"
+	ErrorRedirectNotAllowed = "redirect_not_allowed"
+	ErrorInvalidTicket      = "invalid_ticket"
+	ErrorLoginRequired      = "login_required"
+	ErrorUserDisabled       = "user_disabled"
+	ErrorServerError        = "server_error"
+)
+
+// errorCodes 错误与错误码、状态码的对应关系
+var errorCodes = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{core.ErrSSOAppInvalid, ErrorInvalidApp, http.StatusUnauthorized},
+	{core.ErrSSORedirectNotAllowed, ErrorRedirectNotAllowed, http.StatusBadRequest},
+	{core.ErrSSOTicketInvalid, ErrorInvalidTicket, http.StatusBadRequest},
+	{core.ErrUserDisabled, ErrorUserDisabled, http.StatusForbidden},
+}
+
+// AuthHandler 认证端点，参数 app_id 与 redirect
+// 已登录时签发票据并重定向到 redirect；未登录时重定向到 LoginURL，登录完成后应返回本端点
+func (s *Server) AuthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redirect := r.URL.Query().Get("redirect")
+		app, err := s.checkRedirect(r.URL.Query().Get("app_id"), redirect)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		token := s.token(r)
+		userInfo, err := s.verify(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, core.ErrUserDisabled) {
+				writeError(w, err)
+				return
+			}
+			if s.config.LoginURL != "" {
+				http.Redirect(w, r, appendQuery(s.config.LoginURL, url.Values{"return_to": {r.URL.RequestURI()}}), http.StatusFound)
+				return
+			}
+			writeJSON(w, http.StatusUnauthorized, map[string]string{
+				"error":   ErrorLoginRequired,
+				"message": core.ErrMsgSSOLoginRequired,
+			})
+			return
+		}
+
+		location, err := s.issueTicket(r.Context(), app, redirect, token, userInfo.ID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		http.Redirect(w, r, location, http.StatusFound)
+	}
+}
+
+// CheckTicketHandler 票据校验端点，仅接受 POST 表单 ticket，应用以 HTTP Basic（应用ID与密钥）认证
+func (s *Server) CheckTicketHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		appID, secret, _ := r.BasicAuth()
+		info, err := s.CheckTicket(r.Context(), appID, secret, r.PostFormValue("ticket"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
+// LogoutHandler 单点登出端点，注销 Cookie 或 Authorization 头中的中心Token并清除 Cookie
+// redirect 在任一应用的允许范围内时重定向，否则返回 204
+func (s *Server) LogoutHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := s.token(r)
+		s.ClearSessionCookie(w)
+		if token != "" {
+			// 中心Token已失效或部分应用通知失败时仍视为登出成功
+			_ = s.Logout(r.Context(), token)
+		}
+
+		redirect := r.URL.Query().Get("redirect")
+		for _, app := range s.apps {
+			if redirect != "" && app.allowsRedirect(redirect) {
+				http.Redirect(w, r, redirect, http.StatusFound)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Register 在 ServeMux 上挂载 <prefix>/auth、<prefix>/check-ticket 与 <prefix>/logout
+// Gin 可通过 gin.WrapF 挂载各端点
+func (s *Server) Register(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.Handle(prefix+"/auth", s.AuthHandler())
+	mux.Handle(prefix+"/check-ticket", s.CheckTicketHandler())
+	mux.Handle(prefix+"/logout", s.LogoutHandler())
+}
+
+// token 从 Cookie 或 Authorization 头读取中心Token
+func (s *Server) token(r *http.Request) string {
+	if cookie, err := r.Cookie(s.config.CookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// writeError 返回 JSON 错误响应，内部错误不向应用暴露细节
+func writeError(w http.ResponseWriter, err error) {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			writeJSON(w, e.status, map[string]string{"error": e.code, "message": err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{
+		"error":   ErrorServerError,
+		"message": http.StatusText(http.StatusInternalServerError),
+	})
+}
+
+// writeJSON 返回 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
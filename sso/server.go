@@ -0,0 +1,427 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+const (
+	defaultTicketTTL   = time.Minute
+	defaultSessionTTL  = 24 * time.Hour
+	defaultHTTPTimeout = 5 * time.Second
+
+	// ticketSize 票据随机字节数
+	ticketSize = 32
+
+	// DefaultCookieName 服务端保存中心登录Token的 Cookie 名
+	DefaultCookieName = "gstoken_sso"
+)
+
+// App 接入 SSO 的应用
+type App struct {
+	ID        string
+	Secret    string   // 应用密钥，用于校验票据与认证登出通知
+	AllowURLs []string // 允许的回调地址，协议与主机必须一致，路径按目录前缀匹配
+	LogoutURL string   // 单点登出通知地址，为空时不通知（同域共享 Cookie 的应用直接校验中心Token，无需通知）
+}
+
+// allowsRedirect 回调地址是否在允许范围内
+func (a *App) allowsRedirect(redirect string) bool {
+	target, err := url.Parse(redirect)
+	if err != nil || target.Scheme == "" || target.Host == "" || target.User != nil {
+		return false
+	}
+	for _, allow := range a.AllowURLs {
+		allowed, err := url.Parse(allow)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(allowed.Scheme, target.Scheme) || !strings.EqualFold(allowed.Host, target.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if target.Path == allowed.Path || prefix == "" || strings.HasPrefix(target.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerConfig SSO 服务端配置，零值字段使用默认值
+type ServerConfig struct {
+	Apps []App
+
+	TicketTTL  time.Duration // 票据有效期，默认 1 分钟
+	SessionTTL time.Duration // 中心会话记录有效期，应不短于登录Token有效期，默认 24 小时
+
+	// LoginURL 认证端点遇到未登录用户时重定向的登录页，原地址通过 return_to 参数传递；为空时返回 401
+	// 登录页完成登录后应调用 SetSessionCookie 写入中心登录态
+	LoginURL string
+
+	CookieName   string // 中心登录Token的 Cookie 名，默认 DefaultCookieName
+	CookieDomain string // Cookie 域，同域模式下设置为父域（如 example.com）使各子域应用共享登录态
+	CookieSecure bool   // 仅通过 HTTPS 发送 Cookie
+
+	// HTTPClient 发送单点登出通知使用的客户端，默认超时 5 秒
+	HTTPClient *http.Client
+}
+
+// TicketInfo 票据校验结果
+type TicketInfo struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"` // 中心会话ID，应用据此接收单点登出通知
+}
+
+// ticket 票据记录
+type ticket struct {
+	UserID    string    `json:"user_id"`
+	AppID     string    `json:"app_id"`
+	SessionID string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// session 中心会话记录，已签发过票据的应用按 KeyService.SSOSessionAppKey 单独保存，登出时逐一通知
+type session struct {
+	UserID string `json:"user_id"`
+}
+
+// disableChecker 认证引擎的封禁检查能力
+type disableChecker interface {
+	CheckDisabled(ctx context.Context, userID, service string) error
+}
+
+// sessionEndNotifier 认证引擎的会话结束回调能力，通过引擎登出、踢出或封禁时同样通知应用
+type sessionEndNotifier interface {
+	OnSessionEnd(fn core.SessionEndFunc)
+}
+
+// Server SSO 服务端（认证中心）
+// 用户在中心登录后，认证端点签发一次性票据并重定向回应用，应用通过后端接口用票据换取用户身份；
+// 票据与会话记录中只保存中心Token的摘要，中心Token不会下发给应用
+type Server struct {
+	storage    core.Storage
+	keyService *core.KeyService
+	engine     core.AuthEngine
+	config     ServerConfig
+	apps       map[string]*App
+}
+
+// NewServer 创建 SSO 服务端
+func NewServer(storage core.Storage, keyService *core.KeyService, engine core.AuthEngine, config *ServerConfig) *Server {
+	var cfg ServerConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.TicketTTL <= 0 {
+		cfg.TicketTTL = defaultTicketTTL
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = defaultSessionTTL
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = DefaultCookieName
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	apps := make(map[string]*App, len(cfg.Apps))
+	for i := range cfg.Apps {
+		apps[cfg.Apps[i].ID] = &cfg.Apps[i]
+	}
+	server := &Server{
+		storage:    storage,
+		keyService: keyService,
+		engine:     engine,
+		config:     cfg,
+		apps:       apps,
+	}
+	if notifier, ok := engine.(sessionEndNotifier); ok {
+		notifier.OnSessionEnd(server.onSessionEnd)
+	}
+	return server
+}
+
+// SessionID 由中心Token计算会话ID，引擎只持有Token引用时可由引用得到同一会话ID
+func (s *Server) SessionID(token string) string {
+	return hashValue(s.keyService.TokenRef(token))
+}
+
+// SetSessionCookie 写入中心登录态 Cookie，登录页完成登录后调用
+func (s *Server) SetSessionCookie(w http.ResponseWriter, token string, expire time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.config.CookieName,
+		Value:    token,
+		Path:     "/",
+		Domain:   s.config.CookieDomain,
+		Expires:  expire,
+		Secure:   s.config.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie 清除中心登录态 Cookie
+func (s *Server) ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.config.CookieName,
+		Value:    "",
+		Path:     "/",
+		Domain:   s.config.CookieDomain,
+		MaxAge:   -1,
+		Secure:   s.config.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Authorize 为已登录用户签发票据，返回携带 ticket 参数的应用回调地址
+func (s *Server) Authorize(ctx context.Context, appID, redirect, token string) (string, error) {
+	app, err := s.checkRedirect(appID, redirect)
+	if err != nil {
+		return "", err
+	}
+	userInfo, err := s.verify(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return s.issueTicket(ctx, app, redirect, token, userInfo.ID)
+}
+
+// CheckTicket 应用用票据换取用户身份，票据只能使用一次且只能由签发时指定的应用使用
+func (s *Server) CheckTicket(ctx context.Context, appID, secret, ticketValue string) (*TicketInfo, error) {
+	app, ok := s.apps[appID]
+	if !ok || secret == "" || subtle.ConstantTimeCompare([]byte(app.Secret), []byte(secret)) != 1 {
+		return nil, core.ErrSSOAppInvalid
+	}
+	if ticketValue == "" {
+		return nil, core.ErrSSOTicketInvalid
+	}
+
+	// 存储对不存在的键返回错误，视为票据无效
+	data, err := storage.GetDel(ctx, s.storage, s.keyService.SSOTicketKey(hashValue(ticketValue)))
+	if err != nil || data == nil {
+		return nil, core.ErrSSOTicketInvalid
+	}
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+	var record ticket
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseSSOTicket, err)
+	}
+	if record.AppID != appID || time.Now().After(record.ExpiresAt) {
+		return nil, core.ErrSSOTicketInvalid
+	}
+
+	return &TicketInfo{UserID: record.UserID, SessionID: record.SessionID}, nil
+}
+
+// Logout 单点登出：通知该会话中签发过票据的所有应用，并注销中心Token
+// 读取会话或通知失败不影响中心登出，所有失败合并后返回
+func (s *Server) Logout(ctx context.Context, token string) error {
+	err := s.endSession(ctx, s.SessionID(token))
+	return errors.Join(err, s.engine.Logout(ctx, token))
+}
+
+// checkRedirect 校验应用与回调地址
+func (s *Server) checkRedirect(appID, redirect string) (*App, error) {
+	app, ok := s.apps[appID]
+	if !ok {
+		return nil, core.ErrSSOAppInvalid
+	}
+	if !app.allowsRedirect(redirect) {
+		return nil, core.ErrSSORedirectNotAllowed
+	}
+	return app, nil
+}
+
+// verify 校验中心Token，用户被封禁时同样拒绝
+func (s *Server) verify(ctx context.Context, token string) (*core.UserInfo, error) {
+	if token == "" {
+		return nil, errors.New(core.ErrMsgSSOLoginRequired)
+	}
+	userInfo, err := s.engine.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if checker, ok := s.engine.(disableChecker); ok {
+		if err := checker.CheckDisabled(ctx, userInfo.ID, core.DisableServiceAll); err != nil {
+			return nil, err
+		}
+	}
+	return userInfo, nil
+}
+
+// issueTicket 保存票据并把应用加入中心会话
+func (s *Server) issueTicket(ctx context.Context, app *App, redirect, token, userID string) (string, error) {
+	buf := make([]byte, ticketSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgGenerateSSOTicket, err)
+	}
+	ticketValue := base64.RawURLEncoding.EncodeToString(buf)
+	sessionID := s.SessionID(token)
+
+	record := &ticket{
+		UserID:    userID,
+		AppID:     app.ID,
+		SessionID: sessionID,
+		ExpiresAt: time.Now().Add(s.config.TicketTTL),
+	}
+	if err := s.storage.Set(ctx, s.keyService.SSOTicketKey(hashValue(ticketValue)), record, s.config.TicketTTL); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveSSOTicket, err)
+	}
+
+	// 先保存应用再保存会话记录，会话记录不会早于应用记录过期
+	if err := s.storage.Set(ctx, s.keyService.SSOSessionAppKey(sessionID, app.ID), app.ID, s.config.SessionTTL); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveSSOSession, err)
+	}
+	if err := s.storage.Set(ctx, s.keyService.SSOSessionKey(sessionID), &session{UserID: userID}, s.config.SessionTTL); err != nil {
+		return "", fmt.Errorf("%s: %w", core.ErrMsgSaveSSOSession, err)
+	}
+
+	return appendQuery(redirect, url.Values{"ticket": {ticketValue}}), nil
+}
+
+// endSession 删除中心会话记录并通知其中签发过票据的应用，应用记录逐个原子取出，每个应用只通知一次
+func (s *Server) endSession(ctx context.Context, sessionID string) error {
+	record, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil
+	}
+	keys, err := s.storage.Keys(ctx, s.keyService.SSOSessionAppPattern(sessionID))
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgGetSSOSession, err)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		appID, ok, err := takeValue(ctx, s.storage, key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if app, found := s.apps[appID]; ok && found && app.LogoutURL != "" {
+			errs = append(errs, s.notifyLogout(ctx, app, sessionID, record.UserID))
+		}
+	}
+	if err := s.storage.Delete(ctx, s.keyService.SSOSessionKey(sessionID)); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", core.ErrMsgDeleteSSOSession, err))
+	}
+	return errors.Join(errs...)
+}
+
+// onSessionEnd 中心会话通过引擎登出、踢出或用户被封禁时通知应用，中心会话已结束，通知失败时忽略
+func (s *Server) onSessionEnd(ctx context.Context, userID, tokenRef string) {
+	_ = s.endSession(ctx, hashValue(tokenRef))
+}
+
+// getSession 读取中心会话记录，不存在时返回 nil
+func (s *Server) getSession(ctx context.Context, sessionID string) (*session, error) {
+	// 存储对不存在的键返回错误，先判断是否存在以区分存储故障
+	key := s.keyService.SSOSessionKey(sessionID)
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetSSOSession, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgGetSSOSession, err)
+	}
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return nil, errors.New(core.ErrMsgStorageDataFormat)
+	}
+	var record session
+	if err := json.Unmarshal(dataBytes, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgParseSSOSession, err)
+	}
+	return &record, nil
+}
+
+// notifyLogout 向应用发送登出通知，应用以 HTTP Basic（应用ID与密钥）认证请求
+func (s *Server) notifyLogout(ctx context.Context, app *App, sessionID, userID string) error {
+	form := url.Values{"session_id": {sessionID}, "user_id": {userID}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.LogoutURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", core.ErrMsgSSOLogoutNotify, app.ID, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(app.ID, app.Secret)
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", core.ErrMsgSSOLogoutNotify, app.ID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s %s: status %d", core.ErrMsgSSOLogoutNotify, app.ID, resp.StatusCode)
+	}
+	return nil
+}
+
+// hashValue 票据与Token的 SHA-256 摘要，存储中不保存原文
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// takeValue 原子取出键中保存的字符串，键不存在或已被并发取出时返回 false
+func takeValue(ctx context.Context, store core.Storage, key string) (string, bool, error) {
+	data, err := storage.GetDel(ctx, store, key)
+	if err != nil {
+		// 存储对不存在的键返回错误，键已不存在时视为已被取出
+		if exists, existsErr := store.Exists(ctx, key); existsErr == nil && !exists {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("%s: %w", core.ErrMsgGetSSOSession, err)
+	}
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return "", false, errors.New(core.ErrMsgStorageDataFormat)
+	}
+	var value string
+	if err := json.Unmarshal(dataBytes, &value); err != nil {
+		return "", false, fmt.Errorf("%s: %w", core.ErrMsgParseSSOSession, err)
+	}
+	return value, true, nil
+}
+
+// appendQuery 向地址追加查询参数
+func appendQuery(base string, params url.Values) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, v := range values {
+			if v != "" {
+				query.Add(key, v)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
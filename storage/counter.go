@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// Incr 原子增加计数器并返回增加后的值；存储未实现 core.CounterStorage 时退化为先读后写，并发请求可能少计
+// 供失败次数、验证码尝试次数等限制使用
+func Incr(ctx context.Context, storage core.Storage, key string, delta int64, expire time.Duration) (int64, error) {
+	if counterStorage, ok := storage.(core.CounterStorage); ok {
+		n, err := counterStorage.Incr(ctx, key, delta, expire)
+		if !errors.Is(err, core.ErrIncrNotSupported) {
+			return n, err
+		}
+	}
+
+	// 存储对不存在的键返回错误，先以 Exists 区分不存在与存储故障
+	var current int64
+	exists, err := storage.Exists(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		data, err := storage.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		dataBytes, ok := data.([]byte)
+		if !ok {
+			return 0, errors.New(core.ErrMsgStorageDataFormat)
+		}
+		if err := json.Unmarshal(dataBytes, &current); err != nil {
+			return 0, fmt.Errorf("value is not an integer: %s", key)
+		}
+	}
+	if delta == 0 && exists {
+		return current, nil
+	}
+	if err := storage.Set(ctx, key, current+delta, expire); err != nil {
+		return 0, err
+	}
+	return current + delta, nil
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// encryptedPrefix 密文信封前缀，格式为 gsenc:v1:{kid}:{base64(nonce||ciphertext)}
+const encryptedPrefix = "gsenc:v1:"
+
+// EncryptedStorage 加密存储包装
+//
+// 写入时将记录序列化为 JSON 后使用 AES-GCM 加密，存储键作为附加数据，
+// 防止密文被挪用到其他键下；读取时按信封中的 kid 选择密钥解密，
+// 返回与未加密存储一致的 JSON 字节数组。未加密的数据默认视为解密失败，
+// 防止能写入底层存储的一方注入明文记录；迁移期间可通过 SetAllowPlaintext 临时放行历史数据。
+type EncryptedStorage struct {
+	inner core.Storage
+
+	allowPlaintext bool
+
+	mu       sync.RWMutex
+	keys     map[string]cipher.AEAD
+	activeID string
+	err      error // 密钥配置错误，存在时所有读写均失败，避免明文落盘
+}
+
+// NewEncryptedStorage 创建加密存储，第一个密钥用于加密，其余密钥仅用于解密
+func NewEncryptedStorage(inner core.Storage, keys ...core.EncryptionKey) *EncryptedStorage {
+	s := &EncryptedStorage{
+		inner: inner,
+		keys:  make(map[string]cipher.AEAD, len(keys)),
+	}
+	for _, key := range keys {
+		if err := s.AddKey(key); err != nil {
+			s.err = err
+			return s
+		}
+		if s.activeID == "" {
+			s.activeID = key.ID
+		}
+	}
+	if s.activeID == "" {
+		s.err = core.ErrEncryptionKeyNotConfigured
+	}
+	return s
+}
+
+// Err 返回密钥配置错误
+func (s *EncryptedStorage) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
+
+// AddKey 添加仅用于解密的密钥
+func (s *EncryptedStorage) AddKey(key core.EncryptionKey) error {
+	if key.ID == "" || strings.Contains(key.ID, ":") {
+		return fmt.Errorf("%w: 加密密钥标识不能为空且不能包含冒号", core.ErrConfigInvalid)
+	}
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return fmt.Errorf("%w: 加密密钥 %s: %v", core.ErrConfigInvalid, key.ID, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("%w: 加密密钥 %s: %v", core.ErrConfigInvalid, key.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.keys[key.ID]; exists {
+		return fmt.Errorf("%w: 重复的加密密钥 %s", core.ErrConfigInvalid, key.ID)
+	}
+	s.keys[key.ID] = aead
+	return nil
+}
+
+// Rotate 添加新密钥并切换为加密密钥，原密钥保留用于解密已有记录
+func (s *EncryptedStorage) Rotate(key core.EncryptionKey) error {
+	if err := s.AddKey(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeID = key.ID
+	s.err = nil
+	return nil
+}
+
+// RemoveKey 移除解密密钥（当前加密密钥不能移除）
+func (s *EncryptedStorage) RemoveKey(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kid == s.activeID {
+		return fmt.Errorf("%w: 不能移除当前加密密钥 %s", core.ErrConfigInvalid, kid)
+	}
+	delete(s.keys, kid)
+	return nil
+}
+
+// SetAllowPlaintext 设置是否原样返回未加密的历史数据，仅用于迁移期间，迁移完成后应关闭
+func (s *EncryptedStorage) SetAllowPlaintext(allow bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowPlaintext = allow
+}
+
+// Unwrap 获取被包装的存储
+func (s *EncryptedStorage) Unwrap() core.Storage {
+	return s.inner
+}
+
+// Set 加密后写入
+func (s *EncryptedStorage) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := s.encrypt(key, plaintext)
+	if err != nil {
+		return fmt.Errorf("%s: %w", core.ErrMsgEncryptRecord, err)
+	}
+	return s.inner.Set(ctx, key, envelope, expire)
+}
+
+// Get 读取并解密，返回 JSON 字节数组
+func (s *EncryptedStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.inner.Get(ctx, key)
+	if err != nil || data == nil {
+		return data, err
+	}
+	return s.open(key, data)
+}
+
+// GetDel 原子读取并删除后解密，由被包装的存储执行
+func (s *EncryptedStorage) GetDel(ctx context.Context, key string) (interface{}, error) {
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	inner, ok := s.inner.(core.GetDelStorage)
+	if !ok {
+		return nil, core.ErrGetDelNotSupported
+	}
+
+	data, err := inner.GetDel(ctx, key)
+	if err != nil || data == nil {
+		return data, err
+	}
+	return s.open(key, data)
+}
+
+// open 解密从被包装存储读取的数据
+func (s *EncryptedStorage) open(key string, data interface{}) (interface{}, error) {
+	dataBytes, ok := data.([]byte)
+	if !ok {
+		return data, nil
+	}
+
+	var envelope string
+	if err := json.Unmarshal(dataBytes, &envelope); err != nil || !strings.HasPrefix(envelope, encryptedPrefix) {
+		s.mu.RLock()
+		allow := s.allowPlaintext
+		s.mu.RUnlock()
+		if allow {
+			// 迁移期间未加密的历史数据原样返回
+			return dataBytes, nil
+		}
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgDecryptRecord, core.ErrDecryptFailed)
+	}
+
+	plaintext, err := s.decrypt(key, envelope)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", core.ErrMsgDecryptRecord, err)
+	}
+	return plaintext, nil
+}
+
+// Delete 删除键
+func (s *EncryptedStorage) Delete(ctx context.Context, key string) error {
+	return s.inner.Delete(ctx, key)
+}
+
+// Exists 检查键是否存在
+func (s *EncryptedStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return s.inner.Exists(ctx, key)
+}
+
+// Touch 重置键的过期时间，由被包装的存储执行
+func (s *EncryptedStorage) Touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	inner, ok := s.inner.(core.TTLStorage)
+	if !ok {
+		return false, core.ErrTouchNotSupported
+	}
+	return inner.Touch(ctx, key, expire)
+}
+
+// TTL 获取键的剩余有效期，由被包装的存储执行
+func (s *EncryptedStorage) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	inner, ok := s.inner.(core.TTLStorage)
+	if !ok {
+		return 0, false, core.ErrTouchNotSupported
+	}
+	return inner.TTL(ctx, key)
+}
+
+// Incr 原子计数，由被包装的存储执行（计数器的值不加密）
+func (s *EncryptedStorage) Incr(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	inner, ok := s.inner.(core.CounterStorage)
+	if !ok {
+		return 0, core.ErrIncrNotSupported
+	}
+	return inner.Incr(ctx, key, delta, expire)
+}
+
+// Keys 获取匹配的键列表（键本身不加密）
+func (s *EncryptedStorage) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return s.inner.Keys(ctx, pattern)
+}
+
+// encrypt 使用当前加密密钥生成密文信封
+func (s *EncryptedStorage) encrypt(key string, plaintext []byte) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.err != nil {
+		return "", s.err
+	}
+	aead, ok := s.keys[s.activeID]
+	if !ok {
+		return "", core.ErrEncryptionKeyNotConfigured
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(key))
+	return encryptedPrefix + s.activeID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 解析密文信封并按 kid 解密
+func (s *EncryptedStorage) decrypt(key, envelope string) ([]byte, error) {
+	kid, payload, ok := strings.Cut(strings.TrimPrefix(envelope, encryptedPrefix), ":")
+	if !ok {
+		return nil, core.ErrDecryptFailed
+	}
+
+	s.mu.RLock()
+	aead, found := s.keys[kid]
+	s.mu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, core.ErrDecryptFailed
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return nil, core.ErrDecryptFailed
+	}
+	return plaintext, nil
+}
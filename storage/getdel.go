@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// GetDel 原子读取并删除键；存储未实现 core.GetDelStorage 时退化为先读后删，并发消费时无法保证只有一个请求成功
+// 供授权码、票据等一次性凭证使用
+func GetDel(ctx context.Context, storage core.Storage, key string) (interface{}, error) {
+	if getDelStorage, ok := storage.(core.GetDelStorage); ok {
+		data, err := getDelStorage.GetDel(ctx, key)
+		if !errors.Is(err, core.ErrGetDelNotSupported) {
+			return data, err
+		}
+	}
+
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -82,6 +83,103 @@ func (m *MemoryStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return err == nil, nil
 }
 
+// Touch 重置键的过期时间
+func (m *MemoryStorage) Touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	for {
+		value, ok := m.data.Load(key)
+		if !ok {
+			return false, nil
+		}
+
+		item := value.(*MemoryItem)
+		if !item.ExpireTime.IsZero() && time.Now().After(item.ExpireTime) {
+			m.data.CompareAndDelete(key, item)
+			return false, nil
+		}
+
+		var expireTime time.Time
+		if expire > 0 {
+			expireTime = time.Now().Add(expire)
+		}
+
+		// 替换为新的存储项，避免与并发读取产生数据竞争；被并发写入替换时基于最新的存储项重试
+		if m.data.CompareAndSwap(key, item, &MemoryItem{Value: item.Value, ExpireTime: expireTime}) {
+			return true, nil
+		}
+	}
+}
+
+// TTL 获取键的剩余有效期
+func (m *MemoryStorage) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	value, ok := m.data.Load(key)
+	if !ok {
+		return 0, false, nil
+	}
+
+	item := value.(*MemoryItem)
+	if item.ExpireTime.IsZero() {
+		return 0, true, nil
+	}
+	ttl := time.Until(item.ExpireTime)
+	if ttl <= 0 {
+		return 0, false, nil
+	}
+	return ttl, true, nil
+}
+
+// GetDel 原子读取并删除键
+func (m *MemoryStorage) GetDel(ctx context.Context, key string) (interface{}, error) {
+	value, ok := m.data.LoadAndDelete(key)
+	if !ok {
+		return nil, fmt.Errorf("key not found: %s", key)
+	}
+
+	item := value.(*MemoryItem)
+	if !item.ExpireTime.IsZero() && time.Now().After(item.ExpireTime) {
+		return nil, fmt.Errorf("key expired: %s", key)
+	}
+
+	return item.Value, nil
+}
+
+// Incr 原子增加计数器，键不存在或已过期时从 0 开始，键没有过期时间时设置为 expire
+func (m *MemoryStorage) Incr(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	for {
+		var current int64
+		var expireTime time.Time
+
+		value, loaded := m.data.Load(key)
+		if loaded {
+			item := value.(*MemoryItem)
+			if item.ExpireTime.IsZero() || time.Now().Before(item.ExpireTime) {
+				data, _ := item.Value.([]byte)
+				n, err := strconv.ParseInt(string(data), 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("value is not an integer: %s", key)
+				}
+				current = n
+				expireTime = item.ExpireTime
+			}
+		}
+		if expireTime.IsZero() && expire > 0 {
+			expireTime = time.Now().Add(expire)
+		}
+
+		next := current + delta
+		item := &MemoryItem{Value: []byte(strconv.FormatInt(next, 10)), ExpireTime: expireTime}
+		if !loaded {
+			if _, exists := m.data.LoadOrStore(key, item); !exists {
+				return next, nil
+			}
+			continue
+		}
+		// 被并发写入替换时基于最新的存储项重试
+		if m.data.CompareAndSwap(key, value, item) {
+			return next, nil
+		}
+	}
+}
+
 // Keys 获取匹配的键列表
 func (m *MemoryStorage) Keys(ctx context.Context, pattern string) ([]string, error) {
 	var keys []string
@@ -107,6 +107,52 @@ func (r *RedisStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, err
 }
 
+// GetDel 使用 GETDEL 原子读取并删除键（需要 Redis 6.2 及以上）
+func (r *RedisStorage) GetDel(ctx context.Context, key string) (interface{}, error) {
+	data, err := r.client.GetDel(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+// Touch 使用 EXPIRE 重置键的过期时间
+func (r *RedisStorage) Touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	if expire <= 0 {
+		return r.client.Persist(ctx, key).Result()
+	}
+	return r.client.Expire(ctx, key, expire).Result()
+}
+
+// TTL 使用 PTTL 获取键的剩余有效期
+func (r *RedisStorage) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	ttl, err := r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	switch ttl {
+	case -2:
+		return 0, false, nil
+	case -1:
+		return 0, true, nil
+	}
+	return ttl, true, nil
+}
+
+// incrScript 原子增加计数器，键没有过期时间时设置过期时间
+var incrScript = redis.NewScript(`
+local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v
+`)
+
+// Incr 使用 INCRBY 原子增加计数器，键没有过期时间时以 PEXPIRE 设置过期时间
+func (r *RedisStorage) Incr(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{key}, delta, expire.Milliseconds()).Int64()
+}
+
 // Keys 获取匹配的键列表
 func (r *RedisStorage) Keys(ctx context.Context, pattern string) ([]string, error) {
 	// 使用 SCAN 遍历，避免 KEYS 的阻塞与集群不兼容问题
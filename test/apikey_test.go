@@ -0,0 +1,270 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/apikey"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// TestAPIKeyLifecycle 验证 API Key 的创建、校验、列出与吊销，存储中只保存摘要
+func TestAPIKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	service := apikey.NewService(store, core.NewKeyService("test"), "")
+
+	key, info, err := service.Create(ctx, &apikey.CreateRequest{UserID: "10001", Name: "ci", Scopes: []string{"report:read"}})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !strings.HasPrefix(key, info.Prefix+"_") || !strings.HasPrefix(info.Prefix, apikey.DefaultPrefix+"_") {
+		t.Fatalf("unexpected key format: %s (prefix %s)", key, info.Prefix)
+	}
+	if info.Hash != "" {
+		t.Error("hash should not be exposed")
+	}
+
+	// 存储中不包含完整Key
+	keys, _ := store.Keys(ctx, "*")
+	for _, k := range keys {
+		data, _ := store.Get(ctx, k)
+		if b, ok := data.([]byte); ok && strings.Contains(string(b), key) {
+			t.Fatalf("plain key stored under %s", k)
+		}
+	}
+
+	verified, err := service.Verify(ctx, key)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if verified.UserID != "10001" || verified.LastUsedAt.IsZero() {
+		t.Errorf("unexpected verified key: %+v", verified)
+	}
+	if _, err := service.Verify(ctx, key+"x"); !errors.Is(err, core.ErrAPIKeyInvalid) {
+		t.Errorf("tampered key should be rejected, got %v", err)
+	}
+	if _, err := service.Verify(ctx, "gsk_bad"); !errors.Is(err, core.ErrAPIKeyInvalid) {
+		t.Errorf("malformed key should be rejected, got %v", err)
+	}
+
+	service.Create(ctx, &apikey.CreateRequest{UserID: "10001", Name: "deploy"})
+	service.Create(ctx, &apikey.CreateRequest{UserID: "10002", Name: "other"})
+	list, err := service.List(ctx, "10001")
+	if err != nil || len(list) != 2 || list[0].Name != "ci" || list[1].Name != "deploy" {
+		t.Fatalf("unexpected list: %+v %v", list, err)
+	}
+
+	if err := service.Revoke(ctx, info.ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if _, err := service.Verify(ctx, key); !errors.Is(err, core.ErrAPIKeyInvalid) {
+		t.Errorf("revoked key should be rejected, got %v", err)
+	}
+	if list, _ := service.List(ctx, "10001"); len(list) != 1 {
+		t.Errorf("revoked key should not be listed, got %d", len(list))
+	}
+}
+
+// TestAPIKeyRotateAndExpiry 验证轮换后旧Key立即失效，过期Key被拒绝
+func TestAPIKeyRotateAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	service := apikey.NewService(storage.NewMemoryStorage(), core.NewKeyService("test"), "svc")
+
+	oldKey, info, _ := service.Create(ctx, &apikey.CreateRequest{UserID: "10001", Name: "ci"})
+	newKey, rotated, err := service.Rotate(ctx, info.ID)
+	if err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	if rotated.ID != info.ID || newKey == oldKey || !strings.HasPrefix(newKey, "svc_") {
+		t.Fatalf("unexpected rotation: %s %+v", newKey, rotated)
+	}
+	if _, err := service.Verify(ctx, oldKey); !errors.Is(err, core.ErrAPIKeyInvalid) {
+		t.Errorf("old key should be rejected after rotate, got %v", err)
+	}
+	if _, err := service.Verify(ctx, newKey); err != nil {
+		t.Errorf("new key should work: %v", err)
+	}
+
+	if _, _, err := service.Create(ctx, &apikey.CreateRequest{UserID: "10001", TTL: -time.Second}); err == nil {
+		t.Error("negative ttl should be rejected")
+	}
+	shortKey, _, _ := service.Create(ctx, &apikey.CreateRequest{UserID: "10001", TTL: 50 * time.Millisecond})
+	time.Sleep(80 * time.Millisecond)
+	if _, err := service.Verify(ctx, shortKey); err == nil {
+		t.Error("expired key should be rejected")
+	}
+}
+
+// TestGinAPIKeyMiddleware 验证 X-API-Key 请求以所有者身份通过权限中间件，且受授权范围限制
+func TestGinAPIKeyMiddleware(t *testing.T) {
+	ctx := context.Background()
+	provider := newRP()
+	provider.users["10001"] = []string{"editor"}
+	provider.perms["editor"] = []string{"report:read", "report:write"}
+
+	gs := gstoken.New(config.NewBuilder().WithUserRoleProvider(provider).Build())
+	readKey, _, _ := gs.GetAPIKeyService().Create(ctx, &apikey.CreateRequest{UserID: "10001", Scopes: []string{"report:read"}})
+	fullKey, _, _ := gs.GetAPIKeyService().Create(ctx, &apikey.CreateRequest{UserID: "10001", Scopes: []string{core.PermissionWildcard}})
+	adminKey, _, _ := gs.GetAPIKeyService().Create(ctx, &apikey.CreateRequest{UserID: "10001", Scopes: []string{"admin:all"}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), nil)
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user": c.GetString(web.ContextKeyUserID), "key": c.GetString(web.ContextKeyAPIKeyID)})
+	}
+	r.GET("/reports", auth.RequirePermission("report:read"), handler)
+	r.POST("/reports", auth.RequirePermission("report:write"), handler)
+	r.GET("/admin", auth.RequirePermission("admin:all"), handler)
+	r.GET("/machine", auth.RequireAPIKey(), handler)
+	r.GET("/safe", auth.RequireSafe(""), handler)
+	r.GET("/editor", auth.RequireRole("editor"), handler)
+
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(web.HeaderXAPIKey, key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	cases := []struct {
+		method, path, key string
+		code              int
+	}{
+		{http.MethodGet, "/reports", readKey, http.StatusOK},
+		{http.MethodPost, "/reports", readKey, http.StatusForbidden}, // 超出授权范围
+		{http.MethodPost, "/reports", fullKey, http.StatusOK},
+		{http.MethodGet, "/admin", adminKey, http.StatusForbidden}, // 所有者不具备该权限
+		{http.MethodGet, "/reports", readKey + "x", http.StatusUnauthorized},
+		{http.MethodGet, "/reports", "", http.StatusUnauthorized},
+		{http.MethodGet, "/machine", readKey, http.StatusOK},
+		{http.MethodGet, "/machine", "", http.StatusUnauthorized},
+		{http.MethodGet, "/safe", fullKey, http.StatusUnauthorized}, // 二级认证只接受Token
+		{http.MethodGet, "/editor", readKey, http.StatusForbidden},  // 授权范围受限的Key不使用所有者的角色
+		{http.MethodGet, "/editor", fullKey, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if w := do(tc.method, tc.path, tc.key); w.Code != tc.code {
+			t.Errorf("%s %s: expected %d, got %d (%s)", tc.method, tc.path, tc.code, w.Code, w.Body.String())
+		}
+	}
+
+	if w := do(http.MethodGet, "/reports", readKey); !strings.Contains(w.Body.String(), `"user":"10001"`) {
+		t.Errorf("user id should be set from key owner: %s", w.Body.String())
+	}
+
+	// 仅接受 API Key 的路由不接受Token
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if w := doReqMixed(r, http.MethodGet, "/machine", resp.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("token should not pass RequireAPIKey, got %d", w.Code)
+	}
+	if w := doReqMixed(r, http.MethodPost, "/reports", resp.Token); w.Code != http.StatusOK {
+		t.Errorf("token auth should be unaffected, got %d", w.Code)
+	}
+
+	// 所有者被封禁后 API Key 同样失效
+	gs.Disable(ctx, "10001", "", time.Hour, "leaked key")
+	if w := do(http.MethodGet, "/reports", fullKey); w.Code != http.StatusForbidden {
+		t.Errorf("disabled owner's key should be rejected with 403, got %d", w.Code)
+	}
+}
+
+// getHookStorage 读取指定键后执行一次回调的存储，用于模拟并发操作插入在读取与写入之间
+type getHookStorage struct {
+	*storage.MemoryStorage
+	key  string
+	hook func()
+}
+
+func (s *getHookStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	data, err := s.MemoryStorage.Get(ctx, key)
+	if key == s.key && s.hook != nil {
+		hook := s.hook
+		s.hook = nil
+		hook()
+	}
+	return data, err
+}
+
+// TestAPIKeyVerifyRevokeRace 验证校验与吊销并发时不会写回已吊销的Key
+func TestAPIKeyVerifyRevokeRace(t *testing.T) {
+	ctx := context.Background()
+	keyService := core.NewKeyService("test")
+	store := &getHookStorage{MemoryStorage: storage.NewMemoryStorage()}
+	service := apikey.NewService(store, keyService, "")
+
+	key, info, err := service.Create(ctx, &apikey.CreateRequest{UserID: "10001"})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	// Verify 读取Key记录后、更新最后使用时间前吊销
+	store.key = keyService.APIKeyKey(info.ID)
+	store.hook = func() {
+		if err := service.Revoke(ctx, info.ID); err != nil {
+			t.Errorf("revoke failed: %v", err)
+		}
+	}
+	service.Verify(ctx, key)
+
+	if _, err := service.Verify(ctx, key); !errors.Is(err, core.ErrAPIKeyInvalid) {
+		t.Errorf("revoked key should stay invalid, got %v", err)
+	}
+	if keys, _ := store.Keys(ctx, "test:*"); len(keys) != 0 {
+		t.Errorf("revoked key should leave no records, got %v", keys)
+	}
+}
+
+// collidingStorage 前 collisions 次检查 API Key 记录是否存在时返回已存在，模拟随机ID碰撞
+type collidingStorage struct {
+	*storage.MemoryStorage
+	prefix     string
+	collisions int
+}
+
+func (s *collidingStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if strings.HasPrefix(key, s.prefix) && s.collisions > 0 {
+		s.collisions--
+		return true, nil
+	}
+	return s.MemoryStorage.Exists(ctx, key)
+}
+
+// TestAPIKeyIDCollision 验证随机ID已被占用时重新生成，不覆盖已有的Key
+func TestAPIKeyIDCollision(t *testing.T) {
+	ctx := context.Background()
+	keyService := core.NewKeyService("test")
+	store := &collidingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: keyService.APIKeyKey("")}
+	service := apikey.NewService(store, keyService, "")
+
+	store.collisions = 2
+	key, info, err := service.Create(ctx, &apikey.CreateRequest{UserID: "10001", Name: "retry"})
+	if err != nil {
+		t.Fatalf("create should retry on id collision: %v", err)
+	}
+	if _, err := service.Verify(ctx, key); err != nil {
+		t.Errorf("created key should verify: %v", err)
+	}
+
+	store.collisions = 100
+	if _, _, err := service.Create(ctx, &apikey.CreateRequest{UserID: "10002", Name: "exhausted"}); err == nil {
+		t.Error("create should fail when every generated id is taken")
+	}
+	if got, err := service.Get(ctx, info.ID); err != nil || got.UserID != "10001" {
+		t.Errorf("existing key should be untouched, got %+v (%v)", got, err)
+	}
+}
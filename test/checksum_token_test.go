@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// countingStorage 统计读操作次数的存储包装
+type countingStorage struct {
+	core.Storage
+	gets int64
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	atomic.AddInt64(&s.gets, 1)
+	return s.Storage.Get(ctx, key)
+}
+
+// newChecksumEngine 创建使用校验码Token的认证引擎
+func newChecksumEngine(secret string) (*auth.Engine, *countingStorage) {
+	cfg := config.NewBuilder().WithChecksumToken([]byte(secret)).Build()
+	store := &countingStorage{Storage: storage.NewMemoryStorage()}
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), core.NewKeyService(cfg.KeyPrefix))
+	return engine, store
+}
+
+// TestChecksumTokenRejectsForgery 验证伪造Token在访问存储前即被拒绝
+func TestChecksumTokenRejectsForgery(t *testing.T) {
+	engine, store := newChecksumEngine("checksum-secret")
+	ctx := context.Background()
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "checksum_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if !strings.Contains(resp.Token, ".") {
+		t.Fatalf("unexpected token format: %s", resp.Token)
+	}
+
+	if _, err := engine.Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	atomic.StoreInt64(&store.gets, 0)
+
+	random := resp.Token[:strings.Index(resp.Token, ".")]
+	forged := []string{
+		"550e8400-e29b-41d4-a716-446655440000",
+		"garbage",
+		random + ".000000000000000000000000",
+	}
+	for _, tk := range forged {
+		if _, err := engine.Verify(ctx, tk); err == nil {
+			t.Errorf("forged token %q should be rejected", tk)
+		}
+		if _, err := engine.RefreshToken(ctx, tk); err == nil {
+			t.Errorf("forged refresh token %q should be rejected", tk)
+		}
+	}
+
+	if gets := atomic.LoadInt64(&store.gets); gets != 0 {
+		t.Errorf("forged tokens should not reach storage, got %d gets", gets)
+	}
+
+	// 不同密钥签发的Token同样被拒绝
+	other, _ := newChecksumEngine("another-secret")
+	if _, err := other.Verify(ctx, resp.Token); err == nil {
+		t.Error("token from other secret should be rejected")
+	}
+}
+
+// checksumAdapter 校验码Token的测试适配器，Verify 被调用即视为访问了存储
+type checksumAdapter struct {
+	engine   *auth.Engine
+	verified int64
+}
+
+func (a *checksumAdapter) ValidateTokenFormat(token string) error {
+	return a.engine.ValidateTokenFormat(token)
+}
+
+func (a *checksumAdapter) Verify(ctx context.Context, token string) (*core.UserInfo, error) {
+	atomic.AddInt64(&a.verified, 1)
+	return a.engine.Verify(ctx, token)
+}
+
+func (a *checksumAdapter) CheckPermission(ctx context.Context, userID, permission string) (bool, error) {
+	return false, nil
+}
+
+func (a *checksumAdapter) CheckRole(ctx context.Context, userID, role string) (bool, error) {
+	return false, nil
+}
+
+func (a *checksumAdapter) GetLoginInfo(ctx context.Context, token string) (*core.LoginInfo, error) {
+	return a.engine.GetLoginInfo(ctx, token)
+}
+
+// TestChecksumTokenMiddleware 验证中间件对伪造Token的进程内拒绝
+func TestChecksumTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine, _ := newChecksumEngine("middleware-secret")
+	adapter := &checksumAdapter{engine: engine}
+	mw := web.NewGinAuthMiddleware(adapter, nil)
+
+	r := gin.New()
+	r.GET("/me", mw.RequireAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, web.Helper.MustGetUserID(c))
+	})
+
+	resp, err := engine.Login(context.Background(), &core.LoginRequest{UserID: "mw_user"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(web.HeaderAuthorization, web.BearerPrefix+"forged.token")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("forged token should get 401, got %d", w.Code)
+	}
+	if n := atomic.LoadInt64(&adapter.verified); n != 0 {
+		t.Fatalf("forged token should not reach Verify, got %d calls", n)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(web.HeaderAuthorization, web.BearerPrefix+resp.Token)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "mw_user" {
+		t.Fatalf("valid token should pass, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestChecksumTokenKeyRotation 验证校验码Token使用密钥环签发，轮换密钥后旧Token仍然有效，旧密钥过校验截止时间后失效
+func TestChecksumTokenKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().
+		WithChecksumToken(nil).
+		WithSigningKeys(core.SigningKey{ID: "k1", Algorithm: "HS256", Secret: []byte("checksum-key-one-0123456789abcdef")}).
+		Build()
+	if cfg.TokenStyle != core.StyleChecksum {
+		t.Fatalf("signing keys should keep checksum style, got %v", cfg.TokenStyle)
+	}
+	generator := token.NewGeneratorWithConfig(cfg)
+	engine := auth.NewEngine(cfg, storage.NewMemoryStorage(), generator, core.NewKeyService(cfg.KeyPrefix))
+
+	before, err := engine.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if err := generator.Keyring().Rotate(core.SigningKey{ID: "k2", Algorithm: "HS256", Secret: []byte("checksum-key-two-0123456789abcdef")}); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	after, err := engine.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if err != nil {
+		t.Fatalf("login after rotation failed: %v", err)
+	}
+	for name, tk := range map[string]string{"before": before.Token, "after": after.Token} {
+		if _, err := engine.Verify(ctx, tk); err != nil {
+			t.Errorf("%s rotation token should stay valid: %v", name, err)
+		}
+	}
+
+	if err := generator.Keyring().Retire("k1", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("retire failed: %v", err)
+	}
+	if _, err := engine.Verify(ctx, before.Token); err == nil {
+		t.Error("token signed by retired key should be rejected")
+	}
+	if _, err := engine.Verify(ctx, after.Token); err != nil {
+		t.Errorf("token signed by active key should stay valid: %v", err)
+	}
+}
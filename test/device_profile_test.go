@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// newDeviceProfileGSToken 创建带设备配置的实例：移动端30天、Web端2小时、自助终端不签发刷新Token
+func newDeviceProfileGSToken() *gstoken.GSToken {
+	noRenew := false
+	mutex := core.MutexLogin
+	return gstoken.New(config.NewBuilder().
+		WithTokenExpire(time.Hour).
+		WithRefreshExpire(24*time.Hour).
+		WithLoginMode(core.MultiLogin).
+		WithDeviceProfile("mobile", core.DeviceProfile{TokenExpire: 30 * 24 * time.Hour, RefreshExpire: 90 * 24 * time.Hour}).
+		WithDeviceProfile("web", core.DeviceProfile{TokenExpire: 2 * time.Hour, LoginMode: &mutex}).
+		WithDeviceProfile("kiosk", core.DeviceProfile{DisableRefresh: true, AutoRenew: &noRenew}).
+		Build())
+}
+
+// TestDeviceProfileExpire 验证按设备覆盖Token与刷新Token有效期
+func TestDeviceProfileExpire(t *testing.T) {
+	ctx := context.Background()
+	gs := newDeviceProfileGSToken()
+
+	cases := []struct {
+		device      string
+		expire      time.Duration
+		wantRefresh bool
+	}{
+		{"mobile", 30 * 24 * time.Hour, true},
+		{"web", 2 * time.Hour, true},
+		{"kiosk", time.Hour, false},
+		{"tv", time.Hour, true},
+	}
+	for _, c := range cases {
+		resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "profile_user", Device: c.device})
+		if err != nil {
+			t.Fatalf("%s: login failed: %v", c.device, err)
+		}
+		if d := time.Until(resp.ExpireTime); d < c.expire-time.Minute || d > c.expire {
+			t.Errorf("%s: expected expire %v, got %v", c.device, c.expire, d)
+		}
+		if (resp.RefreshToken != "") != c.wantRefresh {
+			t.Errorf("%s: refresh token issued = %v, want %v", c.device, resp.RefreshToken != "", c.wantRefresh)
+		}
+	}
+
+	cfg := gs.GetConfig()
+	if cfg.RefreshExpireFor("mobile") != 90*24*time.Hour || cfg.RefreshExpireFor("tv") != 24*time.Hour {
+		t.Error("refresh expire should follow device profile")
+	}
+}
+
+// TestDeviceProfileLoginMode 验证按设备覆盖登录模式
+func TestDeviceProfileLoginMode(t *testing.T) {
+	ctx := context.Background()
+	gs := newDeviceProfileGSToken()
+
+	web1, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_mode", Device: "web"})
+	web2, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_mode", Device: "web"})
+	if gs.IsLogin(ctx, web1.Token) || !gs.IsLogin(ctx, web2.Token) {
+		t.Error("web profile should use mutex login")
+	}
+
+	m1, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_mode", Device: "mobile"})
+	m2, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_mode", Device: "mobile"})
+	if !gs.IsLogin(ctx, m1.Token) || !gs.IsLogin(ctx, m2.Token) {
+		t.Error("mobile should fall back to global multi login")
+	}
+}
+
+// TestDeviceProfileAutoRenew 验证按设备关闭自动续期
+func TestDeviceProfileAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	gs := newDeviceProfileGSToken()
+
+	kiosk, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_renew", Device: "kiosk"})
+	before, _ := gs.GetLoginInfo(ctx, kiosk.Token)
+	time.Sleep(10 * time.Millisecond)
+	gs.IsLogin(ctx, kiosk.Token)
+	after, _ := gs.GetLoginInfo(ctx, kiosk.Token)
+	// 最后访问时间由剩余有效期推算，未续期时只有读取间的微小误差
+	if after.LastAccess.Sub(before.LastAccess) > 5*time.Millisecond {
+		t.Error("kiosk profile should not auto renew")
+	}
+
+	mobile, _ := gs.Login(ctx, &core.LoginRequest{UserID: "profile_renew", Device: "mobile"})
+	before, _ = gs.GetLoginInfo(ctx, mobile.Token)
+	time.Sleep(10 * time.Millisecond)
+	gs.IsLogin(ctx, mobile.Token)
+	after, _ = gs.GetLoginInfo(ctx, mobile.Token)
+	if !after.LastAccess.After(before.LastAccess) {
+		t.Error("mobile should inherit global auto renew")
+	}
+}
@@ -0,0 +1,211 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// TestDisableBlocksLoginAndVerify 验证封禁账号后拒绝登录、校验与刷新，解封后恢复
+func TestDisableBlocksLoginAndVerify(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithRefreshExpire(time.Hour).Build())
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "banned_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if err := gs.Disable(ctx, "banned_user", "", time.Hour, "spam"); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+
+	_, err = gs.Login(ctx, &core.LoginRequest{UserID: "banned_user", Device: "web"})
+	var disabledErr *core.UserDisabledError
+	if !errors.As(err, &disabledErr) || !errors.Is(err, core.ErrUserDisabled) {
+		t.Fatalf("expected UserDisabledError on login, got %v", err)
+	}
+	if disabledErr.Reason != "spam" || disabledErr.Service != core.DisableServiceAll {
+		t.Errorf("unexpected disable detail: %+v", disabledErr)
+	}
+
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.Token); !errors.Is(err, core.ErrUserDisabled) {
+		t.Errorf("existing token should be rejected with ErrUserDisabled, got %v", err)
+	}
+	if _, err := gs.RefreshToken(ctx, resp.RefreshToken); !errors.Is(err, core.ErrUserDisabled) {
+		t.Errorf("refresh should be rejected with ErrUserDisabled, got %v", err)
+	}
+
+	remaining, err := gs.GetDisableTime(ctx, "banned_user", "")
+	if err != nil || remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Errorf("unexpected remaining disable time %v, err %v", remaining, err)
+	}
+
+	// 解封后原Token恢复可用
+	if err := gs.Untie(ctx, "banned_user", ""); err != nil {
+		t.Fatalf("untie failed: %v", err)
+	}
+	if !gs.IsLogin(ctx, resp.Token) {
+		t.Error("token should be valid again after untie")
+	}
+	if remaining, _ := gs.GetDisableTime(ctx, "banned_user", ""); remaining != 0 {
+		t.Errorf("remaining disable time should be 0 after untie, got %v", remaining)
+	}
+}
+
+// TestDisableServiceScope 验证按服务范围封禁
+func TestDisableServiceScope(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	if err := gs.Disable(ctx, "scope_user", "comments", 0, "abuse"); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+
+	if disabled, _ := gs.IsDisabled(ctx, "scope_user", "comments"); !disabled {
+		t.Error("user should be disabled for comments")
+	}
+	if disabled, _ := gs.IsDisabled(ctx, "scope_user", "orders"); disabled {
+		t.Error("user should not be disabled for other services")
+	}
+	if remaining, _ := gs.GetDisableTime(ctx, "scope_user", "comments"); remaining != core.DisablePermanent {
+		t.Errorf("expected permanent disable, got %v", remaining)
+	}
+
+	// 服务范围的封禁不影响登录
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "scope_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login should succeed with scoped disable: %v", err)
+	}
+
+	// 全部服务的封禁覆盖所有服务范围
+	gs.Disable(ctx, "scope_user", core.DisableServiceAll, 50*time.Millisecond, "")
+	if disabled, _ := gs.IsDisabled(ctx, "scope_user", "orders"); !disabled {
+		t.Error("disable all should apply to every service")
+	}
+
+	// 到期后自动解封
+	time.Sleep(60 * time.Millisecond)
+	if disabled, _ := gs.IsDisabled(ctx, "scope_user", "orders"); disabled {
+		t.Error("timed disable should expire")
+	}
+	if !gs.IsLogin(ctx, resp.Token) {
+		t.Error("token should be valid after disable expires")
+	}
+}
+
+// TestGinRequireNotDisabled 验证中间件按服务拦截封禁账号并返回独立错误码
+func TestGinRequireNotDisabled(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), nil)
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) }
+	r.GET("/profile", auth.RequireAuth(), ok)
+	r.POST("/comments", auth.RequireNotDisabled("comments"), ok)
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "gin_banned"})
+	gs.Disable(ctx, "gin_banned", "comments", time.Hour, "abuse")
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(web.HeaderAuthorization, web.BearerPrefix+resp.Token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	errorCode := func(w *httptest.ResponseRecorder) string {
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		code, _ := body["error"].(string)
+		return code
+	}
+
+	if w := do("GET", "/profile"); w.Code != http.StatusOK {
+		t.Errorf("scoped disable should not block other routes, got %d", w.Code)
+	}
+	if w := do("POST", "/comments"); w.Code != http.StatusForbidden || errorCode(w) != web.ErrorUserDisabled {
+		t.Errorf("expected 403 user_disabled, got %d %s", w.Code, w.Body.String())
+	}
+
+	// 封禁全部服务后，普通认证路由同样返回独立错误码
+	gs.Disable(ctx, "gin_banned", "", time.Hour, "")
+	if w := do("GET", "/profile"); w.Code != http.StatusForbidden || errorCode(w) != web.ErrorUserDisabled {
+		t.Errorf("expected 403 user_disabled, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// failingStorage 对指定键前缀的读取返回错误的存储，用于模拟存储故障
+type failingStorage struct {
+	*storage.MemoryStorage
+	prefix string
+}
+
+func (f *failingStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	if strings.HasPrefix(key, f.prefix) {
+		return nil, errors.New("storage unavailable")
+	}
+	return f.MemoryStorage.Get(ctx, key)
+}
+
+func (f *failingStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if strings.HasPrefix(key, f.prefix) {
+		return false, errors.New("storage unavailable")
+	}
+	return f.MemoryStorage.Exists(ctx, key)
+}
+
+// TestDisableStorageFailure 验证读取封禁记录失败时拒绝而不是视为未封禁
+func TestDisableStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	keyService := core.NewKeyService("gstoken")
+	store := &failingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: keyService.DisableKey("10001", "")}
+	disable := auth.NewDisableService(store, keyService)
+
+	if err := disable.CheckDisabled(ctx, "10001", "comments"); err == nil {
+		t.Error("storage failure should not be treated as not disabled")
+	}
+	if _, err := disable.IsDisabled(ctx, "10001", ""); err == nil {
+		t.Error("IsDisabled should report storage failure")
+	}
+	if err := disable.CheckDisabled(ctx, "10002", ""); err != nil {
+		t.Errorf("user without disable record should pass, got %v", err)
+	}
+}
+
+// TestRequireNotDisabledWithoutChecker 验证适配器不支持封禁检查时中间件拒绝请求
+func TestRequireNotDisabledWithoutChecker(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// 只暴露 GSTokenAdapter 的方法，隐藏 CheckDisabled
+	adapter := struct{ web.GSTokenAdapter }{web.NewGSTokenWebAdapter(gs)}
+	auth := web.NewGinAuthMiddleware(adapter, nil)
+	r.POST("/comments", auth.RequireNotDisabled("comments"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "no_checker"})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/comments", nil)
+	req.Header.Set(web.HeaderAuthorization, web.BearerPrefix+resp.Token)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("middleware without disable checker should reject, got %d", w.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+)
+
+// encryptionKey 生成 AES-256 测试密钥
+func encryptionKey(id string, b byte) core.EncryptionKey {
+	return core.EncryptionKey{ID: id, Key: []byte(strings.Repeat(string(rune(b)), 32))}
+}
+
+// TestEncryptedStorageRecords 验证会话与登录记录在底层存储中被加密
+func TestEncryptedStorageRecords(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithEncryptionKeys(encryptionKey("k1", 'a')).
+		WithRefreshExpire(time.Hour).
+		Build())
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{
+		UserID: "enc_user",
+		Device: "web",
+		IP:     "203.0.113.7",
+		Extra:  map[string]interface{}{"email": "alice@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	encrypted, ok := gs.GetStorage().(*storage.EncryptedStorage)
+	if !ok {
+		t.Fatalf("storage should be encrypted, got %T", gs.GetStorage())
+	}
+	raw := encrypted.Unwrap()
+	keys, _ := raw.Keys(ctx, "*")
+	for _, key := range keys {
+		value, _ := raw.Get(ctx, key)
+		text := fmt.Sprintf("%s", value)
+		if strings.Contains(text, "alice@example.com") || strings.Contains(text, "203.0.113.7") {
+			t.Errorf("record %s stored in plaintext: %s", key, text)
+		}
+	}
+
+	info, err := gs.GetLoginInfo(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("get login info failed: %v", err)
+	}
+	if info.IP != "203.0.113.7" || info.Extra["email"] != "alice@example.com" {
+		t.Errorf("decrypted login info mismatch: %+v", info)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if _, err := gs.RefreshToken(ctx, resp.RefreshToken); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+}
+
+// TestEncryptedStorageRotation 验证密钥轮换后旧记录仍可解密
+func TestEncryptedStorageRotation(t *testing.T) {
+	ctx := context.Background()
+	inner := storage.NewMemoryStorage()
+	store := storage.NewEncryptedStorage(inner, encryptionKey("k1", 'a'))
+
+	if err := store.Set(ctx, "old", map[string]string{"v": "1"}, time.Minute); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := store.Rotate(encryptionKey("k2", 'b')); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	if err := store.Set(ctx, "new", map[string]string{"v": "2"}, time.Minute); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	for key, want := range map[string]string{"old": `{"v":"1"}`, "new": `{"v":"2"}`} {
+		value, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", key, err)
+		}
+		if string(value.([]byte)) != want {
+			t.Errorf("%s: got %s want %s", key, value, want)
+		}
+	}
+
+	newRaw, _ := inner.Get(ctx, "new")
+	if !strings.Contains(string(newRaw.([]byte)), ":k2:") {
+		t.Errorf("new record should use rotated key: %s", newRaw)
+	}
+
+	// 移除旧密钥后，旧记录无法解密
+	if err := store.RemoveKey("k1"); err != nil {
+		t.Fatalf("remove key failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "old"); !errors.Is(err, core.ErrKeyNotFound) {
+		t.Errorf("record of removed key should fail, got %v", err)
+	}
+	if err := store.RemoveKey("k2"); err == nil {
+		t.Error("active key must not be removed")
+	}
+}
+
+// TestEncryptedStorageTamper 验证密文挪用到其他键或密钥无效时被拒绝
+func TestEncryptedStorageTamper(t *testing.T) {
+	ctx := context.Background()
+	inner := storage.NewMemoryStorage()
+	store := storage.NewEncryptedStorage(inner, encryptionKey("k1", 'a'))
+
+	store.Set(ctx, "gstoken:login:a", "alice", time.Minute)
+	raw, _ := inner.Get(ctx, "gstoken:login:a")
+	var envelope string
+	if err := json.Unmarshal(raw.([]byte), &envelope); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	inner.Set(ctx, "gstoken:login:b", envelope, time.Minute)
+
+	if _, err := store.Get(ctx, "gstoken:login:b"); !errors.Is(err, core.ErrDecryptFailed) {
+		t.Errorf("moved ciphertext should fail, got %v", err)
+	}
+
+	// 非法密钥长度时拒绝写入，避免明文落盘
+	bad := storage.NewEncryptedStorage(inner, core.EncryptionKey{ID: "bad", Key: []byte("short")})
+	if err := bad.Set(ctx, "k", "v", time.Minute); !errors.Is(err, core.ErrConfigInvalid) {
+		t.Errorf("invalid key should fail closed, got %v", err)
+	}
+}
+
+// TestEncryptedStoragePlaintext 验证未加密记录默认被拒绝，仅在迁移开关开启时原样返回
+func TestEncryptedStoragePlaintext(t *testing.T) {
+	ctx := context.Background()
+	inner := storage.NewMemoryStorage()
+	store := storage.NewEncryptedStorage(inner, encryptionKey("k1", 'a'))
+
+	inner.Set(ctx, "gstoken:session:injected", map[string]string{"user_id": "admin"}, time.Minute)
+	if _, err := store.Get(ctx, "gstoken:session:injected"); !errors.Is(err, core.ErrDecryptFailed) {
+		t.Errorf("plaintext record should be rejected by default, got %v", err)
+	}
+
+	store.SetAllowPlaintext(true)
+	value, err := store.Get(ctx, "gstoken:session:injected")
+	if err != nil {
+		t.Fatalf("plaintext fallback failed: %v", err)
+	}
+	if string(value.([]byte)) != `{"user_id":"admin"}` {
+		t.Errorf("unexpected legacy value: %s", value)
+	}
+
+	// 通过配置开启迁移开关
+	gs := gstoken.New(config.NewBuilder().
+		WithEncryptionKeys(encryptionKey("k1", 'a')).
+		WithEncryptionPlaintextFallback(true).
+		Build())
+	gs.GetStorage().(*storage.EncryptedStorage).Unwrap().Set(ctx, "legacy", "v", time.Minute)
+	if _, err := gs.GetStorage().Get(ctx, "legacy"); err != nil {
+		t.Errorf("plaintext fallback from config failed: %v", err)
+	}
+}
+
+// TestEncryptedStorageInvalidKeys 验证加密密钥配置无效时在创建实例时即失败
+func TestEncryptedStorageInvalidKeys(t *testing.T) {
+	cases := map[string][]core.EncryptionKey{
+		"short key":     {{ID: "k1", Key: []byte("too-short")}},
+		"empty id":      {{ID: "", Key: encryptionKey("x", 'a').Key}},
+		"colon in id":   {{ID: "k:1", Key: encryptionKey("x", 'a').Key}},
+		"duplicate ids": {encryptionKey("k1", 'a'), encryptionKey("k1", 'b')},
+	}
+	for name, keys := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.NewBuilder().WithEncryptionKeys(keys...).Build()
+			if err := cfg.Validate(); !errors.Is(err, core.ErrConfigInvalid) {
+				t.Fatalf("expected ErrConfigInvalid, got %v", err)
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Error("gstoken.New should panic on invalid encryption keys")
+				}
+			}()
+			gstoken.New(cfg)
+		})
+	}
+
+	if err := config.NewBuilder().WithEncryptionKeys(encryptionKey("k1", 'a')).Build().Validate(); err != nil {
+		t.Errorf("valid encryption keys rejected: %v", err)
+	}
+}
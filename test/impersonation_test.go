@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// newImpersonationGSToken 创建要求 user:impersonate 权限且禁止模拟 admin 角色的实例
+func newImpersonationGSToken(listener core.SecurityEventListener) *gstoken.GSToken {
+	provider := newRP()
+	provider.users["vip_admin"] = []string{"admin"}
+	provider.users["customer"] = []string{"user"}
+	provider.users["support_01"] = []string{"support"}
+	provider.users["support_02"] = []string{"support"}
+	provider.perms["support"] = []string{"user:impersonate"}
+	return gstoken.New(config.NewBuilder().
+		WithLoginMode(core.SingleLogin).
+		WithRefreshExpire(time.Hour).
+		WithUserRoleProvider(provider).
+		WithSecurityEventListener(listener).
+		WithImpersonationPermission("user:impersonate").
+		WithImpersonationBlockedRoles("admin").
+		Build())
+}
+
+// TestImpersonateAndEnd 验证模拟登录记录双方身份，结束后恢复操作人会话
+func TestImpersonateAndEnd(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := newImpersonationGSToken(listener)
+
+	customer, _ := gs.Login(ctx, &core.LoginRequest{UserID: "customer", Device: "web"})
+	staff, _ := gs.Login(ctx, &core.LoginRequest{UserID: "support_01", Device: "web"})
+
+	resp, err := gs.Impersonate(ctx, &core.ImpersonateRequest{
+		ActorToken:   staff.Token,
+		TargetUserID: "customer",
+		Reason:       "ticket #42",
+	})
+	if err != nil {
+		t.Fatalf("impersonate failed: %v", err)
+	}
+	if resp.RefreshToken != "" {
+		t.Error("impersonation should not issue refresh token")
+	}
+
+	userInfo, err := gs.GetAuthEngine().Verify(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if userInfo.ID != "customer" || userInfo.ImpersonatorID != "support_01" {
+		t.Errorf("unexpected identities: %+v", userInfo)
+	}
+	info, _ := gs.GetLoginInfo(ctx, resp.Token)
+	if info.Impersonator == nil || info.Impersonator.Reason != "ticket #42" {
+		t.Errorf("login info should record impersonator: %+v", info.Impersonator)
+	}
+
+	// 单端登录下模拟登录不踢出用户自己的会话
+	if !gs.IsLogin(ctx, customer.Token) {
+		t.Error("impersonation must not kick out target's own session")
+	}
+
+	// 模拟期间不能嵌套模拟
+	if _, err := gs.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: resp.Token, TargetUserID: "other"}); !errors.Is(err, core.ErrImpersonationNotAllowed) {
+		t.Errorf("nested impersonation should be rejected, got %v", err)
+	}
+
+	original, err := gs.EndImpersonation(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("end impersonation failed: %v", err)
+	}
+	if original.UserID != "support_01" {
+		t.Errorf("should revert to actor session, got %+v", original)
+	}
+	if gs.IsLogin(ctx, resp.Token) {
+		t.Error("impersonation token should be revoked")
+	}
+	if !gs.IsLogin(ctx, staff.Token) {
+		t.Error("actor token should stay valid")
+	}
+	if _, err := gs.EndImpersonation(ctx, staff.Token); err == nil {
+		t.Error("end impersonation on normal token should fail")
+	}
+
+	for _, eventType := range []core.SecurityEventType{core.SecurityEventImpersonationStart, core.SecurityEventImpersonationEnd, core.SecurityEventImpersonationDenied} {
+		if listener.count(eventType) != 1 {
+			t.Errorf("expected one %s event, got %d", eventType, listener.count(eventType))
+		}
+	}
+}
+
+// TestImpersonateBlockedRole 验证禁止模拟拥有受保护角色的用户
+func TestImpersonateBlockedRole(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := newImpersonationGSToken(listener)
+
+	staff, _ := gs.Login(ctx, &core.LoginRequest{UserID: "support_02", Device: "web"})
+
+	_, err := gs.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: staff.Token, TargetUserID: "vip_admin"})
+	if !errors.Is(err, core.ErrImpersonationNotAllowed) {
+		t.Fatalf("expected ErrImpersonationNotAllowed, got %v", err)
+	}
+	if _, err := gs.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: staff.Token, TargetUserID: "support_02"}); !errors.Is(err, core.ErrImpersonationNotAllowed) {
+		t.Errorf("self impersonation should be rejected, got %v", err)
+	}
+	if listener.count(core.SecurityEventImpersonationDenied) != 2 {
+		t.Errorf("denied attempts should be audited, got %d", listener.count(core.SecurityEventImpersonationDenied))
+	}
+
+	if _, err := gs.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: "invalid", TargetUserID: "customer"}); err == nil {
+		t.Error("invalid actor token should be rejected")
+	}
+}
+
+// TestImpersonateRequiresPermission 验证操作人没有模拟登录权限或未配置权限时拒绝模拟
+func TestImpersonateRequiresPermission(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := newImpersonationGSToken(listener)
+
+	customer, _ := gs.Login(ctx, &core.LoginRequest{UserID: "customer", Device: "web"})
+	if _, err := gs.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: customer.Token, TargetUserID: "support_01"}); !errors.Is(err, core.ErrImpersonationNotAllowed) {
+		t.Errorf("actor without permission should be rejected, got %v", err)
+	}
+	if listener.count(core.SecurityEventImpersonationDenied) != 1 {
+		t.Errorf("denied attempt should be audited, got %d", listener.count(core.SecurityEventImpersonationDenied))
+	}
+
+	// 未配置模拟登录权限时默认禁止
+	provider := newRP()
+	provider.users["support_01"] = []string{"admin"}
+	provider.perms["admin"] = []string{core.PermissionWildcard}
+	disabled := gstoken.New(config.NewBuilder().WithUserRoleProvider(provider).Build())
+	staff, _ := disabled.Login(ctx, &core.LoginRequest{UserID: "support_01", Device: "web"})
+	if _, err := disabled.Impersonate(ctx, &core.ImpersonateRequest{ActorToken: staff.Token, TargetUserID: "customer"}); !errors.Is(err, core.ErrImpersonationNotAllowed) {
+		t.Errorf("impersonation should be disabled without permission config, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// newHybridGSToken 创建混合校验模式的实例
+func newHybridGSToken(mode core.LoginMode) *gstoken.GSToken {
+	return gstoken.New(config.NewBuilder().
+		WithJWTSecret([]byte("hybrid-secret")).
+		WithVerifyMode(core.VerifyHybrid).
+		WithLoginMode(mode).
+		WithTokenExpire(time.Hour).
+		Build())
+}
+
+// TestHybridLogoutRevokes 验证混合模式下登出会吊销签名Token
+func TestHybridLogoutRevokes(t *testing.T) {
+	gs := newHybridGSToken(core.MultiLogin)
+	ctx := context.Background()
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "hybrid_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	if err := gs.Logout(ctx, resp.Token); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.Token); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked after logout, got %v", err)
+	}
+
+	// 吊销记录的TTL不应超过Token剩余有效期
+	keys, _ := gs.GetStorage().Keys(ctx, "gstoken:revoked:*")
+	if len(keys) != 1 {
+		t.Fatalf("expected one revocation entry, got %v", keys)
+	}
+}
+
+// TestHybridLogoutByUserIDRevokes 验证按用户登出吊销该用户所有签名Token
+func TestHybridLogoutByUserIDRevokes(t *testing.T) {
+	gs := newHybridGSToken(core.MultiLogin)
+	ctx := context.Background()
+
+	var tokens []string
+	for _, device := range []string{"web", "ios"} {
+		resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "hybrid_multi", Device: device})
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		tokens = append(tokens, resp.Token)
+	}
+
+	if err := gs.LogoutByUserID(ctx, "hybrid_multi"); err != nil {
+		t.Fatalf("logout by user id failed: %v", err)
+	}
+
+	for _, tk := range tokens {
+		if _, err := gs.GetAuthEngine().Verify(ctx, tk); !errors.Is(err, core.ErrTokenRevoked) {
+			t.Errorf("expected ErrTokenRevoked, got %v", err)
+		}
+	}
+}
+
+// TestHybridKickOutRevokes 验证会话服务踢人与单端登录同样会吊销签名Token
+func TestHybridKickOutRevokes(t *testing.T) {
+	gs := newHybridGSToken(core.SingleLogin)
+	ctx := context.Background()
+
+	first, err := gs.Login(ctx, &core.LoginRequest{UserID: "hybrid_single", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	// 单端登录：第二次登录踢出第一次的Token
+	second, err := gs.Login(ctx, &core.LoginRequest{UserID: "hybrid_single", Device: "ios"})
+	if err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, first.Token); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Errorf("first token should be revoked by single login, got %v", err)
+	}
+
+	engine := gs.GetAuthEngine().(interface {
+		GetSessionService() core.SessionService
+	})
+	if err := engine.GetSessionService().KickOut(ctx, "hybrid_single"); err != nil {
+		t.Fatalf("kick out failed: %v", err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, second.Token); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Errorf("second token should be revoked by kick out, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// TestJWTSignAndParse 验证各签名算法的签发与解析
+func TestJWTSignAndParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		jwt  core.JWTConfig
+	}{
+		{"HS256", core.JWTConfig{Algorithm: core.JWTAlgHS256, Secret: []byte("test-secret-0123456789")}},
+		{"RS256", core.JWTConfig{Algorithm: core.JWTAlgRS256, PrivateKey: rsaKey}},
+		{"ES256", core.JWTConfig{Algorithm: core.JWTAlgES256, PrivateKey: ecKey}},
+		{"EdDSA", core.JWTConfig{Algorithm: core.JWTAlgEdDSA, PrivateKey: edKey}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.NewBuilder().WithJWT(tc.jwt).WithTokenExpire(time.Hour).Build()
+			gen := token.NewGeneratorWithConfig(cfg)
+
+			tk, err := gen.Generate(map[string]interface{}{
+				core.TokenExtraKeyUserID: "jwt_user",
+				core.TokenExtraKeyDevice: "web",
+			})
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+			if len(strings.Split(tk, ".")) != 3 {
+				t.Fatalf("jwt should have 3 parts: %s", tk)
+			}
+
+			info, err := gen.Parse(tk)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if info.UserID != "jwt_user" {
+				t.Errorf("user id mismatch: %s", info.UserID)
+			}
+			if info.Extra[core.TokenExtraKeyDevice] != "web" {
+				t.Errorf("device claim lost: %v", info.Extra)
+			}
+			if d := time.Until(info.ExpireTime); d < 59*time.Minute || d > time.Hour {
+				t.Errorf("unexpected expire time: %v", info.ExpireTime)
+			}
+
+			// 篡改载荷后签名校验必须失败
+			parts := strings.Split(tk, ".")
+			forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","exp":9999999999}`))
+			if _, err := gen.Parse(parts[0] + "." + forged + "." + parts[2]); !errors.Is(err, core.ErrTokenSignatureInvalid) {
+				t.Errorf("tampered token should fail signature check, got %v", err)
+			}
+		})
+	}
+}
+
+// TestJWTRejectsAlgorithmSwitch 验证不接受与配置不一致的算法头
+func TestJWTRejectsAlgorithmSwitch(t *testing.T) {
+	cfg := config.NewBuilder().WithJWTSecret([]byte("secret-a")).Build()
+	gen := token.NewGeneratorWithConfig(cfg)
+
+	tk, err := gen.Generate(map[string]interface{}{core.TokenExtraKeyUserID: "u1"})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	parts := strings.Split(tk, ".")
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	if _, err := gen.Parse(noneHeader + "." + parts[1] + "."); err == nil {
+		t.Fatal("alg=none token must be rejected")
+	}
+
+	other := token.NewGeneratorWithConfig(config.NewBuilder().WithJWTSecret([]byte("secret-b")).Build())
+	if _, err := other.Parse(tk); !errors.Is(err, core.ErrTokenSignatureInvalid) {
+		t.Errorf("token signed with other secret should be rejected, got %v", err)
+	}
+}
+
+// TestJWTExpired 验证过期Token被拒绝
+func TestJWTExpired(t *testing.T) {
+	cfg := config.NewBuilder().
+		WithJWTSecret([]byte("secret")).
+		WithTokenExpire(time.Second).
+		Build()
+	gen := token.NewGeneratorWithConfig(cfg)
+
+	tk, err := gen.Generate(map[string]interface{}{core.TokenExtraKeyUserID: "u1"})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	time.Sleep(2100 * time.Millisecond)
+
+	if _, err := gen.Parse(tk); !errors.Is(err, core.ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+// TestStatelessVerify 验证无状态模式下校验方无需访问签发方的存储
+func TestStatelessVerify(t *testing.T) {
+	secret := []byte("shared-edge-secret")
+	ctx := context.Background()
+
+	issuer := gstoken.New(config.NewBuilder().WithJWTSecret(secret).Build())
+	resp, err := issuer.Login(ctx, &core.LoginRequest{
+		UserID: "edge_user",
+		Device: "mobile",
+		Extra:  map[string]interface{}{"tenant": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	// 边缘节点：独立的内存存储，无任何登录数据
+	edge := gstoken.New(config.NewBuilder().
+		WithJWTSecret(secret).
+		WithVerifyMode(core.VerifyStateless).
+		Build())
+
+	userInfo, err := edge.GetAuthEngine().Verify(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("stateless verify failed: %v", err)
+	}
+	if userInfo.ID != "edge_user" {
+		t.Errorf("user id mismatch: %s", userInfo.ID)
+	}
+	if userInfo.Extra["tenant"] != "acme" {
+		t.Errorf("extra claim lost: %v", userInfo.Extra)
+	}
+
+	keys, _ := edge.GetStorage().Keys(ctx, "*")
+	if len(keys) != 0 {
+		t.Errorf("stateless verify should not touch storage, got keys %v", keys)
+	}
+
+	// 刷新Token不能用作访问Token
+	if resp.RefreshToken != "" {
+		if _, err := edge.GetAuthEngine().Verify(ctx, resp.RefreshToken); err == nil {
+			t.Error("refresh token must not pass as access token")
+		}
+	}
+
+	// 非签名风格不允许无状态校验
+	opaque := gstoken.New(config.NewBuilder().WithVerifyMode(core.VerifyStateless).Build())
+	if _, err := opaque.GetAuthEngine().Verify(ctx, "550e8400-e29b-41d4-a716-446655440000"); err == nil {
+		t.Error("opaque token style must not pass stateless verify")
+	}
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// jwtKid 读取JWT头部中的 kid
+func jwtKid(t *testing.T, tk string) string {
+	headerBytes, err := base64.RawURLEncoding.DecodeString(strings.Split(tk, ".")[0])
+	if err != nil {
+		t.Fatalf("decode header: %v", err)
+	}
+	var header struct {
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		t.Fatalf("unmarshal header: %v", err)
+	}
+	return header.Kid
+}
+
+// TestKeyringRotation 验证轮换签名密钥后旧Token仍可校验
+func TestKeyringRotation(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithSigningKeys(core.SigningKey{ID: "k1", Algorithm: core.JWTAlgHS256, Secret: []byte("secret-1")}).
+		Build())
+
+	before, err := gs.Login(ctx, &core.LoginRequest{UserID: "rotate_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if kid := jwtKid(t, before.Token); kid != "k1" {
+		t.Fatalf("expected kid k1, got %q", kid)
+	}
+
+	keyring := gs.GetKeyring()
+	if err := keyring.Rotate(core.SigningKey{ID: "k2", Algorithm: core.JWTAlgHS256, Secret: []byte("secret-2")}); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+
+	after, err := gs.Login(ctx, &core.LoginRequest{UserID: "rotate_user", Device: "ios"})
+	if err != nil {
+		t.Fatalf("login after rotation failed: %v", err)
+	}
+	if kid := jwtKid(t, after.Token); kid != "k2" {
+		t.Fatalf("expected kid k2 after rotation, got %q", kid)
+	}
+
+	gen := gs.GetTokenGenerator()
+	for _, tk := range []string{before.Token, after.Token} {
+		if _, err := gen.Parse(tk); err != nil {
+			t.Errorf("token should verify after rotation: %v", err)
+		}
+	}
+
+	// 退役旧密钥后，旧Token不再被接受
+	if err := keyring.Retire("k1", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("retire failed: %v", err)
+	}
+	if _, err := gen.Parse(before.Token); !errors.Is(err, core.ErrTokenSignatureInvalid) {
+		t.Errorf("token of retired key should be rejected, got %v", err)
+	}
+	if err := keyring.Retire("k2", time.Now()); err == nil {
+		t.Error("active key must not be retired")
+	}
+}
+
+// TestKeyringScheduledRotation 验证计划轮换到期后自动切换签名密钥
+func TestKeyringScheduledRotation(t *testing.T) {
+	keyring, err := token.NewKeyring(
+		core.SigningKey{ID: "old", Algorithm: core.JWTAlgHS256, Secret: []byte("old")},
+		core.SigningKey{ID: "new", Algorithm: core.JWTAlgHS256, Secret: []byte("new"), ActivateAt: time.Now().Add(300 * time.Millisecond)},
+	)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+
+	active, _ := keyring.Active()
+	if active.ID != "old" {
+		t.Fatalf("expected old key active, got %s", active.ID)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	active, _ = keyring.Active()
+	if active.ID != "new" {
+		t.Fatalf("expected scheduled key active, got %s", active.ID)
+	}
+	if _, err := keyring.Lookup("old"); err != nil {
+		t.Errorf("old key should stay verify-only: %v", err)
+	}
+
+	if _, err := token.NewKeyring(
+		core.SigningKey{ID: "dup", Algorithm: core.JWTAlgHS256, Secret: []byte("a")},
+		core.SigningKey{ID: "dup", Algorithm: core.JWTAlgHS256, Secret: []byte("b")},
+	); !errors.Is(err, core.ErrConfigInvalid) {
+		t.Errorf("duplicate kid should be rejected, got %v", err)
+	}
+}
+
+// TestKeyringJWKS 验证 JWKS 仅导出公钥
+func TestKeyringJWKS(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	keyring, err := token.NewKeyring(
+		core.SigningKey{ID: "ec", Algorithm: core.JWTAlgES256, PrivateKey: ecKey},
+		core.SigningKey{ID: "ed", Algorithm: core.JWTAlgEdDSA, PrivateKey: edKey},
+		core.SigningKey{ID: "hs", Algorithm: core.JWTAlgHS256, Secret: []byte("symmetric")},
+	)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+
+	data, err := keyring.JWKS()
+	if err != nil {
+		t.Fatalf("export jwks: %v", err)
+	}
+	var set token.JWKSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		t.Fatalf("unmarshal jwks: %v", err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("expected 2 public keys, got %s", data)
+	}
+	if set.Keys[0].Kty != "EC" || set.Keys[0].Crv != "P-256" || set.Keys[0].X == "" {
+		t.Errorf("unexpected ec jwk: %+v", set.Keys[0])
+	}
+	if set.Keys[1].Kty != "OKP" || set.Keys[1].Crv != "Ed25519" {
+		t.Errorf("unexpected ed25519 jwk: %+v", set.Keys[1])
+	}
+	if strings.Contains(string(data), "symmetric") {
+		t.Error("symmetric secret must not be exported")
+	}
+}
+
+// TestKeyringPaseto 验证 PASETO 使用页脚中的 kid 选择密钥
+func TestKeyringPaseto(t *testing.T) {
+	k1 := make([]byte, 32)
+	k2 := make([]byte, 32)
+	rand.Read(k1)
+	rand.Read(k2)
+
+	cfg := config.NewBuilder().
+		WithSigningKeys(core.SigningKey{ID: "p1", Algorithm: core.KeyAlgPasetoLocal, Secret: k1}).
+		WithPasetoAssertions(`{"app":"demo"}`, "").
+		Build()
+	if cfg.TokenStyle != core.StylePasetoLocal {
+		t.Fatalf("style should follow key algorithm, got %v", cfg.TokenStyle)
+	}
+	gen := token.NewGeneratorWithConfig(cfg)
+
+	old, err := gen.Generate(map[string]interface{}{core.TokenExtraKeyUserID: "p_user"})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if err := gen.Keyring().Rotate(core.SigningKey{ID: "p2", Algorithm: core.KeyAlgPasetoLocal, Secret: k2}); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+
+	info, err := gen.Parse(old)
+	if err != nil {
+		t.Fatalf("old token should verify: %v", err)
+	}
+	if info.Footer != `{"app":"demo","kid":"p1"}` {
+		t.Errorf("unexpected footer: %s", info.Footer)
+	}
+}
@@ -0,0 +1,278 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// newLockoutGSToken 创建配置了密码登录与登录失败限制的 GSToken
+func newLockoutGSToken(policy core.LoginAttemptPolicy, listener core.SecurityEventListener) *gstoken.GSToken {
+	hasher := auth.NewArgon2idHasher(testArgon2idParams())
+	store := newMemCredentialStore()
+	hash, _ := hasher.Hash("correct-pass")
+	store.add("alice", "10001", hash)
+
+	builder := config.NewBuilder().
+		WithCredentialVerifier(auth.NewPasswordVerifier(store, hasher)).
+		WithLoginAttemptPolicy(policy)
+	if listener != nil {
+		builder = builder.WithSecurityEventListener(listener)
+	}
+	return gstoken.New(builder.Build())
+}
+
+// TestLoginLockoutAfterFailures 验证连续失败达到上限后锁定账号，锁定期内正确密码同样被拒绝
+func TestLoginLockoutAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := newLockoutGSToken(core.LoginAttemptPolicy{MaxAttempts: 3, Window: time.Minute, LockDuration: time.Minute}, listener)
+
+	wrong := &core.PasswordLoginRequest{Username: "alice", Password: "wrong", IP: "10.0.0.1"}
+	for i := 0; i < 2; i++ {
+		if _, err := gs.LoginWithPassword(ctx, wrong); !errors.Is(err, core.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: expected ErrInvalidCredentials, got %v", i+1, err)
+		}
+	}
+
+	// 第三次失败触发锁定
+	_, err := gs.LoginWithPassword(ctx, wrong)
+	var locked *core.TooManyAttemptsError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected TooManyAttemptsError, got %v", err)
+	}
+	if locked.Kind != core.LoginAttemptKindUser || locked.Subject != "alice" || locked.RetryAfter <= 0 || locked.RetryAfter > time.Minute {
+		t.Errorf("unexpected lock error: %+v", locked)
+	}
+	if listener.count(core.SecurityEventLoginLocked) != 1 {
+		t.Errorf("expected one login_locked event, got %d", listener.count(core.SecurityEventLoginLocked))
+	}
+
+	_, err = gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "correct-pass"})
+	if !errors.Is(err, core.ErrTooManyAttempts) {
+		t.Fatalf("locked account should reject correct password, got %v", err)
+	}
+
+	// 管理员解锁后可正常登录
+	if err := gs.ResetLoginAttempts(ctx, "alice", ""); err != nil {
+		t.Fatalf("reset attempts failed: %v", err)
+	}
+	if _, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "correct-pass"}); err != nil {
+		t.Fatalf("login after reset failed: %v", err)
+	}
+}
+
+// TestLoginSuccessResetsFailures 验证登录成功后清除该登录名的失败计数
+func TestLoginSuccessResetsFailures(t *testing.T) {
+	ctx := context.Background()
+	gs := newLockoutGSToken(core.LoginAttemptPolicy{MaxAttempts: 2, Window: time.Minute}, nil)
+
+	wrong := &core.PasswordLoginRequest{Username: "alice", Password: "wrong"}
+	gs.LoginWithPassword(ctx, wrong)
+	if _, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "correct-pass"}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if _, err := gs.LoginWithPassword(ctx, wrong); !errors.Is(err, core.ErrInvalidCredentials) {
+		t.Errorf("failure count should restart after success, got %v", err)
+	}
+}
+
+// TestLoginAttemptPerIP 验证同一IP对不同账号的失败累计后锁定该IP
+func TestLoginAttemptPerIP(t *testing.T) {
+	ctx := context.Background()
+	gs := newLockoutGSToken(core.LoginAttemptPolicy{MaxAttempts: 10, MaxIPAttempts: 3, Window: time.Minute}, nil)
+
+	for _, username := range []string{"u1", "u2", "u3"} {
+		gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: username, Password: "x", IP: "10.0.0.9"})
+	}
+
+	_, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "correct-pass", IP: "10.0.0.9"})
+	var locked *core.TooManyAttemptsError
+	if !errors.As(err, &locked) || locked.Kind != core.LoginAttemptKindIP {
+		t.Fatalf("expected ip lock, got %v", err)
+	}
+	if _, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "correct-pass", IP: "10.0.0.10"}); err != nil {
+		t.Errorf("other ip should not be locked: %v", err)
+	}
+}
+
+// TestLoginAttemptSlidingWindowAndBackoff 验证滑动窗口丢弃过期失败记录，连续锁定时锁定时长翻倍且不超过上限
+func TestLoginAttemptSlidingWindowAndBackoff(t *testing.T) {
+	ctx := context.Background()
+	svc := auth.NewLoginAttemptService(storage.NewMemoryStorage(), core.NewKeyService("test"), core.LoginAttemptPolicy{
+		MaxAttempts:     2,
+		Window:          80 * time.Millisecond,
+		LockDuration:    50 * time.Millisecond,
+		MaxLockDuration: 150 * time.Millisecond,
+	})
+
+	// 窗口外的失败不计入
+	svc.RecordFailure(ctx, "bob", "")
+	time.Sleep(100 * time.Millisecond)
+	if err := svc.RecordFailure(ctx, "bob", ""); err != nil {
+		t.Fatalf("failure outside window should not lock: %v", err)
+	}
+
+	expected := []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond}
+	for i, want := range expected {
+		if i > 0 {
+			svc.RecordFailure(ctx, "bob", "")
+		}
+		var locked *core.TooManyAttemptsError
+		if err := svc.RecordFailure(ctx, "bob", ""); !errors.As(err, &locked) {
+			t.Fatalf("lock %d: expected TooManyAttemptsError, got %v", i+1, err)
+		}
+		if locked.RetryAfter != want {
+			t.Errorf("lock %d: expected %v, got %v", i+1, want, locked.RetryAfter)
+		}
+		if err := svc.CheckAttempts(ctx, "bob", ""); !errors.Is(err, core.ErrTooManyAttempts) {
+			t.Errorf("lock %d: check should report lock, got %v", i+1, err)
+		}
+		time.Sleep(want + 10*time.Millisecond)
+	}
+}
+
+// TestGinLimitLoginAttempts 验证登录路由中间件对被锁定的IP返回 429 与 Retry-After
+func TestGinLimitLoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	gs := newLockoutGSToken(core.LoginAttemptPolicy{MaxIPAttempts: 1, Window: time.Minute, LockDuration: time.Minute}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	mw := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), nil)
+	r.POST("/login", mw.LimitLoginAttempts(), func(c *gin.Context) {
+		ip, _ := c.Get(web.ContextKeyClientIP)
+		c.String(http.StatusOK, ip.(string))
+	})
+
+	doLogin := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "192.0.2.7:51234"
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := doLogin(); w.Code != http.StatusOK || w.Body.String() != "192.0.2.7" {
+		t.Fatalf("expected 200 with client ip, got %d %s", w.Code, w.Body.String())
+	}
+
+	gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "wrong", IP: "192.0.2.7"})
+
+	w := doLogin()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get(web.HeaderRetryAfter) != "60" {
+		t.Errorf("expected Retry-After 60, got %q", w.Header().Get(web.HeaderRetryAfter))
+	}
+}
+
+// countingVerifier 统计凭证校验次数的校验器
+type countingVerifier struct {
+	core.CredentialVerifier
+	calls atomic.Int64
+}
+
+func (v *countingVerifier) VerifyCredential(ctx context.Context, username, password string) (string, error) {
+	v.calls.Add(1)
+	return v.CredentialVerifier.VerifyCredential(ctx, username, password)
+}
+
+// TestLoginAttemptConcurrentFailures 验证并发的错误密码登录中最多 MaxAttempts 次到达凭证校验，随后账号被锁定
+func TestLoginAttemptConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	hasher := auth.NewArgon2idHasher(testArgon2idParams())
+	store := newMemCredentialStore()
+	hash, _ := hasher.Hash("correct-pass")
+	store.add("alice", "10001", hash)
+	verifier := &countingVerifier{CredentialVerifier: auth.NewPasswordVerifier(store, hasher)}
+	gs := gstoken.New(config.NewBuilder().
+		WithCredentialVerifier(verifier).
+		WithLoginAttemptPolicy(core.LoginAttemptPolicy{MaxAttempts: 5, Window: time.Minute, LockDuration: time.Minute}).
+		Build())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice", Password: "wrong"})
+		}()
+	}
+	wg.Wait()
+
+	if calls := verifier.calls.Load(); calls > 5 {
+		t.Errorf("expected at most 5 credential checks, got %d", calls)
+	}
+	if err := gs.GetLoginAttemptService().CheckAttempts(ctx, "alice", ""); !errors.Is(err, core.ErrTooManyAttempts) {
+		t.Errorf("account should be locked, got %v", err)
+	}
+}
+
+// TestLoginAttemptStorageFailure 验证读取锁定记录失败时拒绝登录而不是视为未锁定
+func TestLoginAttemptStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	keyService := core.NewKeyService("test")
+	store := &failingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: keyService.LoginLockKey(core.LoginAttemptKindUser, "alice")}
+	svc := auth.NewLoginAttemptService(store, keyService, core.LoginAttemptPolicy{MaxAttempts: 3})
+
+	if err := svc.CheckAttempts(ctx, "alice", ""); err == nil {
+		t.Error("storage failure should not be treated as not locked")
+	}
+	if err := svc.BeginAttempt(ctx, "alice", ""); err == nil {
+		t.Error("BeginAttempt should report storage failure")
+	}
+	if err := svc.RecordFailure(ctx, "alice", ""); err == nil || errors.Is(err, core.ErrTooManyAttempts) {
+		t.Errorf("RecordFailure should report storage failure, got %v", err)
+	}
+}
+
+// TestLoginAttemptWindowBoundary 验证在窗口边界前后连续失败仍按滑动窗口计数，不能得到两倍的尝试次数
+func TestLoginAttemptWindowBoundary(t *testing.T) {
+	ctx := context.Background()
+	const window = 400 * time.Millisecond
+	svc := auth.NewLoginAttemptService(storage.NewMemoryStorage(), core.NewKeyService("test"), core.LoginAttemptPolicy{
+		MaxAttempts:  3,
+		Window:       window,
+		LockDuration: time.Minute,
+	})
+
+	// sleepUntil 等待到下一个固定窗口内的指定位置
+	sleepUntil := func(fraction float64) {
+		phase := time.Duration(time.Now().UnixNano() % int64(window))
+		target := time.Duration(float64(window) * fraction)
+		if target <= phase {
+			target += window
+		}
+		time.Sleep(target - phase)
+	}
+
+	// 窗口开始时失败一次，窗口即将结束时再失败一次
+	sleepUntil(0.01)
+	svc.RecordFailure(ctx, "carol", "")
+	sleepUntil(0.95)
+	if err := svc.RecordFailure(ctx, "carol", ""); err != nil {
+		t.Fatalf("second failure should not lock: %v", err)
+	}
+
+	// 首次失败已超过一个窗口时长，但最近一个窗口时长内的失败达到上限
+	sleepUntil(0.08)
+	svc.RecordFailure(ctx, "carol", "")
+	if err := svc.RecordFailure(ctx, "carol", ""); !errors.Is(err, core.ErrTooManyAttempts) {
+		t.Fatalf("failures across the window boundary should lock, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// newRefreshGSToken 创建开启刷新Token的实例
+func newRefreshGSToken(mode core.LoginMode) *gstoken.GSToken {
+	return gstoken.New(config.NewBuilder().
+		WithRefreshExpire(time.Hour).
+		WithLoginMode(mode).
+		Build())
+}
+
+// TestLogoutRevokesRefreshToken 验证登出后配对的刷新Token失效
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	gs := newRefreshGSToken(core.MultiLogin)
+
+	web, _ := gs.Login(ctx, &core.LoginRequest{UserID: "logout_refresh", Device: "web"})
+	ios, _ := gs.Login(ctx, &core.LoginRequest{UserID: "logout_refresh", Device: "ios"})
+
+	if err := gs.Logout(ctx, web.Token); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	if _, err := gs.RefreshToken(ctx, web.RefreshToken); err == nil {
+		t.Error("refresh token should be revoked after logout")
+	}
+	if _, err := gs.RefreshToken(ctx, ios.RefreshToken); err != nil {
+		t.Errorf("refresh token of other device should stay valid: %v", err)
+	}
+}
+
+// TestLogoutByUserIDRevokesRefreshTokens 验证按用户登出吊销全部刷新Token
+func TestLogoutByUserIDRevokesRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	gs := newRefreshGSToken(core.MultiLogin)
+
+	web, _ := gs.Login(ctx, &core.LoginRequest{UserID: "logout_all_refresh", Device: "web"})
+	ios, _ := gs.Login(ctx, &core.LoginRequest{UserID: "logout_all_refresh", Device: "ios"})
+	rotated, err := gs.RefreshToken(ctx, ios.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	if err := gs.LogoutByUserID(ctx, "logout_all_refresh"); err != nil {
+		t.Fatalf("logout by user id failed: %v", err)
+	}
+	for _, rt := range []string{web.RefreshToken, rotated.RefreshToken} {
+		if _, err := gs.RefreshToken(ctx, rt); err == nil {
+			t.Error("refresh token should be revoked after logout by user id")
+		}
+	}
+	if keys, _ := gs.GetStorage().Keys(ctx, "gstoken:user_refresh:logout_all_refresh:*"); len(keys) != 0 {
+		t.Errorf("refresh index should be cleared, got %v", keys)
+	}
+}
+
+// TestKickOutRevokesRefreshTokens 验证踢人下线同时吊销刷新Token
+func TestKickOutRevokesRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	gs := newRefreshGSToken(core.MultiLogin)
+	sessions := gs.GetAuthEngine().(*auth.Engine).GetSessionService()
+
+	first, _ := gs.Login(ctx, &core.LoginRequest{UserID: "kick_refresh", Device: "web"})
+	second, _ := gs.Login(ctx, &core.LoginRequest{UserID: "kick_refresh", Device: "ios"})
+
+	if err := sessions.KickOutByToken(ctx, first.Token); err != nil {
+		t.Fatalf("kick out by token failed: %v", err)
+	}
+	if _, err := gs.RefreshToken(ctx, first.RefreshToken); err == nil {
+		t.Error("refresh token should be revoked after kick out by token")
+	}
+
+	if err := sessions.KickOut(ctx, "kick_refresh"); err != nil {
+		t.Fatalf("kick out failed: %v", err)
+	}
+	if _, err := gs.RefreshToken(ctx, second.RefreshToken); err == nil {
+		t.Error("refresh token should be revoked after kick out")
+	}
+
+	// 同端互斥登录踢出旧会话时同样吊销其刷新Token
+	mutex := newRefreshGSToken(core.MutexLogin)
+	old, _ := mutex.Login(ctx, &core.LoginRequest{UserID: "kick_refresh", Device: "web"})
+	mutex.Login(ctx, &core.LoginRequest{UserID: "kick_refresh", Device: "web"})
+	if _, err := mutex.RefreshToken(ctx, old.RefreshToken); err == nil {
+		t.Error("refresh token of kicked session should be revoked")
+	}
+}
@@ -0,0 +1,295 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// totpAt 计算相对当前时间偏移 offset 个步长的验证码
+func totpAt(t *testing.T, secret string, offset int) string {
+	code, err := auth.TOTPCode(secret, time.Now().Add(time.Duration(offset)*30*time.Second), 6, 30*time.Second)
+	if err != nil {
+		t.Fatalf("generate totp code failed: %v", err)
+	}
+	return code
+}
+
+// enrollMFA 为用户绑定并确认 TOTP，返回绑定信息
+func enrollMFA(t *testing.T, gs *gstoken.GSToken, userID string) *core.TOTPEnrollment {
+	ctx := context.Background()
+	enrollment, err := gs.EnrollTOTP(ctx, userID, userID+"@example.com")
+	if err != nil {
+		t.Fatalf("enroll failed: %v", err)
+	}
+	if err := gs.ConfirmTOTP(ctx, userID, totpAt(t, enrollment.Secret, 0)); err != nil {
+		t.Fatalf("confirm failed: %v", err)
+	}
+	return enrollment
+}
+
+// TestTOTPCodeRFC6238 验证 RFC 6238 附录B的 SHA1 测试向量
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // ASCII "12345678901234567890"
+	vectors := map[int64]string{
+		59:         "94287082",
+		1111111109: "07081804",
+		1234567890: "89005924",
+		2000000000: "69279037",
+	}
+	for ts, want := range vectors {
+		got, err := auth.TOTPCode(secret, time.Unix(ts, 0), 8, 30*time.Second)
+		if err != nil || got != want {
+			t.Errorf("T=%d: expected %s, got %s (%v)", ts, want, got, err)
+		}
+	}
+}
+
+// TestMFAEnrollment 验证绑定返回 otpauth URI 与恢复码，确认前登录不要求验证码
+func TestMFAEnrollment(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithMFA(core.MFAConfig{Issuer: "GSToken"}).Build())
+
+	enrollment, err := gs.EnrollTOTP(ctx, "10001", "alice@example.com")
+	if err != nil {
+		t.Fatalf("enroll failed: %v", err)
+	}
+	uri, err := url.Parse(enrollment.URI)
+	if err != nil || uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/GSToken:alice@example.com" {
+		t.Fatalf("unexpected uri: %s", enrollment.URI)
+	}
+	query := uri.Query()
+	if query.Get("secret") != enrollment.Secret || query.Get("issuer") != "GSToken" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected uri query: %s", uri.RawQuery)
+	}
+	if len(enrollment.RecoveryCodes) != 10 {
+		t.Errorf("expected 10 recovery codes, got %d", len(enrollment.RecoveryCodes))
+	}
+
+	// 未确认时不启用
+	if resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "10001"}); err != nil || resp.MFARequired || resp.Token == "" {
+		t.Fatalf("unconfirmed enrollment should not require mfa: %+v %v", resp, err)
+	}
+	if err := gs.ConfirmTOTP(ctx, "10001", totpAt(t, enrollment.Secret, 3)); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("wrong code should not confirm, got %v", err)
+	}
+	if err := gs.ConfirmTOTP(ctx, "10001", totpAt(t, enrollment.Secret, 0)); err != nil {
+		t.Fatalf("confirm failed: %v", err)
+	}
+	if enabled, _ := gs.IsMFAEnabled(ctx, "10001"); !enabled {
+		t.Error("mfa should be enabled after confirm")
+	}
+
+	// 已启用时不能重新绑定，解除后可以
+	if _, err := gs.EnrollTOTP(ctx, "10001", ""); err == nil {
+		t.Error("enroll should fail while mfa enabled")
+	}
+	if err := gs.DisableMFA(ctx, "10001"); err != nil {
+		t.Fatalf("disable mfa failed: %v", err)
+	}
+	if resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "10001"}); err != nil || resp.MFARequired {
+		t.Errorf("login after disable should not require mfa: %+v %v", resp, err)
+	}
+}
+
+// TestMFATwoStageLogin 验证启用后登录返回待验证Token，提交验证码换取正式Token，且验证码不可重放
+func TestMFATwoStageLogin(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+	enrollment := enrollMFA(t, gs, "10001")
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "10001", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if !resp.MFARequired || resp.MFAToken == "" || resp.Token != "" || resp.RefreshToken != "" {
+		t.Fatalf("expected mfa pending response, got %+v", resp)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.MFAToken); err == nil {
+		t.Error("mfa pending token should not be accepted as access token")
+	}
+
+	// 确认绑定时使用的验证码不能再次使用
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, totpAt(t, enrollment.Secret, 0)); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("code used for confirm should be rejected, got %v", err)
+	}
+	// 超出允许偏移的验证码被拒绝
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, totpAt(t, enrollment.Secret, 3)); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("code outside skew should be rejected, got %v", err)
+	}
+
+	code := totpAt(t, enrollment.Secret, 1)
+	final, err := gs.VerifyMFA(ctx, resp.MFAToken, code)
+	if err != nil {
+		t.Fatalf("verify mfa failed: %v", err)
+	}
+	if final.Token == "" || final.MFARequired {
+		t.Fatalf("expected full login response, got %+v", final)
+	}
+	if info, err := gs.GetAuthEngine().Verify(ctx, final.Token); err != nil || info.ID != "10001" {
+		t.Errorf("issued token should be valid: %+v %v", info, err)
+	}
+
+	// 待验证Token只能使用一次
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, code); !errors.Is(err, core.ErrMFATokenInvalid) {
+		t.Errorf("pending token should be consumed, got %v", err)
+	}
+
+	// 同一验证码在新的登录中同样不能重放
+	again, _ := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if _, err := gs.VerifyMFA(ctx, again.MFAToken, code); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("replayed code should be rejected, got %v", err)
+	}
+}
+
+// TestMFARecoveryCode 验证恢复码可完成登录且只能使用一次，重新生成后旧恢复码失效
+func TestMFARecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+	enrollment := enrollMFA(t, gs, "10001")
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, enrollment.RecoveryCodes[0]); err != nil {
+		t.Fatalf("recovery code login failed: %v", err)
+	}
+
+	resp, _ = gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, enrollment.RecoveryCodes[0]); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("used recovery code should be rejected, got %v", err)
+	}
+
+	codes, err := gs.RegenerateRecoveryCodes(ctx, "10001")
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("regenerate recovery codes failed: %v", err)
+	}
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, enrollment.RecoveryCodes[1]); !errors.Is(err, core.ErrMFACodeInvalid) {
+		t.Errorf("old recovery code should be rejected, got %v", err)
+	}
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, codes[0]); err != nil {
+		t.Errorf("new recovery code should work: %v", err)
+	}
+}
+
+// TestMFAPendingMaxAttempts 验证错误次数达到上限后待验证Token失效
+func TestMFAPendingMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithMFA(core.MFAConfig{MaxAttempts: 2}).Build())
+	enrollment := enrollMFA(t, gs, "10001")
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	for i := 0; i < 2; i++ {
+		if _, err := gs.VerifyMFA(ctx, resp.MFAToken, "bad-code"); !errors.Is(err, core.ErrMFACodeInvalid) {
+			t.Fatalf("attempt %d: expected ErrMFACodeInvalid, got %v", i+1, err)
+		}
+	}
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, totpAt(t, enrollment.Secret, 1)); !errors.Is(err, core.ErrMFATokenInvalid) {
+		t.Errorf("pending token should be invalid after max attempts, got %v", err)
+	}
+}
+
+// TestMFARecoveryCodeConcurrentUse 验证同一恢复码在多个待验证Token上并发提交时只有一个请求成功
+func TestMFARecoveryCodeConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+	enrollment := enrollMFA(t, gs, "10001")
+
+	tokens := make([]string, 4)
+	for i := range tokens {
+		resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		tokens[i] = resp.MFAToken
+	}
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int64
+	for _, mfaToken := range tokens {
+		wg.Add(1)
+		go func(mfaToken string) {
+			defer wg.Done()
+			if _, err := gs.VerifyMFA(ctx, mfaToken, enrollment.RecoveryCodes[0]); err == nil {
+				succeeded.Add(1)
+			}
+		}(mfaToken)
+	}
+	wg.Wait()
+
+	if n := succeeded.Load(); n != 1 {
+		t.Errorf("expected exactly one login with the recovery code, got %d", n)
+	}
+}
+
+// TestMFAUserLockout 验证验证码错误次数跨待验证Token累计，重新登录不清零，达到上限后锁定用户
+func TestMFAUserLockout(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := gstoken.New(config.NewBuilder().
+		WithMFA(core.MFAConfig{MaxAttempts: 2, MaxUserAttempts: 3, LockDuration: time.Minute}).
+		WithSecurityEventListener(listener).
+		Build())
+	enrollment := enrollMFA(t, gs, "10001")
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	for i := 0; i < 2; i++ {
+		gs.VerifyMFA(ctx, resp.MFAToken, "bad-code")
+	}
+
+	// 重新登录获得新的待验证Token，错误次数继续累计
+	resp, _ = gs.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	_, err := gs.VerifyMFA(ctx, resp.MFAToken, "bad-code")
+	var locked *core.TooManyAttemptsError
+	if !errors.As(err, &locked) || locked.Kind != core.LoginAttemptKindMFA || locked.Subject != "10001" {
+		t.Fatalf("expected mfa lock, got %v", err)
+	}
+	if listener.count(core.SecurityEventLoginLocked) != 1 {
+		t.Errorf("expected one login_locked event, got %d", listener.count(core.SecurityEventLoginLocked))
+	}
+	if _, err := gs.VerifyMFA(ctx, resp.MFAToken, totpAt(t, enrollment.Secret, 1)); !errors.Is(err, core.ErrTooManyAttempts) {
+		t.Errorf("locked user should be rejected even with a valid code, got %v", err)
+	}
+}
+
+// TestMFAStorageFailure 验证读取绑定记录失败时拒绝登录而不是跳过多因素认证
+func TestMFAStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().Build()
+	keyService := core.NewKeyService(cfg.KeyPrefix)
+	store := &failingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: keyService.MFAKey("10001")}
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), keyService)
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if err == nil {
+		t.Fatalf("login should fail when mfa record cannot be read, got %+v", resp)
+	}
+}
+
+// TestMFADigitsValidation 验证超出 6~8 位的验证码位数在配置校验时被拒绝
+func TestMFADigitsValidation(t *testing.T) {
+	for _, digits := range []int{5, 9, 10} {
+		cfg := config.NewBuilder().WithMFA(core.MFAConfig{Digits: digits}).Build()
+		if err := cfg.Validate(); !errors.Is(err, core.ErrConfigInvalid) {
+			t.Errorf("digits=%d: expected ErrConfigInvalid, got %v", digits, err)
+		}
+		if _, err := auth.TOTPCode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", time.Now(), digits, 30*time.Second); !errors.Is(err, core.ErrConfigInvalid) {
+			t.Errorf("digits=%d: TOTPCode expected ErrConfigInvalid, got %v", digits, err)
+		}
+	}
+	for _, digits := range []int{0, 6, 7, 8} {
+		cfg := config.NewBuilder().WithMFA(core.MFAConfig{Digits: digits}).Build()
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("digits=%d: unexpected error %v", digits, err)
+		}
+	}
+}
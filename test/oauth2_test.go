@@ -0,0 +1,428 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/oauth2"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+	"github.com/luckxgo/gstoken/web"
+)
+
+const oauthRedirectURI = "https://app.example.com/callback"
+
+// newOAuthServer 创建启用 OAuth2 的 GSToken，授权端点从 X-Test-User 头读取登录用户
+func newOAuthServer(cfg *oauth2.Config) (*gstoken.GSToken, *oauth2.Server, *http.ServeMux) {
+	provider := newRP()
+	provider.users["10001"] = []string{"editor"}
+	provider.perms["editor"] = []string{"report:read", "report:write"}
+
+	gs := gstoken.New(config.NewBuilder().WithUserRoleProvider(provider).Build())
+	if cfg == nil {
+		cfg = &oauth2.Config{AutoApprove: true}
+	}
+	cfg.CurrentUser = func(r *http.Request) string { return r.Header.Get("X-Test-User") }
+	server := gs.EnableOAuth2(cfg)
+
+	mux := http.NewServeMux()
+	server.Register(mux, "/oauth")
+	return gs, server, mux
+}
+
+// pkcePair 生成 code_verifier 与 S256 code_challenge
+func pkcePair(verifier string) (string, string) {
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize 请求授权端点，返回重定向地址
+func authorize(t *testing.T, mux *http.ServeMux, user string, params url.Values) *url.URL {
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d: %s", w.Code, w.Body.String())
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid location: %v", err)
+	}
+	return location
+}
+
+// requestToken 请求令牌端点，返回状态码与响应
+func requestToken(mux http.Handler, form url.Values, clientID, secret string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if secret != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+// requestRevoke 请求吊销端点，返回状态码
+func requestRevoke(h http.Handler, tk, clientID, secret string) int {
+	req := httptest.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader(url.Values{"token": {tk}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, secret)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+// TestOAuth2AuthorizationCodePKCE 验证授权码 + PKCE 流程、授权码一次性、刷新Token轮换与 scope 绑定
+func TestOAuth2AuthorizationCodePKCE(t *testing.T) {
+	ctx := context.Background()
+	gs, server, mux := newOAuthServer(nil)
+	client := &oauth2.Client{Name: "spa", Public: true, RedirectURIs: []string{oauthRedirectURI}, Scopes: []string{"report:read", "report:write"}}
+	if secret, err := server.Clients().Register(ctx, client); err != nil || secret != "" || client.ID == "" {
+		t.Fatalf("register public client failed: %q %v", secret, err)
+	}
+
+	verifier, challenge := pkcePair("a-long-random-code-verifier-0123456789abcdef")
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ID},
+		"redirect_uri":          {oauthRedirectURI},
+		"scope":                 {"report:read"},
+		"state":                 {"xyz"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {oauth2.PKCEMethodS256},
+	}
+
+	// 错误的 code_verifier 被拒绝
+	location := authorize(t, mux, "10001", params)
+	code, _ := requestToken(mux, url.Values{"grant_type": {"authorization_code"}, "client_id": {client.ID}, "code": {location.Query().Get("code")}, "redirect_uri": {oauthRedirectURI}, "code_verifier": {"wrong"}}, "", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("wrong verifier should be rejected, got %d", code)
+	}
+
+	location = authorize(t, mux, "10001", params)
+	if location.Query().Get("state") != "xyz" || !strings.HasPrefix(location.String(), oauthRedirectURI) {
+		t.Fatalf("unexpected redirect: %s", location)
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID},
+		"code":          {location.Query().Get("code")},
+		"redirect_uri":  {oauthRedirectURI},
+		"code_verifier": {verifier},
+	}
+	status, body := requestToken(mux, form, "", "")
+	if status != http.StatusOK || body["token_type"] != oauth2.TokenTypeBearer || body["scope"] != "report:read" || body["refresh_token"] == nil {
+		t.Fatalf("unexpected token response: %d %v", status, body)
+	}
+	accessToken := body["access_token"].(string)
+	refreshToken := body["refresh_token"].(string)
+
+	// 授权码只能使用一次
+	if status, body := requestToken(mux, form, "", ""); status != http.StatusBadRequest || body["error"] != oauth2.ErrorInvalidGrant {
+		t.Errorf("reused code should be invalid_grant, got %d %v", status, body)
+	}
+
+	info, err := gs.VerifyAccessToken(ctx, accessToken)
+	if err != nil || info.ID != "10001" || info.ClientID != client.ID || len(info.Scopes) != 1 {
+		t.Fatalf("unexpected access token info: %+v %v", info, err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, accessToken); err == nil {
+		t.Error("oauth token should not be accepted as login session")
+	}
+
+	// 访问Token受 scope 限制
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	authConfig := web.DefaultAuthConfig()
+	authConfig.AcceptOAuth2Token = true
+	auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), authConfig)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/reports", auth.RequirePermission("report:read"), ok)
+	r.POST("/reports", auth.RequirePermission("report:write"), ok)
+	if w := doReqMixed(r, http.MethodGet, "/reports", accessToken); w.Code != http.StatusOK {
+		t.Errorf("scoped permission should pass, got %d", w.Code)
+	}
+	if w := doReqMixed(r, http.MethodPost, "/reports", accessToken); w.Code != http.StatusForbidden {
+		t.Errorf("permission outside scope should be forbidden, got %d", w.Code)
+	}
+
+	// 刷新Token轮换，旧刷新Token失效
+	refresh := url.Values{"grant_type": {"refresh_token"}, "client_id": {client.ID}, "refresh_token": {refreshToken}}
+	status, body = requestToken(mux, refresh, "", "")
+	if status != http.StatusOK || body["refresh_token"] == refreshToken || body["access_token"] == accessToken {
+		t.Fatalf("refresh failed: %d %v", status, body)
+	}
+	if status, body := requestToken(mux, refresh, "", ""); status != http.StatusBadRequest || body["error"] != oauth2.ErrorInvalidGrant {
+		t.Errorf("rotated refresh token should be invalid_grant, got %d %v", status, body)
+	}
+
+	// 只能吊销签发给自己的Token
+	other := &oauth2.Client{Public: true, RedirectURIs: []string{oauthRedirectURI}}
+	server.Clients().Register(ctx, other)
+	if err := server.Revoke(ctx, &oauth2.RevokeRequest{ClientID: other.ID, Token: body["access_token"].(string)}); !errors.Is(err, core.ErrOAuthUnauthorizedClient) {
+		t.Errorf("revoking another client's token should be unauthorized_client, got %v", err)
+	}
+	if _, err := gs.VerifyAccessToken(ctx, body["access_token"].(string)); err != nil {
+		t.Fatalf("token should survive foreign revoke: %v", err)
+	}
+	if err := server.Revoke(ctx, &oauth2.RevokeRequest{ClientID: client.ID, Token: body["access_token"].(string)}); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if _, err := gs.VerifyAccessToken(ctx, body["access_token"].(string)); !errors.Is(err, core.ErrOAuthTokenInvalid) {
+		t.Errorf("revoked token should be invalid, got %v", err)
+	}
+}
+
+// TestOAuth2AuthorizeErrors 验证授权端点的登录跳转、回调地址校验、PKCE 要求、scope 校验与拒绝授权
+func TestOAuth2AuthorizeErrors(t *testing.T) {
+	ctx := context.Background()
+	denied := false
+	_, server, mux := newOAuthServer(&oauth2.Config{
+		LoginURL: "/login",
+		Consent: func(w http.ResponseWriter, r *http.Request, req *oauth2.AuthorizeRequest, userID string) ([]string, bool, error) {
+			if denied {
+				return nil, false, core.ErrOAuthAccessDenied
+			}
+			return req.Scopes, true, nil
+		},
+	})
+	client := &oauth2.Client{Public: true, RedirectURIs: []string{oauthRedirectURI}, Scopes: []string{"report:read"}}
+	server.Clients().Register(ctx, client)
+	_, challenge := pkcePair("another-long-random-code-verifier-0123456789")
+
+	base := func() url.Values {
+		return url.Values{"response_type": {"code"}, "client_id": {client.ID}, "state": {"s1"}, "code_challenge": {challenge}, "code_challenge_method": {"S256"}}
+	}
+
+	// 未登录跳转登录页
+	location := authorize(t, mux, "", base())
+	if location.Path != "/login" || !strings.HasPrefix(location.Query().Get("return_to"), "/oauth/authorize?") {
+		t.Errorf("expected login redirect, got %s", location)
+	}
+
+	// 未注册的回调地址不重定向
+	params := base()
+	params.Set("redirect_uri", "https://evil.example.com/cb")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil))
+	if w.Code != http.StatusBadRequest || w.Header().Get("Location") != "" {
+		t.Errorf("unregistered redirect uri should not redirect, got %d", w.Code)
+	}
+
+	errorOf := func(params url.Values) string {
+		location := authorize(t, mux, "10001", params)
+		if location.Query().Get("state") != "s1" {
+			t.Errorf("state should be returned: %s", location)
+		}
+		return location.Query().Get("error")
+	}
+
+	params = base()
+	params.Del("code_challenge")
+	if got := errorOf(params); got != oauth2.ErrorInvalidRequest {
+		t.Errorf("public client without pkce: expected invalid_request, got %s", got)
+	}
+	params = base()
+	params.Set("code_challenge_method", oauth2.PKCEMethodPlain)
+	if got := errorOf(params); got != oauth2.ErrorInvalidRequest {
+		t.Errorf("public client with plain pkce: expected invalid_request, got %s", got)
+	}
+	params = base()
+	params.Del("code_challenge_method")
+	if got := errorOf(params); got != oauth2.ErrorInvalidRequest {
+		t.Errorf("public client without code_challenge_method: expected invalid_request, got %s", got)
+	}
+	params = base()
+	params.Set("scope", "report:write")
+	if got := errorOf(params); got != oauth2.ErrorInvalidScope {
+		t.Errorf("expected invalid_scope, got %s", got)
+	}
+	params = base()
+	params.Set("response_type", "token")
+	if got := errorOf(params); got != oauth2.ErrorUnsupportedResponseType {
+		t.Errorf("expected unsupported_response_type, got %s", got)
+	}
+	denied = true
+	if got := errorOf(base()); got != oauth2.ErrorAccessDenied {
+		t.Errorf("expected access_denied, got %s", got)
+	}
+}
+
+// TestOAuth2ClientCredentialsGin 验证 Gin 挂载的令牌端点、客户端凭证模式与客户端认证
+func TestOAuth2ClientCredentialsGin(t *testing.T) {
+	ctx := context.Background()
+	gs, server, _ := newOAuthServer(nil)
+
+	if _, err := server.Clients().Register(ctx, &oauth2.Client{Public: true, GrantTypes: []string{oauth2.GrantTypeClientCredentials}}); err == nil {
+		t.Error("public client should not use client_credentials")
+	}
+	client := &oauth2.Client{Name: "worker", GrantTypes: []string{oauth2.GrantTypeClientCredentials}, Scopes: []string{"report:read"}}
+	secret, err := server.Clients().Register(ctx, client)
+	if err != nil || secret == "" {
+		t.Fatalf("register confidential client failed: %v", err)
+	}
+	if stored, _ := server.Clients().Get(ctx, client.ID); stored.SecretHash != "" {
+		t.Error("secret hash should not be exposed")
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/oauth/token", server.GinTokenHandler())
+	r.POST("/oauth/revoke", server.GinRevokeHandler())
+
+	form := url.Values{"grant_type": {oauth2.GrantTypeClientCredentials}}
+	status, body := requestToken(r, form, client.ID, secret)
+	if status != http.StatusOK || body["refresh_token"] != nil || body["scope"] != "report:read" {
+		t.Fatalf("unexpected client credentials response: %d %v", status, body)
+	}
+	if info, err := gs.VerifyAccessToken(ctx, body["access_token"].(string)); err != nil || info.ID != oauth2.ClientSubjectPrefix+client.ID || info.ClientID != client.ID {
+		t.Errorf("client credentials token should act as client: %+v %v", info, err)
+	}
+
+	if status, body := requestToken(r, form, client.ID, "wrong"); status != http.StatusUnauthorized || body["error"] != oauth2.ErrorInvalidClient {
+		t.Errorf("wrong secret should be invalid_client, got %d %v", status, body)
+	}
+
+	// 吊销端点需认证客户端
+	accessToken := body["access_token"].(string)
+	if status := requestRevoke(r, accessToken, client.ID, "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("revoke with wrong secret should be 401, got %d", status)
+	}
+	if _, err := gs.VerifyAccessToken(ctx, accessToken); err != nil {
+		t.Fatalf("token should survive unauthenticated revoke: %v", err)
+	}
+	if status := requestRevoke(r, accessToken, client.ID, secret); status != http.StatusOK {
+		t.Errorf("revoke failed: %d", status)
+	}
+	if _, err := gs.VerifyAccessToken(ctx, accessToken); !errors.Is(err, core.ErrOAuthTokenInvalid) {
+		t.Errorf("revoked token should be invalid, got %v", err)
+	}
+	if status, body := requestToken(r, url.Values{"grant_type": {oauth2.GrantTypeAuthorizationCode}, "code": {"x"}}, client.ID, secret); status != http.StatusBadRequest || body["error"] != oauth2.ErrorUnauthorizedClient {
+		t.Errorf("disallowed grant should be unauthorized_client, got %d %v", status, body)
+	}
+	if status, body := requestToken(r, url.Values{"grant_type": {"password"}}, client.ID, secret); status != http.StatusBadRequest || body["error"] != oauth2.ErrorUnsupportedGrantType {
+		t.Errorf("unknown grant should be unsupported_grant_type, got %d %v", status, body)
+	}
+	form.Set("scope", "report:write")
+	if status, body := requestToken(r, form, client.ID, secret); status != http.StatusBadRequest || body["error"] != oauth2.ErrorInvalidScope {
+		t.Errorf("scope outside client should be invalid_scope, got %d %v", status, body)
+	}
+}
+
+// TestOAuth2SafeDefaults 验证未配置授权确认时拒绝授权、客户端ID不能重复注册、中间件默认不接受访问Token且受限Token不能通过角色检查
+func TestOAuth2SafeDefaults(t *testing.T) {
+	ctx := context.Background()
+	gs, server, mux := newOAuthServer(&oauth2.Config{})
+	client := &oauth2.Client{ID: "reports", RedirectURIs: []string{oauthRedirectURI}, Scopes: []string{"report:read"}}
+	secret, err := server.Clients().Register(ctx, client)
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if _, err := server.Clients().Register(ctx, &oauth2.Client{ID: "reports", RedirectURIs: []string{"https://evil.example.com/cb"}}); !errors.Is(err, core.ErrOAuthClientExists) {
+		t.Errorf("duplicate client id should be rejected, got %v", err)
+	}
+	if _, err := server.Clients().Authenticate(ctx, client.ID, secret); err != nil {
+		t.Errorf("original client should be kept: %v", err)
+	}
+
+	// 未配置 Consent 且未开启 AutoApprove 时拒绝授权
+	params := url.Values{"response_type": {"code"}, "client_id": {client.ID}, "state": {"s1"}}
+	if got := authorize(t, mux, "10001", params).Query().Get("error"); got != oauth2.ErrorAccessDenied {
+		t.Errorf("authorize without consent should be access_denied, got %s", got)
+	}
+
+	// 用户 10001 具有 editor 角色，访问Token只授予 report:read
+	req, err := server.ParseAuthorizeRequest(ctx, params)
+	if err != nil {
+		t.Fatalf("parse authorize request failed: %v", err)
+	}
+	location, err := server.Authorize(ctx, req, "10001", nil)
+	if err != nil {
+		t.Fatalf("authorize failed: %v", err)
+	}
+	codeURL, _ := url.Parse(location)
+	status, body := requestToken(mux, url.Values{"grant_type": {"authorization_code"}, "code": {codeURL.Query().Get("code")}}, client.ID, secret)
+	if status != http.StatusOK {
+		t.Fatalf("token request failed: %d %v", status, body)
+	}
+	accessToken := body["access_token"].(string)
+
+	gin.SetMode(gin.TestMode)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	newRouter := func(authConfig *web.AuthConfig) *gin.Engine {
+		r := gin.New()
+		auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), authConfig)
+		r.GET("/me", auth.RequireAuth(), ok)
+		r.GET("/reports", auth.RequirePermission("report:read"), ok)
+		r.GET("/editor", auth.RequireRole("editor"), ok)
+		r.GET("/any", auth.RequireAnyRole("editor"), ok)
+		r.GET("/either", auth.RequireRoleOrPermission([]string{"editor"}, []string{"report:write"}), ok)
+		return r
+	}
+
+	r := newRouter(nil)
+	for _, path := range []string{"/me", "/reports"} {
+		if w := doReqMixed(r, http.MethodGet, path, accessToken); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: oauth token should be rejected by default, got %d", path, w.Code)
+		}
+	}
+
+	authConfig := web.DefaultAuthConfig()
+	authConfig.AcceptOAuth2Token = true
+	r = newRouter(authConfig)
+	for path, want := range map[string]int{"/me": http.StatusOK, "/reports": http.StatusOK, "/editor": http.StatusForbidden, "/any": http.StatusForbidden, "/either": http.StatusForbidden} {
+		if w := doReqMixed(r, http.MethodGet, path, accessToken); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+// TestOAuth2StorageFailure 验证读取授权记录或客户端失败时返回存储错误而不是视为不存在
+func TestOAuth2StorageFailure(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().Build()
+	keyService := core.NewKeyService("gstoken")
+	store := &failingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: "none:"}
+	server := oauth2.NewServer(store, keyService, token.NewGeneratorWithConfig(cfg), nil)
+
+	client := &oauth2.Client{ID: "worker", GrantTypes: []string{oauth2.GrantTypeClientCredentials}, Scopes: []string{"report:read"}}
+	secret, err := server.Clients().Register(ctx, client)
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	resp, err := server.Token(ctx, &oauth2.TokenRequest{GrantType: oauth2.GrantTypeClientCredentials, ClientID: client.ID, ClientSecret: secret})
+	if err != nil {
+		t.Fatalf("client credentials failed: %v", err)
+	}
+
+	store.prefix = keyService.OAuthAccessTokenKey("")
+	if _, err := server.VerifyAccessToken(ctx, resp.AccessToken); err == nil || errors.Is(err, core.ErrOAuthTokenInvalid) {
+		t.Errorf("storage failure should not be treated as invalid token, got %v", err)
+	}
+
+	store.prefix = keyService.OAuthClientKey("")
+	if _, err := server.Clients().Authenticate(ctx, client.ID, secret); err == nil || errors.Is(err, core.ErrOAuthInvalidClient) {
+		t.Errorf("storage failure should not be treated as unknown client, got %v", err)
+	}
+	if _, err := server.Clients().Register(ctx, &oauth2.Client{ID: "another", GrantTypes: []string{oauth2.GrantTypeClientCredentials}}); err == nil {
+		t.Error("register should fail when existence check fails")
+	}
+}
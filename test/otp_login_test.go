@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// mapResolver 基于映射的登录标识解析器
+type mapResolver map[string]string
+
+func (r mapResolver) ResolveIdentifier(ctx context.Context, identifier string) (string, error) {
+	if userID, ok := r[identifier]; ok {
+		return userID, nil
+	}
+	return "", core.ErrUserNotFound
+}
+
+// newOTPGSToken 创建配置了内存验证码发送器的 GSToken
+func newOTPGSToken(otp core.OTPConfig) (*gstoken.GSToken, *auth.MemoryCodeSender) {
+	sender := auth.NewMemoryCodeSender()
+	gs := gstoken.New(config.NewBuilder().
+		WithOTP(otp).
+		WithCodeSender(sender, mapResolver{"alice@example.com": "10001"}).
+		Build())
+	return gs, sender
+}
+
+// TestOTPLogin 验证验证码登录签发Token，验证码只能使用一次
+func TestOTPLogin(t *testing.T) {
+	ctx := context.Background()
+	gs, sender := newOTPGSToken(core.OTPConfig{})
+
+	if err := gs.RequestLoginCode(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("request code failed: %v", err)
+	}
+	code, ok := sender.LastCode("alice@example.com")
+	if !ok || len(code) != 6 {
+		t.Fatalf("expected 6-digit code to be sent, got %q", code)
+	}
+
+	req := &core.OTPLoginRequest{Identifier: "alice@example.com", Code: code, Device: "mobile"}
+	resp, err := gs.LoginWithCode(ctx, req)
+	if err != nil {
+		t.Fatalf("login with code failed: %v", err)
+	}
+	if info, err := gs.GetLoginInfo(ctx, resp.Token); err != nil || info.UserID != "10001" || info.Device != "mobile" {
+		t.Errorf("token should belong to resolved user: %+v %v", info, err)
+	}
+
+	if _, err := gs.LoginWithCode(ctx, req); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Errorf("used code should be rejected, got %v", err)
+	}
+}
+
+// TestOTPUnknownIdentifier 验证未注册的标识不发送验证码，但请求同样成功
+func TestOTPUnknownIdentifier(t *testing.T) {
+	ctx := context.Background()
+	gs, sender := newOTPGSToken(core.OTPConfig{})
+
+	if err := gs.RequestLoginCode(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("request for unknown identifier should not fail: %v", err)
+	}
+	if sender.SentCount() != 0 {
+		t.Error("code should not be sent to unknown identifier")
+	}
+	if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "nobody@example.com", Code: "123456"}); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Errorf("expected ErrOTPInvalid, got %v", err)
+	}
+}
+
+// TestOTPAttemptLimitAndExpiry 验证错误次数达到上限或过期后验证码失效
+func TestOTPAttemptLimitAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	gs, sender := newOTPGSToken(core.OTPConfig{MaxAttempts: 2, TTL: 100 * time.Millisecond, ResendInterval: time.Millisecond})
+
+	gs.RequestLoginCode(ctx, "alice@example.com")
+	code, _ := sender.LastCode("alice@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: wrong}); !errors.Is(err, core.ErrOTPInvalid) {
+			t.Fatalf("attempt %d: expected ErrOTPInvalid, got %v", i+1, err)
+		}
+	}
+	if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: code}); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Errorf("code should be invalid after max attempts, got %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	gs.RequestLoginCode(ctx, "alice@example.com")
+	code, _ = sender.LastCode("alice@example.com")
+	time.Sleep(120 * time.Millisecond)
+	if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: code}); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Errorf("expired code should be rejected, got %v", err)
+	}
+}
+
+// TestOTPSendRateLimit 验证重发间隔与窗口内发送次数限制
+func TestOTPSendRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	gs, _ := newOTPGSToken(core.OTPConfig{ResendInterval: time.Minute})
+	gs.RequestLoginCode(ctx, "alice@example.com")
+	var limited *core.TooManyAttemptsError
+	if err := gs.RequestLoginCode(ctx, "alice@example.com"); !errors.As(err, &limited) {
+		t.Fatalf("expected resend interval limit, got %v", err)
+	}
+	if limited.Kind != core.LoginAttemptKindOTP || limited.RetryAfter <= 0 || limited.RetryAfter > time.Minute {
+		t.Errorf("unexpected rate limit error: %+v", limited)
+	}
+
+	gs, sender := newOTPGSToken(core.OTPConfig{ResendInterval: time.Millisecond, MaxSends: 2, SendWindow: time.Hour})
+	codes := make([]string, 2)
+	for i := range codes {
+		if err := gs.RequestLoginCode(ctx, "alice@example.com"); err != nil {
+			t.Fatalf("send %d failed: %v", i+1, err)
+		}
+		codes[i], _ = sender.LastCode("alice@example.com")
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := gs.RequestLoginCode(ctx, "alice@example.com"); !errors.As(err, &limited) || limited.RetryAfter < 59*time.Minute {
+		t.Errorf("expected send window limit, got %v", err)
+	}
+	if sender.SentCount() != 2 {
+		t.Errorf("expected 2 codes sent, got %d", sender.SentCount())
+	}
+
+	// 新验证码使之前的验证码失效
+	if codes[0] != codes[1] {
+		if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: codes[0]}); !errors.Is(err, core.ErrOTPInvalid) {
+			t.Errorf("previous code should be replaced, got %v", err)
+		}
+	}
+	if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: codes[1]}); err != nil {
+		t.Errorf("latest code should work: %v", err)
+	}
+}
+
+// TestOTPWithoutSender 验证未配置发送器时不能请求验证码
+func TestOTPWithoutSender(t *testing.T) {
+	gs := gstoken.New(config.NewBuilder().Build())
+	if err := gs.RequestLoginCode(context.Background(), "alice@example.com"); err == nil {
+		t.Error("request code without sender should fail")
+	}
+}
+
+// TestOTPConcurrentGuesses 验证并发猜测时错误次数不会少计：超出上限后正确验证码同样被拒绝，并发请求只发送一次验证码
+func TestOTPConcurrentGuesses(t *testing.T) {
+	ctx := context.Background()
+	gs, sender := newOTPGSToken(core.OTPConfig{MaxAttempts: 5, ResendInterval: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.RequestLoginCode(ctx, "alice@example.com")
+		}()
+	}
+	wg.Wait()
+	if sender.SentCount() != 1 {
+		t.Fatalf("expected exactly one code sent, got %d", sender.SentCount())
+	}
+
+	code, _ := sender.LastCode("alice@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: wrong})
+		}()
+	}
+	wg.Wait()
+
+	if _, err := gs.LoginWithCode(ctx, &core.OTPLoginRequest{Identifier: "alice@example.com", Code: code}); !errors.Is(err, core.ErrOTPInvalid) {
+		t.Errorf("code should be invalid after concurrent failures, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// newLocalKey 生成 v4.local 对称密钥
+func newLocalKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// TestPasetoLocal 验证 v4.local 加密Token的签发与解析
+func TestPasetoLocal(t *testing.T) {
+	key := newLocalKey(t)
+	cfg := config.NewBuilder().
+		WithPasetoLocal(key).
+		WithPasetoAssertions(`{"kid":"k1"}`, "tenant-acme").
+		WithTokenExpire(time.Hour).
+		Build()
+	gen := token.NewGeneratorWithConfig(cfg)
+
+	tk, err := gen.Generate(map[string]interface{}{
+		core.TokenExtraKeyUserID: "paseto_user",
+		"secret_note":            "not visible",
+	})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.HasPrefix(tk, "v4.local.") {
+		t.Fatalf("unexpected header: %s", tk)
+	}
+	if strings.Contains(tk, "paseto_user") {
+		t.Fatal("v4.local payload must be encrypted")
+	}
+
+	info, err := gen.Parse(tk)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if info.UserID != "paseto_user" || info.Extra["secret_note"] != "not visible" {
+		t.Errorf("claims mismatch: %+v", info)
+	}
+	if info.Footer != `{"kid":"k1"}` {
+		t.Errorf("footer mismatch: %q", info.Footer)
+	}
+	if info.ID == "" {
+		t.Error("jti should be set")
+	}
+
+	// 隐式断言不一致时校验失败
+	otherCfg := *cfg
+	otherCfg.Paseto.ImplicitAssertion = "tenant-other"
+	if _, err := token.NewGeneratorWithConfig(&otherCfg).Parse(tk); !errors.Is(err, core.ErrTokenSignatureInvalid) {
+		t.Errorf("implicit assertion mismatch should fail, got %v", err)
+	}
+
+	// 错误密钥无法解密
+	otherCfg = *cfg
+	otherCfg.Paseto.LocalKey = newLocalKey(t)
+	if _, err := token.NewGeneratorWithConfig(&otherCfg).Parse(tk); err == nil {
+		t.Error("wrong key must fail")
+	}
+}
+
+// TestPasetoPublic 验证 v4.public 签名Token与公钥校验
+func TestPasetoPublic(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	issuer := token.NewGeneratorWithConfig(config.NewBuilder().
+		WithPasetoPublic(priv, nil).
+		WithPasetoAssertions("", "svc-orders").
+		Build())
+	verifier := token.NewGeneratorWithConfig(config.NewBuilder().
+		WithPasetoPublic(nil, pub).
+		WithPasetoAssertions("", "svc-orders").
+		Build())
+
+	tk, err := issuer.Generate(map[string]interface{}{core.TokenExtraKeyUserID: "pub_user"})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.HasPrefix(tk, "v4.public.") {
+		t.Fatalf("unexpected header: %s", tk)
+	}
+
+	info, err := verifier.Parse(tk)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if info.UserID != "pub_user" {
+		t.Errorf("user id mismatch: %s", info.UserID)
+	}
+
+	// 篡改签名
+	forged := tk[:len(tk)-4] + "AAAA"
+	if _, err := verifier.Parse(forged); err == nil {
+		t.Error("forged token must fail")
+	}
+
+	// 跨版本头部不被接受
+	if _, err := verifier.Parse(strings.Replace(tk, "v4.public.", "v4.local.", 1)); err == nil {
+		t.Error("header mismatch must fail")
+	}
+}
+
+// TestPasetoStatelessEngine 验证 PASETO 风格可用于无状态校验
+func TestPasetoStatelessEngine(t *testing.T) {
+	key := newLocalKey(t)
+	ctx := context.Background()
+
+	issuer := gstoken.New(config.NewBuilder().WithPasetoLocal(key).Build())
+	resp, err := issuer.Login(ctx, &core.LoginRequest{UserID: "paseto_edge", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	edge := gstoken.New(config.NewBuilder().
+		WithPasetoLocal(key).
+		WithVerifyMode(core.VerifyStateless).
+		Build())
+	userInfo, err := edge.GetAuthEngine().Verify(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("stateless verify failed: %v", err)
+	}
+	if userInfo.ID != "paseto_edge" {
+		t.Errorf("user id mismatch: %s", userInfo.ID)
+	}
+}
+
+// PASETO v4 官方测试向量（paseto-standard/test-vectors v4.json）
+const (
+	pasetoVectorLocalKey  = "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f"
+	pasetoVectorSecretKey = "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+	pasetoVectorPublicKey = "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+	pasetoVectorFooter    = `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`
+)
+
+var pasetoVectors = []struct {
+	name     string
+	public   bool
+	token    string
+	data     string
+	footer   string
+	implicit string
+}{
+	{"4-E-1", false, "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg", "this is a secret message", "", ""},
+	{"4-E-2", false, "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A", "this is a hidden message", "", ""},
+	{"4-E-3", false, "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6-tyebyWG6Ov7kKvBdkrrAJ837lKP3iDag2hzUPHuMKA", "this is a secret message", "", ""},
+	{"4-E-4", false, "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4gt6TiLm55vIH8c_lGxxZpE3AWlH4WTR0v45nsWoU3gQ", "this is a hidden message", "", ""},
+	{"4-E-5", false, "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9", "this is a secret message", pasetoVectorFooter, ""},
+	{"4-E-6", false, "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6pWSA5HX2wjb3P-xLQg5K5feUCX4P2fpVK3ZLWFbMSxQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9", "this is a hidden message", pasetoVectorFooter, ""},
+	{"4-E-7", false, "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t40KCCWLA7GYL9KFHzKlwY9_RnIfRrMQpueydLEAZGGcA.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9", "this is a secret message", pasetoVectorFooter, `{"test-vector":"4-E-7"}`},
+	{"4-S-1", true, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA", "this is a signed message", "", ""},
+	{"4-S-2", true, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9v3Jt8mx_TdM2ceTGoqwrh4yDFn0XsHvvV_D0DtwQxVrJEBMl0F2caAdgnpKlt4p7xBnx1HcO-SPo8FPp214HDw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9", "this is a signed message", pasetoVectorFooter, ""},
+	{"4-S-3", true, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9NPWciuD3d0o5eXJXG5pJy-DiVEoyPYWs1YSTwWHNJq6DZD3je5gf-0M4JR9ipdUSJbIovzmBECeaWmaqcaP0DQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9", "this is a signed message", pasetoVectorFooter, `{"test-vector":"4-S-3"}`},
+}
+
+// TestPasetoOfficialVectors 使用官方测试向量验证与其他实现的互通：官方Token可被解密或验签，
+// 且页脚与隐式断言按规范参与认证
+func TestPasetoOfficialVectors(t *testing.T) {
+	localKey, _ := hex.DecodeString(pasetoVectorLocalKey)
+	secretKey, _ := hex.DecodeString(pasetoVectorSecretKey)
+	publicKey, _ := hex.DecodeString(pasetoVectorPublicKey)
+
+	for _, v := range pasetoVectors {
+		t.Run(v.name, func(t *testing.T) {
+			builder := config.NewBuilder().WithPasetoAssertions(v.footer, v.implicit)
+			if v.public {
+				builder = builder.WithPasetoPublic(nil, ed25519.PublicKey(publicKey))
+			} else {
+				builder = builder.WithPasetoLocal(localKey)
+			}
+			cfg := builder.Build()
+			// 向量的 exp 为 2022-01-01，放宽时间容差以校验密码学部分
+			cfg.Paseto.Leeway = time.Since(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) + time.Hour
+
+			info, err := token.NewGeneratorWithConfig(cfg).Parse(v.token)
+			if err != nil {
+				t.Fatalf("parse official vector failed: %v", err)
+			}
+			if info.Extra["data"] != v.data || info.Footer != v.footer {
+				t.Errorf("claims mismatch: %+v", info)
+			}
+			if !info.ExpireTime.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("exp mismatch: %v", info.ExpireTime)
+			}
+
+			// 篡改任意载荷字节均无法通过认证
+			tampered := []byte(v.token)
+			tampered[len("v4.local.")+10] ^= 1
+			if _, err := token.NewGeneratorWithConfig(cfg).Parse(string(tampered)); err == nil {
+				t.Error("tampered vector must fail")
+			}
+			if v.public {
+				return
+			}
+
+			// 隐式断言参与认证
+			other := *cfg
+			other.Paseto.ImplicitAssertion = "other"
+			if _, err := token.NewGeneratorWithConfig(&other).Parse(v.token); !errors.Is(err, core.ErrTokenSignatureInvalid) {
+				t.Errorf("implicit assertion mismatch should fail, got %v", err)
+			}
+		})
+	}
+
+	// 过期的官方向量在默认容差下被拒绝
+	cfg := config.NewBuilder().WithPasetoPublic(ed25519.PrivateKey(secretKey), nil).Build()
+	if _, err := token.NewGeneratorWithConfig(cfg).Parse(pasetoVectors[7].token); !errors.Is(err, core.ErrTokenExpired) {
+		t.Errorf("expired vector should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memCredentialStore 内存凭证存储
+type memCredentialStore struct {
+	mu      sync.Mutex
+	users   map[string]string // 登录名 -> 用户ID
+	hashes  map[string]string // 用户ID -> 密码哈希
+	updates int
+}
+
+func newMemCredentialStore() *memCredentialStore {
+	return &memCredentialStore{users: make(map[string]string), hashes: make(map[string]string)}
+}
+
+func (s *memCredentialStore) add(username, userID, hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = userID
+	s.hashes[userID] = hash
+}
+
+func (s *memCredentialStore) GetPasswordHash(ctx context.Context, username string) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.users[username]
+	if !ok {
+		return "", "", core.ErrUserNotFound
+	}
+	return userID, s.hashes[userID], nil
+}
+
+func (s *memCredentialStore) UpdatePasswordHash(ctx context.Context, userID, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes[userID] = hash
+	s.updates++
+	return nil
+}
+
+// testArgon2idParams 测试用的低开销 argon2id 参数
+func testArgon2idParams() auth.Argon2idParams {
+	params := auth.DefaultArgon2idParams()
+	params.Memory = 1024
+	params.Iterations = 1
+	return params
+}
+
+// TestPasswordLogin 验证密码登录校验凭证后签发Token
+func TestPasswordLogin(t *testing.T) {
+	ctx := context.Background()
+	hasher := auth.NewArgon2idHasher(testArgon2idParams())
+	store := newMemCredentialStore()
+	hash, _ := hasher.Hash("s3cret!")
+	store.add("alice@example.com", "10001", hash)
+
+	gs := gstoken.New(config.NewBuilder().
+		WithCredentialVerifier(auth.NewPasswordVerifier(store, hasher)).
+		Build())
+
+	resp, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "alice@example.com", Password: "s3cret!", Device: "web"})
+	if err != nil {
+		t.Fatalf("password login failed: %v", err)
+	}
+	if info, _ := gs.GetLoginInfo(ctx, resp.Token); info == nil || info.UserID != "10001" {
+		t.Errorf("token should belong to resolved user id, got %+v", info)
+	}
+
+	for _, c := range []struct{ username, password string }{
+		{"alice@example.com", "wrong"},
+		{"nobody@example.com", "s3cret!"},
+	} {
+		_, err := gs.LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: c.username, Password: c.password})
+		if !errors.Is(err, core.ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", c.username, err)
+		}
+	}
+	if store.updates != 0 {
+		t.Error("hash with current parameters should not be rehashed")
+	}
+
+	// 未配置校验器时不允许密码登录
+	if _, err := gstoken.New(config.NewBuilder().Build()).LoginWithPassword(ctx, &core.PasswordLoginRequest{Username: "a", Password: "b"}); err == nil {
+		t.Error("password login without verifier should fail")
+	}
+}
+
+// TestPasswordRehashOnLogin 验证参数或算法变化时登录后自动重新哈希
+func TestPasswordRehashOnLogin(t *testing.T) {
+	ctx := context.Background()
+	store := newMemCredentialStore()
+
+	// 历史 bcrypt 哈希迁移到 argon2id
+	legacyHash, _ := auth.NewBcryptHasher(bcrypt.MinCost).Hash("legacy-pass")
+	store.add("bob", "10002", legacyHash)
+
+	// argon2id 参数升级
+	weak := testArgon2idParams()
+	weak.Iterations = 1
+	oldHash, _ := auth.NewArgon2idHasher(weak).Hash("carol-pass")
+	store.add("carol", "10003", oldHash)
+
+	current := testArgon2idParams()
+	current.Iterations = 2
+	verifier := auth.NewPasswordVerifier(store, auth.NewArgon2idHasher(current), auth.NewBcryptHasher(bcrypt.MinCost))
+
+	for _, c := range []struct{ username, password, userID string }{
+		{"bob", "legacy-pass", "10002"},
+		{"carol", "carol-pass", "10003"},
+	} {
+		userID, err := verifier.VerifyCredential(ctx, c.username, c.password)
+		if err != nil || userID != c.userID {
+			t.Fatalf("%s: verify failed: %s %v", c.username, userID, err)
+		}
+		newHash := store.hashes[c.userID]
+		if !strings.HasPrefix(newHash, "$argon2id$v=19$m=1024,t=2,p=2$") {
+			t.Errorf("%s: hash should be upgraded, got %s", c.username, newHash)
+		}
+		if _, err := verifier.VerifyCredential(ctx, c.username, c.password); err != nil {
+			t.Errorf("%s: rehashed password should verify: %v", c.username, err)
+		}
+	}
+	if store.updates != 2 {
+		t.Errorf("expected 2 rehash updates, got %d", store.updates)
+	}
+}
+
+// TestBcryptHasherNeedsRehash 验证 bcrypt cost 变化时需要重新哈希
+func TestBcryptHasherNeedsRehash(t *testing.T) {
+	low := auth.NewBcryptHasher(bcrypt.MinCost)
+	hash, _ := low.Hash("pw")
+	if ok, _ := low.Verify("pw", hash); !ok {
+		t.Fatal("bcrypt verify failed")
+	}
+	if low.NeedsRehash(hash) {
+		t.Error("same cost should not need rehash")
+	}
+	if !auth.NewBcryptHasher(bcrypt.MinCost + 1).NeedsRehash(hash) {
+		t.Error("different cost should need rehash")
+	}
+	if !low.Supports(hash) || auth.NewArgon2idHasher(testArgon2idParams()).Supports(hash) {
+		t.Error("hash format detection mismatch")
+	}
+}
+
+// TestArgon2idRejectsInvalidParams 验证存储的哈希参数为零或摘要长度异常时拒绝校验且不 panic
+func TestArgon2idRejectsInvalidParams(t *testing.T) {
+	hasher := auth.NewArgon2idHasher(testArgon2idParams())
+	hash, err := hasher.Hash("pw")
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	parts := strings.Split(hash, "$")
+
+	tampered := map[string]string{
+		"zero iterations":  strings.Replace(hash, ",t=1,", ",t=0,", 1),
+		"zero parallelism": strings.Replace(hash, ",p=2$", ",p=0$", 1),
+		"zero memory":      strings.Replace(hash, "m=1024,", "m=0,", 1),
+		"empty key":        strings.Join(append(parts[:5:5], ""), "$"),
+		"short key":        strings.Join(append(parts[:5:5], parts[5][:8]), "$"),
+		"empty salt":       strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "", parts[5]}, "$"),
+	}
+	for name, h := range tampered {
+		if h == hash {
+			t.Fatalf("%s: tampering did not change hash", name)
+		}
+		if ok, err := hasher.Verify("any password", h); ok || err == nil {
+			t.Errorf("%s: expected rejection, got ok=%v err=%v", name, ok, err)
+		}
+	}
+
+	bad := testArgon2idParams()
+	bad.Iterations = 0
+	if _, err := auth.NewArgon2idHasher(bad).Hash("pw"); err == nil {
+		t.Error("hashing with zero iterations should fail")
+	}
+}
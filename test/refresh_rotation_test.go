@@ -0,0 +1,259 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// recordingListener 记录安全事件的监听器
+type recordingListener struct {
+	mu     sync.Mutex
+	events []*core.SecurityEvent
+}
+
+func (l *recordingListener) OnSecurityEvent(ctx context.Context, event *core.SecurityEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingListener) count(eventType core.SecurityEventType) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, e := range l.events {
+		if e.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+// TestRefreshTokenRotationChain 验证正常轮换时刷新Token链可持续使用
+func TestRefreshTokenRotationChain(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithRefreshExpire(time.Hour).Build())
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "chain_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	refreshToken := resp.RefreshToken
+	for i := 0; i < 3; i++ {
+		next, err := gs.RefreshToken(ctx, refreshToken)
+		if err != nil {
+			t.Fatalf("refresh %d failed: %v", i, err)
+		}
+		if next.RefreshToken == refreshToken {
+			t.Fatal("refresh token should rotate")
+		}
+		refreshToken = next.RefreshToken
+	}
+}
+
+// TestRefreshTokenReuseRevokesFamily 验证重放已轮换的刷新Token会吊销整个Token族
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	listener := &recordingListener{}
+	gs := gstoken.New(config.NewBuilder().
+		WithRefreshExpire(time.Hour).
+		WithSecurityEventListener(listener).
+		Build())
+
+	login, err := gs.Login(ctx, &core.LoginRequest{UserID: "reuse_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	rotated, err := gs.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	// 攻击者重放旧刷新Token
+	if _, err := gs.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, core.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	// 族内的访问Token与当前刷新Token全部失效
+	for _, tk := range []string{login.Token, rotated.Token} {
+		if _, err := gs.GetAuthEngine().Verify(ctx, tk); err == nil {
+			t.Errorf("access token of revoked family should be invalid")
+		}
+	}
+	if _, err := gs.RefreshToken(ctx, rotated.RefreshToken); err == nil {
+		t.Error("current refresh token of revoked family should be invalid")
+	}
+
+	if n := listener.count(core.SecurityEventRefreshTokenReuse); n != 1 {
+		t.Fatalf("expected 1 reuse event, got %d", n)
+	}
+	if listener.events[0].UserID != "reuse_user" || listener.events[0].Extra["family_id"] == "" {
+		t.Errorf("unexpected event: %+v", listener.events[0])
+	}
+
+	// 其他登录产生的Token族不受影响
+	other, _ := gs.Login(ctx, &core.LoginRequest{UserID: "reuse_user", Device: "ios"})
+	if _, err := gs.RefreshToken(ctx, other.RefreshToken); err != nil {
+		t.Errorf("unrelated family should still refresh: %v", err)
+	}
+}
+
+// slowStorage 读取时增加延迟的内存存储，用于放大并发竞争窗口
+type slowStorage struct {
+	*storage.MemoryStorage
+	delay time.Duration
+}
+
+func (s *slowStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	time.Sleep(s.delay)
+	return s.MemoryStorage.Get(ctx, key)
+}
+
+// newSlowEngine 创建使用慢速存储的认证引擎
+func newSlowEngine(cfg *core.Config) *auth.Engine {
+	store := &slowStorage{MemoryStorage: storage.NewMemoryStorage(), delay: 5 * time.Millisecond}
+	return auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), core.NewKeyService(cfg.KeyPrefix))
+}
+
+// TestRefreshTokenConcurrentUse 验证同一刷新Token被并发使用时至多一个请求成功
+// 落败的请求视为重放并吊销Token族，因此并发结束后至多一个访问Token有效
+func TestRefreshTokenConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	engine := newSlowEngine(config.NewBuilder().WithRefreshExpire(time.Hour).Build())
+
+	login, err := engine.Login(ctx, &core.LoginRequest{UserID: "race_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	results := make([]*core.LoginResponse, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = engine.RefreshToken(ctx, login.RefreshToken)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded, valid := 0, 0
+	for _, resp := range results {
+		if resp == nil {
+			continue
+		}
+		succeeded++
+		if _, err := engine.Verify(ctx, resp.Token); err == nil {
+			valid++
+		}
+	}
+	if succeeded > 1 || valid > 1 {
+		t.Fatalf("concurrent refresh should succeed at most once, got %d succeeded and %d valid", succeeded, valid)
+	}
+}
+
+// hookStorage 写入指定前缀的键之前执行一次回调的内存存储，用于构造确定的并发交错
+type hookStorage struct {
+	*storage.MemoryStorage
+	prefix string
+	hook   func()
+}
+
+func (h *hookStorage) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	if hook := h.hook; hook != nil && strings.HasPrefix(key, h.prefix) {
+		h.hook = nil
+		hook()
+	}
+	return h.MemoryStorage.Set(ctx, key, value, expire)
+}
+
+// TestRefreshFamilyRevokedDuringRotation 验证轮换写回族记录时不会覆盖并发写入的吊销状态
+func TestRefreshFamilyRevokedDuringRotation(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().WithRefreshExpire(time.Hour).Build()
+	keyService := core.NewKeyService(cfg.KeyPrefix)
+	store := &hookStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: keyService.RefreshFamilyKey("")}
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), keyService)
+
+	login, err := engine.Login(ctx, &core.LoginRequest{UserID: "family_race_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	rotated, err := engine.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	// 正常轮换写回族记录之前，攻击者重放旧刷新Token触发吊销
+	store.hook = func() {
+		if _, err := engine.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, core.ErrRefreshTokenReused) {
+			t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+		}
+	}
+	next, err := engine.RefreshToken(ctx, rotated.RefreshToken)
+	if !errors.Is(err, core.ErrRefreshTokenInvalid) {
+		t.Fatalf("rotation racing with revocation should fail, got %v (%v)", err, next)
+	}
+
+	keys, _ := store.Keys(ctx, keyService.LoginInfoKey("")+"*")
+	if len(keys) != 0 {
+		t.Errorf("revoked family should leave no access tokens, got %v", keys)
+	}
+	keys, _ = store.Keys(ctx, keyService.RefreshTokenKey("")+"*")
+	if len(keys) != 0 {
+		t.Errorf("revoked family should leave no refresh tokens, got %v", keys)
+	}
+}
+
+// TestRefreshFamilyPrunesAccessTokens 验证族记录只保留仍有效的访问Token，不随轮换无限增长
+func TestRefreshFamilyPrunesAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().WithTokenExpire(20 * time.Millisecond).WithRefreshExpire(time.Hour).Build()
+	keyService := core.NewKeyService(cfg.KeyPrefix)
+	store := storage.NewMemoryStorage()
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), keyService)
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "family_prune_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		// 等待访问Token过期后再刷新，族内只需保留当前访问Token
+		time.Sleep(30 * time.Millisecond)
+		if resp, err = engine.RefreshToken(ctx, resp.RefreshToken); err != nil {
+			t.Fatalf("refresh %d failed: %v", i, err)
+		}
+	}
+
+	keys, _ := store.Keys(ctx, keyService.RefreshFamilyKey("")+"*")
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 family record, got %v", keys)
+	}
+	data, err := store.Get(ctx, keys[0])
+	if err != nil {
+		t.Fatalf("get family failed: %v", err)
+	}
+	var family struct {
+		AccessTokens []string `json:"access_tokens"`
+	}
+	if err := json.Unmarshal(data.([]byte), &family); err != nil {
+		t.Fatalf("decode family failed: %v", err)
+	}
+	if len(family.AccessTokens) != 1 {
+		t.Errorf("expected only the live access token, got %d", len(family.AccessTokens))
+	}
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// TestRefreshPreservesLoginInfo 验证刷新后沿用设备与额外信息并使用调用方IP
+func TestRefreshPreservesLoginInfo(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithRefreshExpire(time.Hour).Build())
+
+	login, err := gs.Login(ctx, &core.LoginRequest{
+		UserID: "refresh_info_user",
+		Device: "ios",
+		IP:     "10.0.0.1",
+		Extra:  map[string]interface{}{"tenant": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	refreshed, err := gs.Refresh(ctx, &core.RefreshRequest{RefreshToken: login.RefreshToken, IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	info, err := gs.GetLoginInfo(ctx, refreshed.Token)
+	if err != nil {
+		t.Fatalf("get login info failed: %v", err)
+	}
+	if info.Device != "ios" || info.IP != "10.0.0.2" || info.Extra["tenant"] != "acme" {
+		t.Errorf("refreshed login info mismatch: %+v", info)
+	}
+
+	session, err := gs.GetAuthEngine().(*auth.Engine).GetSessionService().GetSession(ctx, refreshed.Token)
+	if err != nil {
+		t.Fatalf("get session failed: %v", err)
+	}
+	if session.Device != "ios" || session.Extra["tenant"] != "acme" {
+		t.Errorf("refreshed session mismatch: %+v", session)
+	}
+
+	// 未携带IP时沿用原会话IP
+	again, err := gs.RefreshToken(ctx, refreshed.RefreshToken)
+	if err != nil {
+		t.Fatalf("second refresh failed: %v", err)
+	}
+	if info, _ := gs.GetLoginInfo(ctx, again.Token); info == nil || info.IP != "10.0.0.2" {
+		t.Errorf("ip should carry forward, got %+v", info)
+	}
+}
+
+// TestRefreshRevokesPairedAccessToken 验证刷新后旧访问Token失效
+func TestRefreshRevokesPairedAccessToken(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithRefreshExpire(time.Hour).
+		WithLoginMode(core.MultiLogin).
+		Build())
+
+	login, _ := gs.Login(ctx, &core.LoginRequest{UserID: "paired_user", Device: "web"})
+	other, _ := gs.Login(ctx, &core.LoginRequest{UserID: "paired_user", Device: "ios"})
+
+	refreshed, err := gs.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if gs.IsLogin(ctx, login.Token) {
+		t.Error("access token paired with used refresh token should be revoked")
+	}
+	if !gs.IsLogin(ctx, refreshed.Token) || !gs.IsLogin(ctx, other.Token) {
+		t.Error("new token and other sessions should stay valid in multi login mode")
+	}
+}
+
+// TestRefreshHonorsLoginMode 验证刷新与登录执行相同的登录模式检查
+func TestRefreshHonorsLoginMode(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	newEngine := func(mode core.LoginMode) *auth.Engine {
+		cfg := config.NewBuilder().WithRefreshExpire(time.Hour).WithLoginMode(mode).Build()
+		return auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), core.NewKeyService(cfg.KeyPrefix))
+	}
+
+	// 多端登录下同一设备存在两个会话
+	multi := newEngine(core.MultiLogin)
+	first, _ := multi.Login(ctx, &core.LoginRequest{UserID: "mutex_refresh", Device: "web"})
+	second, _ := multi.Login(ctx, &core.LoginRequest{UserID: "mutex_refresh", Device: "web"})
+
+	// 切换为同端互斥后，刷新会踢出同设备的其他会话
+	mutex := newEngine(core.MutexLogin)
+	refreshed, err := mutex.RefreshToken(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if _, err := mutex.Verify(ctx, second.Token); err == nil {
+		t.Error("refresh should kick out same-device session in mutex mode")
+	}
+	if _, err := mutex.Verify(ctx, refreshed.Token); err != nil {
+		t.Errorf("refreshed token should be valid: %v", err)
+	}
+}
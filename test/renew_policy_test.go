@@ -0,0 +1,208 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+)
+
+// writeCountingStorage 统计写入与TTL重置次数的存储包装
+type writeCountingStorage struct {
+	*storage.MemoryStorage
+	sets    int32
+	touches int32
+}
+
+func (c *writeCountingStorage) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	atomic.AddInt32(&c.sets, 1)
+	return c.MemoryStorage.Set(ctx, key, value, expire)
+}
+
+func (c *writeCountingStorage) Touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	atomic.AddInt32(&c.touches, 1)
+	return c.MemoryStorage.Touch(ctx, key, expire)
+}
+
+func (c *writeCountingStorage) reset() {
+	atomic.StoreInt32(&c.sets, 0)
+	atomic.StoreInt32(&c.touches, 0)
+}
+
+// newRenewEngine 创建使用计数存储的认证引擎
+func newRenewEngine(cfg *core.Config) (*auth.Engine, *writeCountingStorage) {
+	store := &writeCountingStorage{MemoryStorage: storage.NewMemoryStorage()}
+	return auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), core.NewKeyService(cfg.KeyPrefix)), store
+}
+
+// TestRenewThrottledByInterval 验证续期间隔内的校验不写存储
+func TestRenewThrottledByInterval(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newRenewEngine(config.NewBuilder().
+		WithAutoRenew(true).
+		WithRenewPolicy(0, 30*time.Millisecond).
+		Build())
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "renew_interval", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	store.reset()
+	for i := 0; i < 5; i++ {
+		if _, err := engine.Verify(ctx, resp.Token); err != nil {
+			t.Fatalf("verify failed: %v", err)
+		}
+	}
+	if store.sets != 0 || store.touches != 0 {
+		t.Errorf("verify within interval should be read-only, sets=%d touches=%d", store.sets, store.touches)
+	}
+
+	time.Sleep(35 * time.Millisecond)
+	before, _ := engine.GetLoginInfo(ctx, resp.Token)
+	if _, err := engine.Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	after, _ := engine.GetLoginInfo(ctx, resp.Token)
+	if !after.LastAccess.After(before.LastAccess) {
+		t.Error("verify after interval should renew")
+	}
+	// 续期不改写记录，登录信息、会话与用户会话映射只重置TTL
+	if store.sets != 0 || store.touches != 3 {
+		t.Errorf("renew should only touch records, sets=%d touches=%d", store.sets, store.touches)
+	}
+}
+
+// TestRenewThrottledByThreshold 验证剩余有效期低于阈值时才续期
+func TestRenewThrottledByThreshold(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newRenewEngine(config.NewBuilder().
+		WithTokenExpire(100*time.Millisecond).
+		WithAutoRenew(true).
+		WithRenewPolicy(0.5, 0).
+		Build())
+
+	resp, _ := engine.Login(ctx, &core.LoginRequest{UserID: "renew_threshold", Device: "web"})
+
+	store.reset()
+	engine.Verify(ctx, resp.Token)
+	if store.touches != 0 {
+		t.Error("verify with plenty of remaining ttl should not renew")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	engine.Verify(ctx, resp.Token)
+	if store.touches == 0 {
+		t.Error("verify below threshold should renew")
+	}
+
+	// 续期后会话仍然有效且超过原始有效期
+	time.Sleep(60 * time.Millisecond)
+	if _, err := engine.Verify(ctx, resp.Token); err != nil {
+		t.Errorf("renewed token should stay valid: %v", err)
+	}
+	if _, err := engine.GetSessionService().GetSession(ctx, resp.Token); err != nil {
+		t.Errorf("session ttl should be touched: %v", err)
+	}
+}
+
+// TestRenewConcurrentVerify 验证并发校验触发续期时不会因TTL重置竞争而失败
+func TestRenewConcurrentVerify(t *testing.T) {
+	ctx := context.Background()
+	engine, _ := newRenewEngine(config.NewBuilder().WithAutoRenew(true).Build())
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "renew_concurrent", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	var failed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 1600; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := engine.Verify(ctx, resp.Token); err != nil {
+				atomic.AddInt32(&failed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if failed != 0 {
+		t.Errorf("%d concurrent verifications failed", failed)
+	}
+}
+
+// TestRenewIntervalCappedByIdleTimeout 验证续期间隔大于空闲超时时，持续访问的用户不会被判定空闲
+func TestRenewIntervalCappedByIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	engine, _ := newRenewEngine(config.NewBuilder().
+		WithAutoRenew(true).
+		WithRenewPolicy(0, time.Hour).
+		WithSessionLifetime(0, 60*time.Millisecond).
+		Build())
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "renew_idle", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		time.Sleep(35 * time.Millisecond)
+		if _, err := engine.Verify(ctx, resp.Token); err != nil {
+			t.Fatalf("active session should not idle out at access %d: %v", i, err)
+		}
+	}
+
+	time.Sleep(70 * time.Millisecond)
+	if _, err := engine.Verify(ctx, resp.Token); err == nil {
+		t.Error("idle session should expire")
+	}
+}
+
+// logoutOnTouchStorage 首次重置TTL成功后执行回调的存储，用于模拟续期过程中并发登出
+type logoutOnTouchStorage struct {
+	*storage.MemoryStorage
+	onTouch func()
+}
+
+func (s *logoutOnTouchStorage) Touch(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	touched, err := s.MemoryStorage.Touch(ctx, key, expire)
+	if hook := s.onTouch; hook != nil {
+		s.onTouch = nil
+		hook()
+	}
+	return touched, err
+}
+
+// TestRenewDoesNotResurrectLoggedOutToken 验证续期过程中登出的Token不会被续期写回
+func TestRenewDoesNotResurrectLoggedOutToken(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().WithAutoRenew(true).Build()
+	store := &logoutOnTouchStorage{MemoryStorage: storage.NewMemoryStorage()}
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), core.NewKeyService(cfg.KeyPrefix))
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "renew_logout", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	store.onTouch = func() {
+		if err := engine.Logout(ctx, resp.Token); err != nil {
+			t.Errorf("logout failed: %v", err)
+		}
+	}
+	if _, err := engine.Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify before logout failed: %v", err)
+	}
+
+	if _, err := engine.Verify(ctx, resp.Token); err == nil {
+		t.Error("token logged out during renewal should stay invalid")
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// TestSafeWindow 验证二级认证窗口的开启、过期与关闭
+func TestSafeWindow(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "safe_user", Device: "web"})
+	other, _ := gs.Login(ctx, &core.LoginRequest{UserID: "safe_user", Device: "ios"})
+
+	if safe, _ := gs.IsSafe(ctx, resp.Token, "password"); safe {
+		t.Fatal("safe window should be closed by default")
+	}
+
+	if err := gs.OpenSafe(ctx, resp.Token, "password", 50*time.Millisecond); err != nil {
+		t.Fatalf("open safe failed: %v", err)
+	}
+	if safe, _ := gs.IsSafe(ctx, resp.Token, "password"); !safe {
+		t.Error("safe window should be open")
+	}
+	if safe, _ := gs.IsSafe(ctx, resp.Token, "billing"); safe {
+		t.Error("safe window should be scoped to service")
+	}
+	if safe, _ := gs.IsSafe(ctx, other.Token, "password"); safe {
+		t.Error("safe window should be scoped to token")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if safe, _ := gs.IsSafe(ctx, resp.Token, "password"); safe {
+		t.Error("safe window should expire")
+	}
+
+	gs.OpenSafe(ctx, resp.Token, "", time.Minute)
+	if safe, _ := gs.IsSafe(ctx, resp.Token, core.SafeServiceDefault); !safe {
+		t.Error("empty service should use default service")
+	}
+	if err := gs.CloseSafe(ctx, resp.Token, ""); err != nil {
+		t.Fatalf("close safe failed: %v", err)
+	}
+	if safe, _ := gs.IsSafe(ctx, resp.Token, ""); safe {
+		t.Error("safe window should be closed")
+	}
+
+	// 无效Token不能开启二级认证
+	if err := gs.OpenSafe(ctx, "not-a-token", "password", time.Minute); err == nil {
+		t.Error("open safe should fail for invalid token")
+	}
+}
+
+// TestGinRequireSafe 验证中间件在未完成二级认证时返回独立的 401 错误码
+func TestGinRequireSafe(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), nil)
+	r.POST("/password", auth.RequireSafe("password"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "gin_safe"})
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/password", nil)
+		req.Header.Set(web.HeaderAuthorization, web.BearerPrefix+resp.Token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do()
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusUnauthorized || body["error"] != web.ErrorSafeRequired {
+		t.Fatalf("expected 401 safe_auth_required, got %d %s", w.Code, w.Body.String())
+	}
+
+	gs.OpenSafe(ctx, resp.Token, "password", time.Minute)
+	if w := do(); w.Code != http.StatusOK {
+		t.Errorf("expected 200 after open safe, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// TestSessionMaxLifetime 验证自动续期不延长会话的绝对有效期
+func TestSessionMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithAutoRenew(true).
+		WithSessionLifetime(50*time.Millisecond, 0).
+		Build())
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "lifetime_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	// 持续访问触发续期
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if !gs.IsLogin(ctx, resp.Token) {
+			t.Fatal("session should be valid within lifetime")
+		}
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	_, err = gs.GetAuthEngine().Verify(ctx, resp.Token)
+	if !errors.Is(err, core.ErrSessionLifetimeExceeded) {
+		t.Fatalf("expected ErrSessionLifetimeExceeded, got %v", err)
+	}
+	if gs.IsLogin(ctx, resp.Token) {
+		t.Error("session should be logged out after lifetime exceeded")
+	}
+}
+
+// TestSessionIdleTimeout 验证超过空闲时长未访问的会话失效
+func TestSessionIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithAutoRenew(true).
+		WithSessionLifetime(0, 40*time.Millisecond).
+		Build())
+
+	resp, _ := gs.Login(ctx, &core.LoginRequest{UserID: "idle_user", Device: "web"})
+
+	// 空闲时长内的访问会刷新最后访问时间
+	time.Sleep(25 * time.Millisecond)
+	if !gs.IsLogin(ctx, resp.Token) {
+		t.Fatal("session should be valid within idle timeout")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if !gs.IsLogin(ctx, resp.Token) {
+		t.Fatal("access should reset idle timer")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	_, err := gs.GetAuthEngine().Verify(ctx, resp.Token)
+	if !errors.Is(err, core.ErrSessionIdleTimeout) {
+		t.Fatalf("expected ErrSessionIdleTimeout, got %v", err)
+	}
+}
+
+// TestRefreshKeepsAuthTime 验证刷新沿用首次认证时间，不延长绝对有效期
+func TestRefreshKeepsAuthTime(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithRefreshExpire(time.Hour).
+		WithSessionLifetime(50*time.Millisecond, 0).
+		Build())
+
+	login, _ := gs.Login(ctx, &core.LoginRequest{UserID: "auth_time_user", Device: "web"})
+	first, _ := gs.GetLoginInfo(ctx, login.Token)
+
+	time.Sleep(10 * time.Millisecond)
+	refreshed, err := gs.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	info, _ := gs.GetLoginInfo(ctx, refreshed.Token)
+	if info == nil || !info.AuthTime.Equal(first.AuthTime) {
+		t.Errorf("auth time should carry over, got %+v", info)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := gs.RefreshToken(ctx, refreshed.RefreshToken); !errors.Is(err, core.ErrSessionLifetimeExceeded) {
+		t.Errorf("refresh beyond lifetime should fail with ErrSessionLifetimeExceeded, got %v", err)
+	}
+}
+
+// TestSignedTokenMaxLifetime 验证无状态校验按 auth_time 执行绝对有效期，刷新后的Token不延长
+func TestSignedTokenMaxLifetime(t *testing.T) {
+	secret := []byte("lifetime-edge-secret")
+	ctx := context.Background()
+
+	// JWT 时间声明精度为秒，有效期需大于 1 秒
+	issuer := gstoken.New(config.NewBuilder().
+		WithJWTSecret(secret).
+		WithRefreshExpire(time.Hour).
+		WithSessionLifetime(2*time.Second, 0).
+		Build())
+	edge := gstoken.New(config.NewBuilder().
+		WithJWTSecret(secret).
+		WithVerifyMode(core.VerifyStateless).
+		WithSessionLifetime(2*time.Second, 0).
+		Build())
+
+	resp, err := issuer.Login(ctx, &core.LoginRequest{UserID: "signed_lifetime_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	refreshed, err := issuer.RefreshToken(ctx, resp.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	for _, token := range []string{resp.Token, refreshed.Token} {
+		if _, err := edge.GetAuthEngine().Verify(ctx, token); err != nil {
+			t.Fatalf("signed token should be valid within lifetime: %v", err)
+		}
+	}
+
+	time.Sleep(2100 * time.Millisecond)
+	for _, token := range []string{resp.Token, refreshed.Token} {
+		if _, err := edge.GetAuthEngine().Verify(ctx, token); !errors.Is(err, core.ErrSessionLifetimeExceeded) {
+			t.Errorf("expected ErrSessionLifetimeExceeded, got %v", err)
+		}
+	}
+}
+
+// TestSignedVerifyRejectsIdleTimeout 验证无状态与混合校验模式不能配置空闲超时
+func TestSignedVerifyRejectsIdleTimeout(t *testing.T) {
+	for _, mode := range []core.VerifyMode{core.VerifyStateless, core.VerifyHybrid} {
+		cfg := config.NewBuilder().
+			WithJWTSecret([]byte("idle-secret")).
+			WithVerifyMode(mode).
+			WithSessionLifetime(0, time.Minute).
+			Build()
+		if err := cfg.Validate(); !errors.Is(err, core.ErrConfigInvalid) {
+			t.Errorf("mode %v: expected ErrConfigInvalid, got %v", mode, err)
+		}
+
+		cfg = config.NewBuilder().
+			WithJWTSecret([]byte("idle-secret")).
+			WithVerifyMode(mode).
+			WithDeviceProfile("web", core.DeviceProfile{IdleTimeout: time.Minute}).
+			Build()
+		if err := cfg.Validate(); !errors.Is(err, core.ErrConfigInvalid) {
+			t.Errorf("mode %v with device profile: expected ErrConfigInvalid, got %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// TestMaxSessionsEvictOldest 验证超限时踢出登录最早的会话
+func TestMaxSessionsEvictOldest(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithLoginMode(core.MultiLogin).
+		WithMaxSessionsPerUser(2, core.EvictOldest).
+		Build())
+
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "seat_user", Device: "web"})
+		if err != nil {
+			t.Fatalf("login %d failed: %v", i, err)
+		}
+		tokens = append(tokens, resp.Token)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if gs.IsLogin(ctx, tokens[0]) {
+		t.Error("oldest session should be evicted")
+	}
+	if !gs.IsLogin(ctx, tokens[1]) || !gs.IsLogin(ctx, tokens[2]) {
+		t.Error("newer sessions should stay")
+	}
+}
+
+// TestMaxSessionsEvictLRU 验证超限时踢出最近最少访问的会话
+func TestMaxSessionsEvictLRU(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithLoginMode(core.MultiLogin).
+		WithAutoRenew(true).
+		WithMaxSessionsPerUser(2, core.EvictLRU).
+		Build())
+
+	first, _ := gs.Login(ctx, &core.LoginRequest{UserID: "lru_user", Device: "web"})
+	time.Sleep(2 * time.Millisecond)
+	second, _ := gs.Login(ctx, &core.LoginRequest{UserID: "lru_user", Device: "ios"})
+	time.Sleep(2 * time.Millisecond)
+
+	// 访问第一个会话，使第二个会话成为最近最少访问
+	gs.IsLogin(ctx, first.Token)
+	time.Sleep(2 * time.Millisecond)
+
+	third, _ := gs.Login(ctx, &core.LoginRequest{UserID: "lru_user", Device: "android"})
+	if gs.IsLogin(ctx, second.Token) {
+		t.Error("least recently used session should be evicted")
+	}
+	if !gs.IsLogin(ctx, first.Token) || !gs.IsLogin(ctx, third.Token) {
+		t.Error("recently used and new sessions should stay")
+	}
+}
+
+// TestMaxSessionsReject 验证拒绝策略返回类型化错误
+func TestMaxSessionsReject(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithLoginMode(core.MultiLogin).
+		WithMaxSessionsPerUser(1, core.EvictReject).
+		Build())
+
+	first, err := gs.Login(ctx, &core.LoginRequest{UserID: "reject_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("first login failed: %v", err)
+	}
+
+	_, err = gs.Login(ctx, &core.LoginRequest{UserID: "reject_user", Device: "ios"})
+	var maxErr *core.MaxSessionsError
+	if !errors.As(err, &maxErr) || !errors.Is(err, core.ErrMaxSessionsExceeded) {
+		t.Fatalf("expected MaxSessionsError, got %v", err)
+	}
+	if maxErr.UserID != "reject_user" || maxErr.Limit != 1 {
+		t.Errorf("unexpected error detail: %+v", maxErr)
+	}
+	if !gs.IsLogin(ctx, first.Token) {
+		t.Error("existing session must stay when new login is rejected")
+	}
+
+	// 登出后释放名额
+	gs.Logout(ctx, first.Token)
+	if _, err := gs.Login(ctx, &core.LoginRequest{UserID: "reject_user", Device: "ios"}); err != nil {
+		t.Errorf("login after logout should succeed: %v", err)
+	}
+}
+
+// TestMaxSessionsPerDevice 验证按设备限制会话数
+func TestMaxSessionsPerDevice(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithLoginMode(core.MultiLogin).
+		WithDeviceProfile("web", core.DeviceProfile{MaxSessions: 1}).
+		Build())
+
+	web1, _ := gs.Login(ctx, &core.LoginRequest{UserID: "device_seat", Device: "web"})
+	ios1, _ := gs.Login(ctx, &core.LoginRequest{UserID: "device_seat", Device: "ios"})
+	ios2, _ := gs.Login(ctx, &core.LoginRequest{UserID: "device_seat", Device: "ios"})
+	web2, _ := gs.Login(ctx, &core.LoginRequest{UserID: "device_seat", Device: "web"})
+
+	if gs.IsLogin(ctx, web1.Token) {
+		t.Error("web is limited to one session")
+	}
+	for _, tk := range []string{ios1.Token, ios2.Token, web2.Token} {
+		if !gs.IsLogin(ctx, tk) {
+			t.Error("sessions within device limit should stay")
+		}
+	}
+}
+
+// TestMaxSessionsConcurrentLogin 验证并发登录同时通过预检查时，有效会话数仍不超过上限
+func TestMaxSessionsConcurrentLogin(t *testing.T) {
+	for _, policy := range []core.SessionEvictPolicy{core.EvictReject, core.EvictOldest} {
+		ctx := context.Background()
+		engine := newSlowEngine(config.NewBuilder().
+			WithLoginMode(core.MultiLogin).
+			WithMaxSessionsPerUser(2, policy).
+			Build())
+
+		const n = 8
+		var wg sync.WaitGroup
+		tokens := make([]string, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "seat_race_user", Device: "web"}); err == nil {
+					tokens[i] = resp.Token
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		valid := 0
+		for _, tk := range tokens {
+			if tk == "" {
+				continue
+			}
+			if _, err := engine.Verify(ctx, tk); err == nil {
+				valid++
+			}
+		}
+		if valid > 2 {
+			t.Errorf("policy %v: expected at most 2 valid sessions, got %d", policy, valid)
+		}
+		if policy == core.EvictOldest && valid == 0 {
+			t.Errorf("policy %v: at least one concurrent login should keep its session", policy)
+		}
+	}
+}
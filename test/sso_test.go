@@ -0,0 +1,448 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+	"github.com/luckxgo/gstoken/sso"
+	"github.com/luckxgo/gstoken/storage"
+	"github.com/luckxgo/gstoken/token"
+	"github.com/luckxgo/gstoken/web"
+)
+
+// virtualHosts 按 Host 分发请求，用一个 httptest 服务器模拟多个域名
+type virtualHosts map[string]http.Handler
+
+func (v virtualHosts) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := v[r.Host]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// newVirtualTransport 启动 httptest 服务器，返回将所有域名解析到该服务器的 Transport
+func newVirtualTransport(t *testing.T, hosts virtualHosts) http.RoundTripper {
+	srv := httptest.NewServer(hosts)
+	t.Cleanup(srv.Close)
+	addr := srv.Listener.Addr().String()
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// newBrowser 创建带 Cookie 的浏览器客户端
+func newBrowser(t *testing.T, transport http.RoundTripper) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("create cookie jar failed: %v", err)
+	}
+	return &http.Client{Transport: transport, Jar: jar}
+}
+
+// ssoLoginHandler 模拟认证中心登录页：直接以 10001 登录并写入中心 Cookie 后返回 return_to
+func ssoLoginHandler(gs *gstoken.GSToken, server *sso.Server, visits *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*visits++
+		resp, err := gs.Login(r.Context(), &core.LoginRequest{UserID: "10001"})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		server.SetSessionCookie(w, resp.Token, resp.ExpireTime)
+		http.Redirect(w, r, r.URL.Query().Get("return_to"), http.StatusFound)
+	}
+}
+
+// TestSSOCrossDomain 验证跨域模式：票据重定向登录、票据一次性与应用绑定、单点登出通知所有应用
+func TestSSOCrossDomain(t *testing.T) {
+	ctx := context.Background()
+	hosts := virtualHosts{}
+	transport := newVirtualTransport(t, hosts)
+	backend := &http.Client{Transport: transport}
+
+	center := gstoken.New(config.NewBuilder().Build())
+	var apps []sso.App
+	for _, name := range []string{"alpha", "beta"} {
+		apps = append(apps, sso.App{
+			ID:        name,
+			Secret:    name + "-secret",
+			AllowURLs: []string{"http://" + name + ".test/sso/"},
+			LogoutURL: "http://" + name + ".test/sso/logout",
+		})
+	}
+	server := center.NewSSOServer(&sso.ServerConfig{
+		Apps:       apps,
+		LoginURL:   "http://sso.center.test/login",
+		HTTPClient: backend,
+	})
+	loginVisits := 0
+	centerMux := http.NewServeMux()
+	server.Register(centerMux, "/sso")
+	centerMux.Handle("/login", ssoLoginHandler(center, server, &loginVisits))
+	hosts["sso.center.test"] = centerMux
+
+	// 每个应用使用独立的 GSToken 与存储
+	appTokens := map[string]*gstoken.GSToken{}
+	for _, name := range []string{"alpha", "beta"} {
+		gs := gstoken.New(config.NewBuilder().Build())
+		client := gs.NewSSOClient(&sso.ClientConfig{
+			AppID:      name,
+			Secret:     name + "-secret",
+			ServerURL:  "http://sso.center.test/sso",
+			HTTPClient: backend,
+		})
+		callback := "http://" + name + ".test/sso/callback"
+		mux := http.NewServeMux()
+		mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, client.AuthURL(callback), http.StatusFound)
+		})
+		mux.Handle("/sso/callback", client.CallbackHandler(nil))
+		mux.Handle("/sso/logout", client.LogoutCallbackHandler())
+		hosts[name+".test"] = mux
+		appTokens[name] = gs
+	}
+
+	browser := newBrowser(t, transport)
+	login := func(app string) (string, *url.URL) {
+		resp, err := browser.Get("http://" + app + ".test/login")
+		if err != nil {
+			t.Fatalf("%s login failed: %v", app, err)
+		}
+		defer resp.Body.Close()
+		var body core.LoginResponse
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&body) != nil || body.Token == "" {
+			t.Fatalf("%s login: unexpected response %d", app, resp.StatusCode)
+		}
+		return body.Token, resp.Request.URL
+	}
+
+	alphaToken, callbackURL := login("alpha")
+	if loginVisits != 1 {
+		t.Fatalf("expected one visit to login page, got %d", loginVisits)
+	}
+	if info, err := appTokens["alpha"].GetLoginInfo(ctx, alphaToken); err != nil || info.UserID != "10001" {
+		t.Fatalf("alpha local session invalid: %+v %v", info, err)
+	}
+
+	// 已在中心登录，第二个应用无需再次登录
+	betaToken, _ := login("beta")
+	if loginVisits != 1 {
+		t.Errorf("beta should reuse center session, login page visited %d times", loginVisits)
+	}
+	if !appTokens["beta"].IsLogin(ctx, betaToken) {
+		t.Error("beta local session should be valid")
+	}
+
+	// 票据只能使用一次
+	resp, err := browser.Get(callbackURL.String())
+	if err != nil {
+		t.Fatalf("replay ticket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("replayed ticket should be rejected, got %d", resp.StatusCode)
+	}
+
+	// 票据只能由签发时指定的应用使用，回调地址必须在允许范围内
+	centerURL, _ := url.Parse("http://sso.center.test/")
+	cookies := browser.Jar.Cookies(centerURL)
+	if len(cookies) != 1 || cookies[0].Name != sso.DefaultCookieName {
+		t.Fatalf("unexpected center cookies: %v", cookies)
+	}
+	centerToken := cookies[0].Value
+	location, err := server.Authorize(ctx, "alpha", "http://alpha.test/sso/callback", centerToken)
+	if err != nil {
+		t.Fatalf("authorize failed: %v", err)
+	}
+	ticketURL, _ := url.Parse(location)
+	if _, err := server.CheckTicket(ctx, "beta", "beta-secret", ticketURL.Query().Get("ticket")); !errors.Is(err, core.ErrSSOTicketInvalid) {
+		t.Errorf("ticket issued for alpha should be rejected for beta, got %v", err)
+	}
+	if _, err := server.CheckTicket(ctx, "alpha", "wrong", "x"); !errors.Is(err, core.ErrSSOAppInvalid) {
+		t.Errorf("wrong app secret should be rejected, got %v", err)
+	}
+	for _, redirect := range []string{"http://evil.test/sso/callback", "http://alpha.test/sso-evil", "//alpha.test/sso/callback"} {
+		if _, err := server.Authorize(ctx, "alpha", redirect, centerToken); !errors.Is(err, core.ErrSSORedirectNotAllowed) {
+			t.Errorf("redirect %s should be rejected, got %v", redirect, err)
+		}
+	}
+
+	// 中心登出通知所有应用注销本地会话
+	resp, err = browser.Get("http://sso.center.test/sso/logout")
+	if err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("logout: expected 204, got %d", resp.StatusCode)
+	}
+	if center.IsLogin(ctx, centerToken) {
+		t.Error("center token should be logged out")
+	}
+	if appTokens["alpha"].IsLogin(ctx, alphaToken) || appTokens["beta"].IsLogin(ctx, betaToken) {
+		t.Error("app tokens should be logged out by single logout")
+	}
+
+	// 登出后再次访问应用需要重新登录
+	login("alpha")
+	if loginVisits != 2 {
+		t.Errorf("expected login page after logout, visited %d times", loginVisits)
+	}
+}
+
+// TestSSOSameDomain 验证同域模式：子域应用通过父域 Cookie 共享中心Token，中心登出后所有应用立即失效
+func TestSSOSameDomain(t *testing.T) {
+	ctx := context.Background()
+	hosts := virtualHosts{}
+	transport := newVirtualTransport(t, hosts)
+
+	gs := gstoken.New(config.NewBuilder().Build())
+	server := gs.NewSSOServer(&sso.ServerConfig{CookieDomain: "example.test"})
+	loginVisits := 0
+	centerMux := http.NewServeMux()
+	server.Register(centerMux, "/sso")
+	centerMux.Handle("/login", ssoLoginHandler(gs, server, &loginVisits))
+	hosts["sso.example.test"] = centerMux
+
+	gin.SetMode(gin.TestMode)
+	authConfig := web.DefaultAuthConfig()
+	authConfig.TokenCookie = sso.DefaultCookieName
+	for _, name := range []string{"a", "b"} {
+		r := gin.New()
+		auth := web.NewGinAuthMiddleware(web.NewGSTokenWebAdapter(gs), authConfig)
+		r.GET("/me", auth.RequireAuth(), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"user": c.GetString(web.ContextKeyUserID)})
+		})
+		hosts[name+".example.test"] = r
+	}
+
+	browser := newBrowser(t, transport)
+	get := func(rawURL string) int {
+		resp, err := browser.Get(rawURL)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", rawURL, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("http://a.example.test/me"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 before login, got %d", code)
+	}
+	if code := get("http://sso.example.test/login?return_to=http://a.example.test/me"); code != http.StatusOK {
+		t.Fatalf("login should redirect back to app, got %d", code)
+	}
+	if code := get("http://b.example.test/me"); code != http.StatusOK {
+		t.Errorf("sibling app should share login, got %d", code)
+	}
+
+	appURL, _ := url.Parse("http://b.example.test/")
+	cookies := browser.Jar.Cookies(appURL)
+	if len(cookies) != 1 {
+		t.Fatalf("expected shared cookie on sibling domain, got %v", cookies)
+	}
+	token := cookies[0].Value
+
+	if code := get("http://sso.example.test/sso/logout"); code != http.StatusNoContent {
+		t.Fatalf("logout failed: %d", code)
+	}
+	if code := get("http://a.example.test/me"); code != http.StatusUnauthorized {
+		t.Errorf("cookie should be cleared after logout, got %d", code)
+	}
+
+	// 即使客户端保留旧 Cookie，中心Token也已失效
+	req, _ := http.NewRequest(http.MethodGet, "http://b.example.test/me", nil)
+	req.AddCookie(&http.Cookie{Name: sso.DefaultCookieName, Value: token})
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("logged out token should be rejected, got %d", resp.StatusCode)
+	}
+	if gs.IsLogin(ctx, token) {
+		t.Error("token should be logged out")
+	}
+}
+
+// logoutRecorder 记录各应用收到的单点登出通知次数
+type logoutRecorder struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (l *logoutRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	appID, _, _ := r.BasicAuth()
+	l.mu.Lock()
+	l.counts[appID]++
+	l.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (l *logoutRecorder) take() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	counts := l.counts
+	l.counts = map[string]int{}
+	return counts
+}
+
+// TestSSOConcurrentLoginAndEngineLogout 验证并发签发票据与绑定本地Token不丢失记录，通过引擎登出、踢出或封禁时同样通知应用
+func TestSSOConcurrentLoginAndEngineLogout(t *testing.T) {
+	ctx := context.Background()
+	recorder := &logoutRecorder{counts: map[string]int{}}
+	notify := httptest.NewServer(recorder)
+	t.Cleanup(notify.Close)
+
+	var apps []sso.App
+	for i := 0; i < 8; i++ {
+		id := fmt.Sprintf("app%d", i)
+		apps = append(apps, sso.App{ID: id, Secret: id + "-secret", AllowURLs: []string{"http://" + id + ".test/"}, LogoutURL: notify.URL + "/" + id})
+	}
+	center := gstoken.New(config.NewBuilder().Build())
+	server := center.NewSSOServer(&sso.ServerConfig{Apps: apps})
+
+	authorizeAll := func(apps []sso.App) string {
+		resp, err := center.Login(ctx, &core.LoginRequest{UserID: "10001"})
+		if err != nil {
+			t.Fatalf("center login failed: %v", err)
+		}
+		var wg sync.WaitGroup
+		for _, app := range apps {
+			wg.Add(1)
+			go func(app sso.App) {
+				defer wg.Done()
+				if _, err := server.Authorize(ctx, app.ID, app.AllowURLs[0]+"cb", resp.Token); err != nil {
+					t.Errorf("authorize %s failed: %v", app.ID, err)
+				}
+			}(app)
+		}
+		wg.Wait()
+		return resp.Token
+	}
+	expectNotified := func(action string, apps []sso.App) {
+		counts := recorder.take()
+		if len(counts) != len(apps) {
+			t.Errorf("%s: expected %d apps notified, got %v", action, len(apps), counts)
+		}
+		for _, app := range apps {
+			if counts[app.ID] != 1 {
+				t.Errorf("%s: app %s should be notified once, got %d", action, app.ID, counts[app.ID])
+			}
+		}
+	}
+
+	token := authorizeAll(apps)
+	if err := server.Logout(ctx, token); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	expectNotified("sso logout", apps)
+
+	authorizeAll(apps)
+	if err := center.LogoutByUserID(ctx, "10001"); err != nil {
+		t.Fatalf("logout by user failed: %v", err)
+	}
+	expectNotified("engine logout", apps)
+
+	authorizeAll(apps[:3])
+	if err := center.Disable(ctx, "10001", "", time.Minute, "test"); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	expectNotified("disable", apps[:3])
+
+	// 应用端并发绑定的本地Token全部随中心会话注销
+	gs := gstoken.New(config.NewBuilder().WithTokenHashing(nil).Build())
+	client := gs.NewSSOClient(&sso.ClientConfig{AppID: "app0", Secret: "app0-secret"})
+	tokens := make([]string, 20)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "10001", Device: fmt.Sprintf("d%d", i)})
+			if err != nil {
+				t.Errorf("local login failed: %v", err)
+				return
+			}
+			tokens[i] = resp.Token
+			if err := client.Bind(ctx, "session-1", resp.Token, resp.ExpireTime); err != nil {
+				t.Errorf("bind failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 开启摘要存储时映射中只保存Token摘要，不保存原始Token
+	keyService := core.NewKeyService(gs.GetConfig().KeyPrefix)
+	keys, _ := gs.GetStorage().Keys(ctx, keyService.SSOClientTokenPattern("app0", "session-1"))
+	if len(keys) != len(tokens) {
+		t.Fatalf("expected %d bindings, got %d", len(tokens), len(keys))
+	}
+	for _, key := range keys {
+		data, _ := gs.GetStorage().Get(ctx, key)
+		for i, token := range tokens {
+			if strings.Contains(string(data.([]byte)), token) {
+				t.Errorf("binding %s stores raw token %d", key, i)
+			}
+		}
+	}
+
+	if err := client.LogoutSession(ctx, "session-1"); err != nil {
+		t.Fatalf("logout session failed: %v", err)
+	}
+	for i, token := range tokens {
+		if gs.IsLogin(ctx, token) {
+			t.Errorf("bound token %d should be logged out", i)
+		}
+	}
+}
+
+// TestSSOLogoutStorageFailure 验证读取中心会话失败时返回错误，中心Token仍被注销
+func TestSSOLogoutStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().Build()
+	keyService := core.NewKeyService(cfg.KeyPrefix)
+	store := &failingStorage{MemoryStorage: storage.NewMemoryStorage(), prefix: "none:"}
+	engine := auth.NewEngine(cfg, store, token.NewGeneratorWithConfig(cfg), keyService)
+	server := sso.NewServer(store, keyService, engine, &sso.ServerConfig{
+		Apps: []sso.App{{ID: "alpha", Secret: "alpha-secret", AllowURLs: []string{"http://alpha.test/"}}},
+	})
+
+	resp, err := engine.Login(ctx, &core.LoginRequest{UserID: "10001"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if _, err := server.Authorize(ctx, "alpha", "http://alpha.test/cb", resp.Token); err != nil {
+		t.Fatalf("authorize failed: %v", err)
+	}
+
+	store.prefix = keyService.SSOSessionKey(server.SessionID(resp.Token))
+	if err := server.Logout(ctx, resp.Token); err == nil {
+		t.Error("storage failure should be reported")
+	}
+	if _, err := engine.Verify(ctx, resp.Token); err == nil {
+		t.Error("center token should be logged out despite storage failure")
+	}
+}
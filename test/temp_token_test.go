@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// TestTempTokenSingleUse 验证临时Token绑定用途且只能使用一次
+func TestTempTokenSingleUse(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	tk, err := gs.CreateTempToken(ctx, "reset_password", "user_1001", time.Minute)
+	if err != nil {
+		t.Fatalf("create temp token failed: %v", err)
+	}
+
+	// 用途不匹配时不消费
+	if _, err := gs.ConsumeTempToken(ctx, "verify_email", tk); !errors.Is(err, core.ErrTempTokenInvalid) {
+		t.Errorf("expected ErrTempTokenInvalid for wrong purpose, got %v", err)
+	}
+
+	value, err := gs.ConsumeTempToken(ctx, "reset_password", tk)
+	if err != nil || value != "user_1001" {
+		t.Fatalf("consume failed: value=%q err=%v", value, err)
+	}
+	if _, err := gs.ConsumeTempToken(ctx, "reset_password", tk); !errors.Is(err, core.ErrTempTokenInvalid) {
+		t.Errorf("temp token should be single use, got %v", err)
+	}
+
+	// 临时Token不能作为访问Token
+	if gs.IsLogin(ctx, tk) {
+		t.Error("temp token must not be accepted as access token")
+	}
+}
+
+// TestTempTokenExpire 验证临时Token到期失效
+func TestTempTokenExpire(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().Build())
+
+	tk, _ := gs.CreateTempToken(ctx, "download", "/files/report.pdf", 30*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if _, err := gs.ConsumeTempToken(ctx, "download", tk); !errors.Is(err, core.ErrTempTokenInvalid) {
+		t.Errorf("expired temp token should be invalid, got %v", err)
+	}
+
+	if _, err := gs.CreateTempToken(ctx, "download", "x", 0); err == nil {
+		t.Error("zero ttl should be rejected")
+	}
+	if _, err := gs.CreateTempToken(ctx, "", "x", time.Minute); err == nil {
+		t.Error("empty purpose should be rejected")
+	}
+}
+
+// TestTempTokenConcurrentConsume 验证并发消费时只有一个请求成功（含加密存储）
+func TestTempTokenConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	instances := map[string]*gstoken.GSToken{
+		"memory": gstoken.New(config.NewBuilder().Build()),
+		"encrypted": gstoken.New(config.NewBuilder().
+			WithEncryptionKeys(core.EncryptionKey{ID: "k1", Key: []byte("0123456789abcdef0123456789abcdef")}).
+			Build()),
+	}
+
+	for name, gs := range instances {
+		tk, _ := gs.CreateTempToken(ctx, "verify_email", "a@example.com", time.Minute)
+
+		var success int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if value, err := gs.ConsumeTempToken(ctx, "verify_email", tk); err == nil && value == "a@example.com" {
+					atomic.AddInt32(&success, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if success != 1 {
+			t.Errorf("%s: expected exactly one successful consume, got %d", name, success)
+		}
+	}
+}
+
+// TestTempTokenSignedStyle 验证签名风格的临时Token不能通过无状态校验
+func TestTempTokenSignedStyle(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithJWTSecret([]byte("temp-token-secret-0123456789")).
+		WithVerifyMode(core.VerifyStateless).
+		Build())
+
+	tk, err := gs.CreateTempToken(ctx, "download", "/files/a.zip", time.Minute)
+	if err != nil {
+		t.Fatalf("create temp token failed: %v", err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, tk); !errors.Is(err, core.ErrTokenInvalid) {
+		t.Errorf("signed temp token should be rejected as access token, got %v", err)
+	}
+	if value, err := gs.ConsumeTempToken(ctx, "download", tk); err != nil || value != "/files/a.zip" {
+		t.Errorf("consume signed temp token failed: value=%q err=%v", value, err)
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luckxgo/gstoken"
+	"github.com/luckxgo/gstoken/auth"
+	"github.com/luckxgo/gstoken/config"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// assertNoRawToken 检查存储中的键和值均不包含原始Token
+func assertNoRawToken(t *testing.T, gs *gstoken.GSToken, tokens ...string) {
+	t.Helper()
+	ctx := context.Background()
+	store := gs.GetStorage()
+
+	keys, err := store.Keys(ctx, "*")
+	if err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("storage should not be empty")
+	}
+	for _, key := range keys {
+		value, _ := store.Get(ctx, key)
+		for _, tk := range tokens {
+			if strings.Contains(key, tk) {
+				t.Errorf("key %s contains raw token", key)
+			}
+			if strings.Contains(fmt.Sprintf("%s", value), tk) {
+				t.Errorf("value of %s contains raw token", key)
+			}
+		}
+	}
+}
+
+// TestTokenHashingStorage 验证开启摘要存储后存储中不出现原始Token
+func TestTokenHashingStorage(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithTokenHashing([]byte("hash-secret")).
+		WithRefreshExpire(time.Hour).
+		Build())
+
+	resp, err := gs.Login(ctx, &core.LoginRequest{UserID: "hash_user", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	assertNoRawToken(t, gs, resp.Token, resp.RefreshToken)
+
+	if _, err := gs.GetAuthEngine().Verify(ctx, resp.Token); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	// 存储中的引用不能作为Token使用
+	loginInfo, err := gs.GetLoginInfo(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("get login info failed: %v", err)
+	}
+	if loginInfo.Token == resp.Token {
+		t.Fatal("login info should hold token hash")
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, loginInfo.Token); err == nil {
+		t.Fatal("token hash must not be accepted as bearer token")
+	}
+
+	// 刷新Token按摘要查找
+	refreshed, err := gs.RefreshToken(ctx, resp.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	assertNoRawToken(t, gs, refreshed.Token, refreshed.RefreshToken)
+
+	if err := gs.Logout(ctx, refreshed.Token); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, refreshed.Token); err == nil {
+		t.Fatal("token should be invalid after logout")
+	}
+}
+
+// TestTokenHashingKickOut 验证按用户下线在仅保存摘要时仍然生效
+func TestTokenHashingKickOut(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().WithTokenHashing(nil).Build())
+
+	web, _ := gs.Login(ctx, &core.LoginRequest{UserID: "hash_kick", Device: "web"})
+	ios, _ := gs.Login(ctx, &core.LoginRequest{UserID: "hash_kick", Device: "ios"})
+
+	if err := gs.LogoutByUserID(ctx, "hash_kick"); err != nil {
+		t.Fatalf("logout by user failed: %v", err)
+	}
+	for _, tk := range []string{web.Token, ios.Token} {
+		if _, err := gs.GetAuthEngine().Verify(ctx, tk); err == nil {
+			t.Error("token should be invalid after logout by user id")
+		}
+	}
+	for _, pattern := range []string{"gstoken:session:*", "gstoken:login:*", "gstoken:user_session:*"} {
+		if keys, _ := gs.GetStorage().Keys(ctx, pattern); len(keys) != 0 {
+			t.Errorf("records of %s should be removed, got %v", pattern, keys)
+		}
+	}
+}
+
+// TestTokenHashingJWTRevocation 验证签名Token在仅保存摘要时按 jti 吊销
+func TestTokenHashingJWTRevocation(t *testing.T) {
+	ctx := context.Background()
+	gs := gstoken.New(config.NewBuilder().
+		WithJWTSecret([]byte("jwt-hash-secret")).
+		WithTokenHashing(nil).
+		WithVerifyMode(core.VerifyHybrid).
+		WithLoginMode(core.SingleLogin).
+		Build())
+
+	first, err := gs.Login(ctx, &core.LoginRequest{UserID: "hash_jwt", Device: "web"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if _, err := gs.Login(ctx, &core.LoginRequest{UserID: "hash_jwt", Device: "ios"}); err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+	if _, err := gs.GetAuthEngine().Verify(ctx, first.Token); err == nil {
+		t.Fatal("kicked out jwt should be revoked")
+	}
+}
+
+// TestTokenHashingSessionService 验证开启摘要存储后通过公开方法创建的会话可按原始Token读取与删除
+func TestTokenHashingSessionService(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewBuilder().WithTokenHashing([]byte("hash-secret")).Build()
+	gs := gstoken.New(cfg)
+	keyService := core.NewKeyService(cfg.KeyPrefix)
+	keyService.SetTokenHasher(core.NewTokenHasher(cfg.TokenHashSecret))
+	sessions := auth.NewSessionService(gs.GetStorage(), cfg, keyService)
+
+	raw := "raw-session-token"
+	if err := sessions.CreateSession(ctx, &core.Session{ID: raw, UserID: "hash_session", Token: raw, Device: "web"}); err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	assertNoRawToken(t, gs, raw)
+
+	session, err := sessions.GetSession(ctx, raw)
+	if err != nil {
+		t.Fatalf("get session failed: %v", err)
+	}
+	if session.UserID != "hash_session" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+
+	session.Token = raw
+	session.Extra = map[string]interface{}{"k": "v"}
+	if err := sessions.UpdateSession(ctx, session); err != nil {
+		t.Fatalf("update session failed: %v", err)
+	}
+	if updated, err := sessions.GetSession(ctx, raw); err != nil || updated.Extra["k"] != "v" {
+		t.Errorf("session should be updated: %+v %v", updated, err)
+	}
+	assertNoRawToken(t, gs, raw)
+
+	if err := sessions.DeleteSession(ctx, raw); err != nil {
+		t.Fatalf("delete session failed: %v", err)
+	}
+	if _, err := sessions.GetSession(ctx, raw); err == nil {
+		t.Error("session should be deleted")
+	}
+	if keys, _ := gs.GetStorage().Keys(ctx, "*"); len(keys) != 0 {
+		t.Errorf("session records should be removed, got %v", keys)
+	}
+}
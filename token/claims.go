@@ -0,0 +1,158 @@
+package token
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luckxgo/gstoken/core"
+)
+
+// 注册声明名称（JWT 与 PASETO 共用）
+const (
+	claimIssuer    = "iss"
+	claimSubject   = "sub"
+	claimAudience  = "aud"
+	claimExpire    = "exp"
+	claimNotBefore = "nbf"
+	claimIssuedAt  = "iat"
+	claimID        = "jti"
+	claimAuthTime  = core.TokenExtraKeyAuthTime
+)
+
+// registeredClaims 注册声明集合，解析时不放入 Extra
+var registeredClaims = map[string]struct{}{
+	claimIssuer:    {},
+	claimSubject:   {},
+	claimAudience:  {},
+	claimExpire:    {},
+	claimNotBefore: {},
+	claimIssuedAt:  {},
+	claimID:        {},
+	claimAuthTime:  {},
+}
+
+// newClaims 根据额外参数构造声明集合，formatTime 决定时间声明的编码方式
+func newClaims(extra map[string]interface{}, issuer, audience string, ttl time.Duration, formatTime func(time.Time) interface{}) map[string]interface{} {
+	now := time.Now()
+	claims := make(map[string]interface{}, len(extra)+7)
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	// user_id 映射为标准的 sub 声明
+	if userID, ok := claims[core.TokenExtraKeyUserID]; ok {
+		claims[claimSubject] = userID
+		delete(claims, core.TokenExtraKeyUserID)
+	}
+	if issuer != "" {
+		claims[claimIssuer] = issuer
+	}
+	if audience != "" {
+		claims[claimAudience] = audience
+	}
+	if authTime, ok := claims[claimAuthTime].(time.Time); ok {
+		claims[claimAuthTime] = formatTime(authTime)
+	}
+	claims[claimIssuedAt] = formatTime(now)
+	claims[claimNotBefore] = formatTime(now)
+	claims[claimExpire] = formatTime(now.Add(ttl))
+	claims[claimID] = uuid.New().String()
+
+	return claims
+}
+
+// buildTokenInfo 校验时间与签发声明并构造Token信息
+func buildTokenInfo(claims map[string]interface{}, issuer, audience string, leeway time.Duration) (*core.TokenInfo, error) {
+	now := time.Now()
+
+	expireTime, ok := timeClaim(claims[claimExpire])
+	if !ok {
+		return nil, core.ErrTokenInvalid
+	}
+	if now.After(expireTime.Add(leeway)) {
+		return nil, core.ErrTokenExpired
+	}
+
+	if nbf, ok := timeClaim(claims[claimNotBefore]); ok {
+		if now.Add(leeway).Before(nbf) {
+			return nil, core.ErrTokenNotYetValid
+		}
+	}
+
+	var issuedAt, authTime time.Time
+	if iat, ok := timeClaim(claims[claimIssuedAt]); ok {
+		if now.Add(leeway).Before(iat) {
+			return nil, core.ErrTokenNotYetValid
+		}
+		issuedAt = iat
+	}
+	if t, ok := timeClaim(claims[claimAuthTime]); ok {
+		authTime = t
+	}
+
+	if issuer != "" {
+		if iss, _ := claims[claimIssuer].(string); iss != issuer {
+			return nil, core.ErrTokenInvalid
+		}
+	}
+
+	if audience != "" && !audienceContains(claims[claimAudience], audience) {
+		return nil, core.ErrTokenInvalid
+	}
+
+	subject, _ := claims[claimSubject].(string)
+	tokenID, _ := claims[claimID].(string)
+
+	extra := make(map[string]interface{}, len(claims))
+	for k, v := range claims {
+		if _, registered := registeredClaims[k]; !registered {
+			extra[k] = v
+		}
+	}
+	if subject != "" {
+		extra[core.TokenExtraKeyUserID] = subject
+	}
+
+	return &core.TokenInfo{
+		ID:         tokenID,
+		UserID:     subject,
+		ExpireTime: expireTime,
+		IssuedAt:   issuedAt,
+		AuthTime:   authTime,
+		Extra:      extra,
+	}, nil
+}
+
+// timeClaim 读取时间型声明（JWT 使用 Unix 秒，PASETO 使用 RFC3339 字符串）
+func timeClaim(v interface{}) (time.Time, bool) {
+	switch n := v.(type) {
+	case float64:
+		return time.Unix(int64(n), 0), true
+	case int64:
+		return time.Unix(n, 0), true
+	case json.Number:
+		i, err := n.Int64()
+		return time.Unix(i, 0), err == nil
+	case string:
+		t, err := time.Parse(time.RFC3339, n)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// audienceContains 检查 aud 声明是否包含指定受众（支持字符串与数组）
+func audienceContains(v interface{}, audience string) bool {
+	switch aud := v.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, item := range aud {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -15,6 +15,9 @@ import (
 type Generator struct {
 	style      core.TokenStyle
 	customFunc core.CustomTokenFunc
+	config     *core.Config
+	keyring    *Keyring
+	keyringErr error
 }
 
 // NewGenerator 创建Token生成器
@@ -24,6 +27,18 @@ func NewGenerator(style core.TokenStyle) *Generator {
 	}
 }
 
+// NewGeneratorWithConfig 根据框架配置创建Token生成器（签名类风格需要读取密钥与过期时间）
+func NewGeneratorWithConfig(config *core.Config) *Generator {
+	g := &Generator{
+		style:  config.TokenStyle,
+		config: config,
+	}
+	if len(config.SigningKeys) > 0 {
+		g.keyring, g.keyringErr = NewKeyring(config.SigningKeys...)
+	}
+	return g
+}
+
 // Generate 生成Token
 func (g *Generator) Generate(extra map[string]interface{}) (string, error) {
 	switch g.style {
@@ -41,6 +56,12 @@ func (g *Generator) Generate(extra map[string]interface{}) (string, error) {
 		return g.generateTik()
 	case core.StyleCustom:
 		return g.generateCustom(extra)
+	case core.StyleJWT:
+		return g.generateJWT(extra)
+	case core.StylePasetoLocal, core.StylePasetoPublic:
+		return g.generatePaseto(extra)
+	case core.StyleChecksum:
+		return g.generateChecksum()
 	default:
 		return g.generateUUID()
 	}
@@ -48,7 +69,16 @@ func (g *Generator) Generate(extra map[string]interface{}) (string, error) {
 
 // Parse 解析Token
 func (g *Generator) Parse(token string) (*core.TokenInfo, error) {
-	// 这里简化实现，实际应该根据Token风格进行不同的解析
+	switch g.style {
+	case core.StyleJWT:
+		return g.parseJWT(token)
+	case core.StylePasetoLocal, core.StylePasetoPublic:
+		return g.parsePaseto(token)
+	case core.StyleChecksum:
+		return g.parseChecksum(token)
+	}
+
+	// 非自包含风格的Token不携带信息，需要从存储中获取
 	return &core.TokenInfo{
 		UserID:     "", // 需要从存储中获取
 		ExpireTime: time.Now().Add(24 * time.Hour),
@@ -68,6 +98,52 @@ func (g *Generator) Refresh(token string) (string, error) {
 	return g.Generate(tokenInfo.Extra)
 }
 
+// Keyring 获取签名密钥环（未配置时为 nil），可用于运行时轮换密钥
+func (g *Generator) Keyring() *Keyring {
+	return g.keyring
+}
+
+// SetKeyring 设置签名密钥环
+func (g *Generator) SetKeyring(keyring *Keyring) {
+	g.keyring = keyring
+	g.keyringErr = nil
+}
+
+// getKeyring 获取密钥环及其构建错误
+func (g *Generator) getKeyring() (*Keyring, error) {
+	return g.keyring, g.keyringErr
+}
+
+// jwtConfig 获取JWT签名配置
+func (g *Generator) jwtConfig() core.JWTConfig {
+	if g.config == nil {
+		return core.JWTConfig{}
+	}
+	return g.config.JWT
+}
+
+// pasetoConfig 获取PASETO配置
+func (g *Generator) pasetoConfig() core.PasetoConfig {
+	if g.config == nil {
+		return core.PasetoConfig{}
+	}
+	return g.config.Paseto
+}
+
+// expireFor 根据Token类型与设备计算有效期（刷新Token使用刷新有效期）
+func (g *Generator) expireFor(extra map[string]interface{}) time.Duration {
+	if g.config == nil {
+		return 24 * time.Hour
+	}
+	device, _ := extra[core.TokenExtraKeyDevice].(string)
+	if t, _ := extra[core.TokenExtraKeyType].(string); t == core.TokenTypeRefresh {
+		if expire := g.config.RefreshExpireFor(device); expire > 0 {
+			return expire
+		}
+	}
+	return g.config.TokenExpireFor(device)
+}
+
 // generateUUID 生成UUID风格Token
 func (g *Generator) generateUUID() (string, error) {
 	id := uuid.New()
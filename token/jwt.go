@@ -0,0 +1,270 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// jwtHeader JWT头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// signingKey 签名密钥材料
+type signingKey struct {
+	alg     string
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// jwtKey 从配置构造签名密钥
+func jwtKey(cfg core.JWTConfig) *signingKey {
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = core.JWTAlgHS256
+	}
+	key := &signingKey{
+		alg:     alg,
+		secret:  cfg.Secret,
+		private: cfg.PrivateKey,
+		public:  cfg.PublicKey,
+	}
+	if key.public == nil && key.private != nil {
+		key.public = key.private.Public()
+	}
+	return key
+}
+
+// keyringKey 从密钥环条目构造签名密钥
+func keyringKey(k core.SigningKey) *signingKey {
+	return &signingKey{
+		alg:     k.Algorithm,
+		secret:  k.Secret,
+		private: k.PrivateKey,
+		public:  k.PublicKey,
+	}
+}
+
+// jwtSigningKey 选择JWT签名密钥：配置了密钥环时使用当前签名密钥
+func (g *Generator) jwtSigningKey() (*signingKey, string, error) {
+	keyring, err := g.getKeyring()
+	if err != nil {
+		return nil, "", err
+	}
+	if keyring == nil {
+		return jwtKey(g.jwtConfig()), "", nil
+	}
+
+	active, err := keyring.Active()
+	if err != nil {
+		return nil, "", err
+	}
+	return keyringKey(active), active.ID, nil
+}
+
+// jwtVerifyKey 根据头部 kid 选择校验密钥；无 kid 的Token回退到单密钥配置
+func (g *Generator) jwtVerifyKey(kid string) (*signingKey, error) {
+	keyring, err := g.getKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil || kid == "" {
+		return jwtKey(g.jwtConfig()), nil
+	}
+
+	key, err := keyring.Lookup(kid)
+	if err != nil {
+		return nil, core.ErrTokenSignatureInvalid
+	}
+	return keyringKey(key), nil
+}
+
+// generateJWT 生成JWT风格Token
+func (g *Generator) generateJWT(extra map[string]interface{}) (string, error) {
+	cfg := g.jwtConfig()
+	key, kid, err := g.jwtSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	claims := newClaims(extra, cfg.Issuer, cfg.Audience, g.expireFor(extra), func(t time.Time) interface{} {
+		return t.Unix()
+	})
+
+	headerBytes, err := json.Marshal(jwtHeader{Alg: key.alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+	sig, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parseJWT 解析并校验JWT风格Token
+func (g *Generator) parseJWT(token string) (*core.TokenInfo, error) {
+	cfg := g.jwtConfig()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, core.ErrTokenInvalid
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+
+	key, err := g.jwtVerifyKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不接受算法协商：头部算法必须与密钥配置一致
+	if header.Alg != key.alg {
+		return nil, core.ErrTokenSignatureInvalid
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+	if err := key.verify([]byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(claimsBytes, &claims); err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+
+	return buildTokenInfo(claims, cfg.Issuer, cfg.Audience, cfg.Leeway)
+}
+
+// sign 使用签名密钥对数据签名
+func (k *signingKey) sign(data []byte) ([]byte, error) {
+	switch k.alg {
+	case core.JWTAlgHS256:
+		if len(k.secret) == 0 {
+			return nil, core.ErrSigningKeyNotConfigured
+		}
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case core.JWTAlgRS256:
+		priv, ok := k.private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, core.ErrSigningKeyNotConfigured
+		}
+		digest := sha256.Sum256(data)
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	case core.JWTAlgES256:
+		priv, ok := k.private.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return nil, core.ErrSigningKeyNotConfigured
+		}
+		digest := sha256.Sum256(data)
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS 要求 R||S 定长拼接
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case core.JWTAlgEdDSA:
+		priv, ok := k.private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, core.ErrSigningKeyNotConfigured
+		}
+		return ed25519.Sign(priv, data), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", core.ErrAlgorithmNotSupported, k.alg)
+	}
+}
+
+// verify 使用签名密钥校验签名
+func (k *signingKey) verify(data, sig []byte) error {
+	switch k.alg {
+	case core.JWTAlgHS256:
+		if len(k.secret) == 0 {
+			return core.ErrSigningKeyNotConfigured
+		}
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return core.ErrTokenSignatureInvalid
+		}
+		return nil
+	case core.JWTAlgRS256:
+		pub, ok := k.public.(*rsa.PublicKey)
+		if !ok {
+			return core.ErrSigningKeyNotConfigured
+		}
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return core.ErrTokenSignatureInvalid
+		}
+		return nil
+	case core.JWTAlgES256:
+		pub, ok := k.public.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return core.ErrSigningKeyNotConfigured
+		}
+		if len(sig) != 64 {
+			return core.ErrTokenSignatureInvalid
+		}
+		digest := sha256.Sum256(data)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return core.ErrTokenSignatureInvalid
+		}
+		return nil
+	case core.JWTAlgEdDSA:
+		pub, ok := k.public.(ed25519.PublicKey)
+		if !ok {
+			return core.ErrSigningKeyNotConfigured
+		}
+		if !ed25519.Verify(pub, data, sig) {
+			return core.ErrTokenSignatureInvalid
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", core.ErrAlgorithmNotSupported, k.alg)
+	}
+}
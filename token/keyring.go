@@ -0,0 +1,275 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+)
+
+// Keyring 签名密钥环
+//
+// 同一时刻只有一个签名密钥（active），其余密钥仅用于校验（retired）。
+// 密钥可通过 ActivateAt 计划在未来自动启用，旧的签名密钥随之转为仅校验，
+// 因此轮换密钥不会导致已签发的Token失效。
+type Keyring struct {
+	mu       sync.RWMutex
+	keys     map[string]core.SigningKey
+	activeID string
+	since    time.Time // 当前签名密钥的启用时间
+}
+
+// JWK 单个公钥（JWKS 导出格式）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet 公钥集合（JWKS 导出格式）
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeyring 创建密钥环；第一个未计划启用的密钥作为签名密钥
+func NewKeyring(keys ...core.SigningKey) (*Keyring, error) {
+	k := &Keyring{
+		keys: make(map[string]core.SigningKey, len(keys)),
+	}
+	for _, key := range keys {
+		if err := k.Add(key); err != nil {
+			return nil, err
+		}
+		if k.activeID == "" && key.ActivateAt.IsZero() {
+			k.activeID = key.ID
+		}
+	}
+	return k, nil
+}
+
+// Add 添加密钥（仅校验，或按 ActivateAt 计划启用）
+func (k *Keyring) Add(key core.SigningKey) error {
+	if key.ID == "" {
+		return fmt.Errorf("%w: kid不能为空", core.ErrConfigInvalid)
+	}
+	if key.Algorithm == "" {
+		return fmt.Errorf("%w: 密钥 %s 未指定算法", core.ErrConfigInvalid, key.ID)
+	}
+	if key.PublicKey == nil && key.PrivateKey != nil {
+		key.PublicKey = key.PrivateKey.Public()
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, exists := k.keys[key.ID]; exists {
+		return fmt.Errorf("%w: 重复的kid %s", core.ErrConfigInvalid, key.ID)
+	}
+	k.keys[key.ID] = key
+	return nil
+}
+
+// Rotate 添加新密钥并立即切换为签名密钥，原签名密钥转为仅校验
+func (k *Keyring) Rotate(key core.SigningKey) error {
+	if err := k.Add(key); err != nil {
+		return err
+	}
+	return k.SetActive(key.ID)
+}
+
+// ScheduleRotation 计划在指定时间将密钥切换为签名密钥
+func (k *Keyring) ScheduleRotation(kid string, at time.Time) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+	key.ActivateAt = at
+	k.keys[kid] = key
+	return nil
+}
+
+// SetActive 立即将指定密钥设为签名密钥
+func (k *Keyring) SetActive(kid string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[kid]; !ok {
+		return fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+	k.activeID = kid
+	k.since = time.Now()
+	return nil
+}
+
+// Retire 设置密钥的校验截止时间（签名密钥不能退役）
+func (k *Keyring) Retire(kid string, verifyUntil time.Time) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+	if kid == k.activeID {
+		return fmt.Errorf("%w: 不能退役当前签名密钥 %s", core.ErrConfigInvalid, kid)
+	}
+	key.VerifyUntil = verifyUntil
+	k.keys[kid] = key
+	return nil
+}
+
+// Remove 移除密钥（签名密钥不能移除）
+func (k *Keyring) Remove(kid string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if kid == k.activeID {
+		return fmt.Errorf("%w: 不能移除当前签名密钥 %s", core.ErrConfigInvalid, kid)
+	}
+	delete(k.keys, kid)
+	return nil
+}
+
+// Active 获取当前签名密钥（会先应用已到期的计划轮换）
+func (k *Keyring) Active() (core.SigningKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.applySchedule(time.Now())
+
+	key, ok := k.keys[k.activeID]
+	if !ok {
+		return core.SigningKey{}, core.ErrSigningKeyNotConfigured
+	}
+	return key, nil
+}
+
+// Lookup 根据 kid 获取可用于校验的密钥
+func (k *Keyring) Lookup(kid string) (core.SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return core.SigningKey{}, fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+	if !key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil) {
+		return core.SigningKey{}, fmt.Errorf("%w: %s", core.ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// verifySecrets 获取未过校验截止时间的对称密钥，当前签名密钥排在最前
+func (k *Keyring) verifySecrets() [][]byte {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	k.applySchedule(now)
+
+	var secrets [][]byte
+	if active, ok := k.keys[k.activeID]; ok && len(active.Secret) > 0 {
+		secrets = append(secrets, active.Secret)
+	}
+	for id, key := range k.keys {
+		if id == k.activeID || len(key.Secret) == 0 {
+			continue
+		}
+		if !key.VerifyUntil.IsZero() && now.After(key.VerifyUntil) {
+			continue
+		}
+		secrets = append(secrets, key.Secret)
+	}
+	return secrets
+}
+
+// applySchedule 应用已到期的计划轮换，选择启用时间最晚的到期密钥
+func (k *Keyring) applySchedule(now time.Time) {
+	for id, key := range k.keys {
+		if key.ActivateAt.IsZero() || key.ActivateAt.After(now) {
+			continue
+		}
+		if key.ActivateAt.After(k.since) {
+			k.activeID = id
+			k.since = key.ActivateAt
+		}
+	}
+}
+
+// JWKS 导出非对称公钥集合（对称密钥不导出）
+func (k *Keyring) JWKS() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range ids {
+		key := k.keys[id]
+		if !key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil) {
+			continue
+		}
+		jwk, err := publicJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		if jwk != nil {
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return json.Marshal(set)
+}
+
+// publicJWK 将公钥转换为 JWK；对称密钥返回 nil
+func publicJWK(key core.SigningKey) (*JWK, error) {
+	enc := base64.RawURLEncoding
+	jwk := &JWK{Kid: key.ID, Alg: key.Algorithm, Use: "sig"}
+
+	switch pub := key.PublicKey.(type) {
+	case nil:
+		return nil, nil
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		// 未压缩点格式：0x04 || X || Y
+		raw := ecdh.Bytes()
+		size := (len(raw) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc.EncodeToString(raw[1 : 1+size])
+		jwk.Y = enc.EncodeToString(raw[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return nil, fmt.Errorf("%w: 密钥 %s 的公钥类型", core.ErrAlgorithmNotSupported, key.ID)
+	}
+	return jwk, nil
+}
@@ -0,0 +1,406 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/luckxgo/gstoken/core"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// PASETO v4 头部
+const (
+	pasetoLocalHeader  = "v4.local."
+	pasetoPublicHeader = "v4.public."
+)
+
+// PASETO v4.local 密钥派生常量
+const (
+	pasetoEncKeyInfo  = "paseto-encryption-key"
+	pasetoAuthKeyInfo = "paseto-auth-key-for-aead"
+	pasetoNonceSize   = 32
+	pasetoMacSize     = 32
+	pasetoLocalKeyLen = 32
+)
+
+// pasetoKey PASETO 密钥材料
+type pasetoKey struct {
+	local   []byte
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// pasetoKeyFromSigningKey 从密钥环条目构造 PASETO 密钥，用途需与当前风格一致
+func (g *Generator) pasetoKeyFromSigningKey(k core.SigningKey) (*pasetoKey, error) {
+	switch {
+	case g.style == core.StylePasetoLocal && k.Algorithm == core.KeyAlgPasetoLocal:
+		return &pasetoKey{local: k.Secret}, nil
+	case g.style == core.StylePasetoPublic && k.Algorithm == core.KeyAlgPasetoPublic:
+		key := &pasetoKey{}
+		key.private, _ = k.PrivateKey.(ed25519.PrivateKey)
+		key.public, _ = k.PublicKey.(ed25519.PublicKey)
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: 密钥 %s 的用途 %s", core.ErrAlgorithmNotSupported, k.ID, k.Algorithm)
+	}
+}
+
+// pasetoSigningKey 选择签发密钥：配置了密钥环时使用当前签名密钥
+func (g *Generator) pasetoSigningKey() (*pasetoKey, string, error) {
+	keyring, err := g.getKeyring()
+	if err != nil {
+		return nil, "", err
+	}
+	if keyring == nil {
+		cfg := g.pasetoConfig()
+		return &pasetoKey{local: cfg.LocalKey, private: cfg.PrivateKey, public: cfg.PublicKey}, "", nil
+	}
+
+	active, err := keyring.Active()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := g.pasetoKeyFromSigningKey(active)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, active.ID, nil
+}
+
+// pasetoVerifyKey 根据页脚中的 kid 选择校验密钥；无 kid 的Token回退到单密钥配置
+func (g *Generator) pasetoVerifyKey(kid string) (*pasetoKey, error) {
+	keyring, err := g.getKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil || kid == "" {
+		cfg := g.pasetoConfig()
+		key := &pasetoKey{local: cfg.LocalKey, private: cfg.PrivateKey, public: cfg.PublicKey}
+		return key, nil
+	}
+
+	entry, err := keyring.Lookup(kid)
+	if err != nil {
+		return nil, core.ErrTokenSignatureInvalid
+	}
+	return g.pasetoKeyFromSigningKey(entry)
+}
+
+// generatePaseto 生成PASETO v4风格Token
+func (g *Generator) generatePaseto(extra map[string]interface{}) (string, error) {
+	cfg := g.pasetoConfig()
+
+	key, kid, err := g.pasetoSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	claims := newClaims(extra, cfg.Issuer, cfg.Audience, g.expireFor(extra), func(t time.Time) interface{} {
+		return t.UTC().Format(time.RFC3339)
+	})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	footer, err := pasetoFooter(cfg.Footer, kid)
+	if err != nil {
+		return "", err
+	}
+	implicit := []byte(cfg.ImplicitAssertion)
+
+	if g.style == core.StylePasetoLocal {
+		return pasetoEncrypt(key.local, payload, footer, implicit)
+	}
+	return pasetoSign(key.private, payload, footer, implicit)
+}
+
+// parsePaseto 解析并校验PASETO v4风格Token
+func (g *Generator) parsePaseto(token string) (*core.TokenInfo, error) {
+	cfg := g.pasetoConfig()
+	implicit := []byte(cfg.ImplicitAssertion)
+
+	header := pasetoPublicHeader
+	if g.style == core.StylePasetoLocal {
+		header = pasetoLocalHeader
+	}
+
+	// 页脚为明文，先读取 kid 选择密钥，随后由认证标签保证其完整性
+	_, footer, err := pasetoDecode(header, token)
+	if err != nil {
+		return nil, err
+	}
+	kid := pasetoFooterKid(footer)
+
+	key, err := g.pasetoVerifyKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	if g.style == core.StylePasetoLocal {
+		payload, footer, err = pasetoDecrypt(key.local, token, implicit)
+	} else {
+		publicKey := key.public
+		if publicKey == nil && key.private != nil {
+			publicKey = key.private.Public().(ed25519.PublicKey)
+		}
+		payload, footer, err = pasetoVerify(publicKey, token, implicit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置了页脚时要求Token页脚一致，防止跨用途复用
+	if cfg.Footer != "" {
+		expected, err := pasetoFooter(cfg.Footer, kid)
+		if err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare(footer, expected) != 1 {
+			return nil, core.ErrTokenInvalid
+		}
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, core.ErrTokenInvalid
+	}
+
+	tokenInfo, err := buildTokenInfo(claims, cfg.Issuer, cfg.Audience, cfg.Leeway)
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo.Footer = string(footer)
+	return tokenInfo, nil
+}
+
+// pasetoFooter 构造页脚；使用密钥环时页脚为 JSON 对象并写入 kid
+func pasetoFooter(configured, kid string) ([]byte, error) {
+	if kid == "" {
+		return []byte(configured), nil
+	}
+
+	fields := make(map[string]interface{})
+	if configured != "" {
+		if err := json.Unmarshal([]byte(configured), &fields); err != nil {
+			return nil, fmt.Errorf("%w: 使用密钥环时页脚必须为JSON对象", core.ErrConfigInvalid)
+		}
+	}
+	fields["kid"] = kid
+	return json.Marshal(fields)
+}
+
+// pasetoFooterKid 从页脚中读取 kid
+func pasetoFooterKid(footer []byte) string {
+	if len(footer) == 0 || footer[0] != '{' {
+		return ""
+	}
+	var fields struct {
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(footer, &fields); err != nil {
+		return ""
+	}
+	return fields.Kid
+}
+
+// pasetoEncrypt v4.local 加密
+func pasetoEncrypt(key, message, footer, implicit []byte) (string, error) {
+	if len(key) != pasetoLocalKeyLen {
+		return "", core.ErrSigningKeyNotConfigured
+	}
+
+	nonce := make([]byte, pasetoNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	encKey, counterNonce, authKey, err := pasetoSplitKey(key, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(message))
+	cipher.XORKeyStream(ciphertext, message)
+
+	mac, err := pasetoMac(authKey, pae([]byte(pasetoLocalHeader), nonce, ciphertext, footer, implicit))
+	if err != nil {
+		return "", err
+	}
+
+	body := make([]byte, 0, len(nonce)+len(ciphertext)+len(mac))
+	body = append(body, nonce...)
+	body = append(body, ciphertext...)
+	body = append(body, mac...)
+
+	return pasetoEncode(pasetoLocalHeader, body, footer), nil
+}
+
+// pasetoDecrypt v4.local 解密
+func pasetoDecrypt(key []byte, token string, implicit []byte) ([]byte, []byte, error) {
+	if len(key) != pasetoLocalKeyLen {
+		return nil, nil, core.ErrSigningKeyNotConfigured
+	}
+
+	body, footer, err := pasetoDecode(pasetoLocalHeader, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) < pasetoNonceSize+pasetoMacSize {
+		return nil, nil, core.ErrTokenInvalid
+	}
+
+	nonce := body[:pasetoNonceSize]
+	ciphertext := body[pasetoNonceSize : len(body)-pasetoMacSize]
+	mac := body[len(body)-pasetoMacSize:]
+
+	encKey, counterNonce, authKey, err := pasetoSplitKey(key, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expected, err := pasetoMac(authKey, pae([]byte(pasetoLocalHeader), nonce, ciphertext, footer, implicit))
+	if err != nil {
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare(mac, expected) != 1 {
+		return nil, nil, core.ErrTokenSignatureInvalid
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	message := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(message, ciphertext)
+
+	return message, footer, nil
+}
+
+// pasetoSplitKey 派生加密密钥、XChaCha20 随机数与认证密钥
+func pasetoSplitKey(key, nonce []byte) (encKey, counterNonce, authKey []byte, err error) {
+	h, err := blake2b.New(32+chacha20.NonceSizeX, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte(pasetoEncKeyInfo))
+	h.Write(nonce)
+	tmp := h.Sum(nil)
+
+	authKey, err = pasetoMac(key, append([]byte(pasetoAuthKeyInfo), nonce...))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return tmp[:32], tmp[32:], authKey, nil
+}
+
+// pasetoMac 计算 BLAKE2b-256 带密钥摘要
+func pasetoMac(key, data []byte) ([]byte, error) {
+	h, err := blake2b.New(pasetoMacSize, key)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// pasetoSign v4.public 签名
+func pasetoSign(privateKey ed25519.PrivateKey, message, footer, implicit []byte) (string, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return "", core.ErrSigningKeyNotConfigured
+	}
+
+	sig := ed25519.Sign(privateKey, pae([]byte(pasetoPublicHeader), message, footer, implicit))
+
+	body := make([]byte, 0, len(message)+len(sig))
+	body = append(body, message...)
+	body = append(body, sig...)
+
+	return pasetoEncode(pasetoPublicHeader, body, footer), nil
+}
+
+// pasetoVerify v4.public 验签
+func pasetoVerify(publicKey ed25519.PublicKey, token string, implicit []byte) ([]byte, []byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, nil, core.ErrSigningKeyNotConfigured
+	}
+
+	body, footer, err := pasetoDecode(pasetoPublicHeader, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) < ed25519.SignatureSize {
+		return nil, nil, core.ErrTokenInvalid
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	sig := body[len(body)-ed25519.SignatureSize:]
+
+	if !ed25519.Verify(publicKey, pae([]byte(pasetoPublicHeader), message, footer, implicit), sig) {
+		return nil, nil, core.ErrTokenSignatureInvalid
+	}
+
+	return message, footer, nil
+}
+
+// pasetoEncode 拼接头部、载荷与可选页脚
+func pasetoEncode(header string, body, footer []byte) string {
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// pasetoDecode 校验头部并拆分载荷与页脚
+func pasetoDecode(header, token string) ([]byte, []byte, error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, core.ErrTokenInvalid
+	}
+
+	parts := strings.Split(token[len(header):], ".")
+	if len(parts) > 2 {
+		return nil, nil, core.ErrTokenInvalid
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, core.ErrTokenInvalid
+	}
+
+	var footer []byte
+	if len(parts) == 2 {
+		if footer, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			return nil, nil, core.ErrTokenInvalid
+		}
+	}
+
+	return body, footer, nil
+}
+
+// pae 预认证编码（Pre-Authentication Encoding）
+func pae(pieces ...[]byte) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(len(pieces))&^(1<<63))
+	for _, p := range pieces {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p))&^(1<<63))
+		buf = append(buf, n[:]...)
+		buf = append(buf, p...)
+	}
+	return buf
+}
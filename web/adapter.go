@@ -2,8 +2,12 @@ package web
 
 import (
 	"context"
+	"errors"
+	"math"
+	"net"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/luckxgo/gstoken/core"
@@ -93,6 +97,22 @@ type AuthMiddleware interface {
 	OptionalAuth() MiddlewareFunc
 }
 
+// SecurityMiddleware 安全相关中间件接口（可选实现）
+// 独立于 AuthMiddleware 定义，已有的 AuthMiddleware 实现不受影响；BaseAuthMiddleware 同时实现两者
+type SecurityMiddleware interface {
+	// RequireNotDisabled 要求账号未在指定服务上被封禁的中间件
+	RequireNotDisabled(service string) MiddlewareFunc
+
+	// RequireSafe 要求Token处于指定服务二级认证窗口内的中间件
+	RequireSafe(service string) MiddlewareFunc
+
+	// RequireAPIKey 仅接受 API Key 认证的中间件，用于机器客户端专用的路由
+	RequireAPIKey() MiddlewareFunc
+
+	// LimitLoginAttempts 登录路由使用的中间件，客户端IP因登录失败过多被锁定时拒绝请求
+	LimitLoginAttempts() MiddlewareFunc
+}
+
 // MiddlewareFunc 中间件函数类型
 type MiddlewareFunc func(WebContext)
 
@@ -102,6 +122,13 @@ type AuthConfig struct {
 	TokenHeader string // Token 在请求头中的字段名，默认 "Authorization"
 	TokenQuery  string // Token 在查询参数中的字段名，默认 "token"
 	TokenPrefix string // Token 前缀，默认 "Bearer "
+	TokenCookie string // Token 所在的 Cookie 名，为空时不从 Cookie 读取；同域 SSO 共享登录态时使用
+
+	// API Key 请求头字段名，默认 "X-API-Key"，为空时不接受 API Key
+	APIKeyHeader string
+
+	// 是否接受 OAuth2 访问Token，默认不接受；开启后授权范围受限的访问Token只能通过 RequireAuth 与权限检查，不能通过角色检查
+	AcceptOAuth2Token bool
 
 	// 跳过认证的路径
 	SkipPaths []string
@@ -112,22 +139,32 @@ type AuthConfig struct {
 
 	// 用户信息提取器
 	UserInfoExtractor func(ctx context.Context, token string) (*core.UserInfo, error)
+
+	// 客户端IP提取器，为空时优先使用框架上下文的 ClientIP，否则取 RemoteAddr
+	ClientIPExtractor func(WebContext) string
 }
 
 // DefaultAuthConfig 默认认证配置
 func DefaultAuthConfig() *AuthConfig {
 	return &AuthConfig{
-		TokenHeader: HeaderAuthorization,
-		TokenQuery:  QueryParamToken,
-		TokenPrefix: BearerPrefix,
-		SkipPaths:   []string{},
+		TokenHeader:  HeaderAuthorization,
+		TokenQuery:   QueryParamToken,
+		TokenPrefix:  BearerPrefix,
+		APIKeyHeader: HeaderXAPIKey,
+		SkipPaths:    []string{},
 		UnauthorizedHandler: func(c WebContext, err error) {
+			if abortWithErrorCode(c, err) {
+				return
+			}
 			c.AbortWithJSON(http.StatusUnauthorized, map[string]interface{}{
 				"error":      ErrorUnauthorized,
 				ErrorMessage: err.Error(),
 			})
 		},
 		ForbiddenHandler: func(c WebContext, err error) {
+			if abortWithErrorCode(c, err) {
+				return
+			}
 			c.AbortWithJSON(http.StatusForbidden, map[string]interface{}{
 				"error":      ErrorForbidden,
 				ErrorMessage: err.Error(),
@@ -136,6 +173,34 @@ func DefaultAuthConfig() *AuthConfig {
 	}
 }
 
+// abortWithErrorCode 对需要客户端区别处理的错误返回独立的错误码
+// 账号被封禁返回 403 user_disabled；需要二级认证返回 401 safe_auth_required，提示客户端重新认证；
+// 登录失败次数过多返回 429 too_many_attempts，并通过 Retry-After 头告知剩余锁定秒数
+func abortWithErrorCode(c WebContext, err error) bool {
+	var tooMany *core.TooManyAttemptsError
+	switch {
+	case errors.As(err, &tooMany):
+		c.SetHeader(HeaderRetryAfter, strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+		c.AbortWithJSON(http.StatusTooManyRequests, map[string]interface{}{
+			"error":      ErrorTooManyAttempts,
+			ErrorMessage: err.Error(),
+		})
+	case errors.Is(err, core.ErrUserDisabled):
+		c.AbortWithJSON(http.StatusForbidden, map[string]interface{}{
+			"error":      ErrorUserDisabled,
+			ErrorMessage: err.Error(),
+		})
+	case errors.Is(err, core.ErrSafeAuthRequired):
+		c.AbortWithJSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":      ErrorSafeRequired,
+			ErrorMessage: err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
 // BaseAuthMiddleware 基础认证中间件实现
 type BaseAuthMiddleware struct {
 	gsToken GSTokenAdapter
@@ -157,6 +222,49 @@ type GSTokenAdapter interface {
 	GetLoginInfo(ctx context.Context, token string) (*core.LoginInfo, error)
 }
 
+// TokenFormatValidator 可选的本地Token格式校验接口
+// 适配器实现该接口后，中间件会在调用 Verify（访问存储）之前先在进程内拒绝伪造的Token
+type TokenFormatValidator interface {
+	ValidateTokenFormat(token string) error
+}
+
+// DisableChecker 可选的封禁检查接口
+// 适配器实现该接口后，可使用 RequireNotDisabled 按服务范围拦截被封禁的账号；未实现时该中间件拒绝所有请求
+type DisableChecker interface {
+	CheckDisabled(ctx context.Context, userID, service string) error
+}
+
+// SafeChecker 可选的二级认证检查接口
+// 适配器实现该接口后，可使用 RequireSafe 保护敏感操作
+type SafeChecker interface {
+	IsSafe(ctx context.Context, token, service string) (bool, error)
+}
+
+// LoginAttemptChecker 可选的登录失败限制检查接口
+// 适配器实现该接口后，可使用 LimitLoginAttempts 在解析登录请求之前拒绝被锁定的IP
+type LoginAttemptChecker interface {
+	CheckLoginAttempts(ctx context.Context, username, ip string) error
+}
+
+// APIKeyVerifier 可选的 API Key 校验接口
+// 适配器实现该接口后，未携带Token的请求可通过 API Key 请求头认证，以 Key 所有者的身份通过各 Require* 中间件；
+// 权限检查同时受 Key 的授权范围限制，角色检查使用所有者的角色
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*core.UserInfo, error)
+}
+
+// AccessTokenVerifier 可选的 OAuth2 访问Token校验接口
+// 适配器实现该接口且 AuthConfig.AcceptOAuth2Token 开启时，登录会话校验失败的 Bearer Token 会再按 OAuth2 访问Token校验，
+// 权限检查同时受Token的 scope 限制
+type AccessTokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*core.UserInfo, error)
+}
+
+// clientIPProvider 可选的客户端IP获取接口，由框架上下文实现（GinContext 经嵌入的 *gin.Context 按可信代理解析）
+type clientIPProvider interface {
+	ClientIP() string
+}
+
 // NewBaseAuthMiddleware 创建基础认证中间件
 func NewBaseAuthMiddleware(gsToken GSTokenAdapter, config *AuthConfig) *BaseAuthMiddleware {
 	if config == nil {
@@ -189,23 +297,103 @@ func (m *BaseAuthMiddleware) extractToken(c WebContext) string {
 		}
 	}
 
+	// 从 Cookie 提取
+	if m.config.TokenCookie != "" {
+		if req := c.GetRequest(); req != nil {
+			if cookie, err := req.Cookie(m.config.TokenCookie); err == nil && cookie.Value != "" {
+				return cookie.Value
+			}
+		}
+	}
+
 	return ""
 }
 
+// verify 校验Token：先做本地格式校验，再调用适配器校验
+func (m *BaseAuthMiddleware) verify(ctx context.Context, token string) (*core.UserInfo, error) {
+	if validator, ok := m.gsToken.(TokenFormatValidator); ok {
+		if err := validator.ValidateTokenFormat(token); err != nil {
+			return nil, err
+		}
+	}
+	return m.gsToken.Verify(ctx, token)
+}
+
+// extractAPIKey 从请求头提取 API Key
+func (m *BaseAuthMiddleware) extractAPIKey(c WebContext) string {
+	if m.config.APIKeyHeader == "" {
+		return ""
+	}
+	return c.GetHeader(m.config.APIKeyHeader)
+}
+
+// verifyAPIKey 通过适配器校验 API Key，适配器不支持时视为无效
+func (m *BaseAuthMiddleware) verifyAPIKey(ctx context.Context, key string) (*core.UserInfo, error) {
+	verifier, ok := m.gsToken.(APIKeyVerifier)
+	if !ok {
+		return nil, core.ErrAPIKeyInvalid
+	}
+	return verifier.VerifyAPIKey(ctx, key)
+}
+
+// authenticate 认证请求：优先使用Token（登录会话，开启 AcceptOAuth2Token 时还包括 OAuth2 访问Token），
+// 未携带Token时使用 API Key，返回的 token 在 API Key 认证时为空
+func (m *BaseAuthMiddleware) authenticate(c WebContext) (*core.UserInfo, string, error) {
+	if token := m.extractToken(c); token != "" {
+		userInfo, err := m.verify(c.GetContext(), token)
+		if err != nil && m.config.AcceptOAuth2Token {
+			if verifier, ok := m.gsToken.(AccessTokenVerifier); ok {
+				// 不是 OAuth2 访问Token时返回登录会话的校验错误
+				if info, verr := verifier.VerifyAccessToken(c.GetContext(), token); !errors.Is(verr, core.ErrOAuthTokenInvalid) {
+					return info, token, verr
+				}
+			}
+		}
+		return userInfo, token, err
+	}
+	if key := m.extractAPIKey(c); key != "" {
+		userInfo, err := m.verifyAPIKey(c.GetContext(), key)
+		return userInfo, "", err
+	}
+	return nil, "", core.ErrTokenNotFound
+}
+
+// setAuthContext 将认证结果写入上下文，API Key 认证时不写入 ContextKeyToken
+func (m *BaseAuthMiddleware) setAuthContext(c WebContext, userInfo *core.UserInfo, token string) {
+	c.Set(ContextKeyUserID, userInfo.ID)
+	c.Set(ContextKeyUserInfo, userInfo)
+	if token != "" {
+		c.Set(ContextKeyToken, token)
+	}
+	if userInfo.APIKeyID != "" {
+		c.Set(ContextKeyAPIKeyID, userInfo.APIKeyID)
+	}
+}
+
+// checkPermission 检查权限，API Key 与 OAuth2 访问Token认证时权限还需在授权范围内
+func (m *BaseAuthMiddleware) checkPermission(ctx context.Context, userInfo *core.UserInfo, permission string) (bool, error) {
+	if !userInfo.ScopeAllows(permission) {
+		return false, nil
+	}
+	return m.gsToken.CheckPermission(ctx, userInfo.ID, permission)
+}
+
+// checkRole 检查角色，授权范围受限的 API Key 与 OAuth2 访问Token不能通过角色检查
+func (m *BaseAuthMiddleware) checkRole(ctx context.Context, userInfo *core.UserInfo, role string) (bool, error) {
+	if !userInfo.ScopeAllowsRoles() {
+		return false, nil
+	}
+	return m.gsToken.CheckRole(ctx, userInfo.ID, role)
+}
+
 // softAuth 在不强制鉴权情况下尽可能提取用户信息（不阻断流程）
 func (m *BaseAuthMiddleware) softAuth(c WebContext) {
-	token := m.extractToken(c)
-	if token == "" {
-		return
-	}
-	if userInfo, err := m.gsToken.Verify(c.GetContext(), token); err == nil && userInfo != nil {
+	if userInfo, token, err := m.authenticate(c); err == nil && userInfo != nil {
 		// 将用户信息存储到上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 如果配置了用户信息提取器，获取完整用户信息
-		if m.config.UserInfoExtractor != nil {
+		if m.config.UserInfoExtractor != nil && token != "" && userInfo.ClientID == "" {
 			if ui, err := m.config.UserInfoExtractor(c.GetContext(), token); err == nil && ui != nil {
 				c.Set(ContextKeyUserInfo, ui)
 			}
@@ -213,6 +401,21 @@ func (m *BaseAuthMiddleware) softAuth(c WebContext) {
 	}
 }
 
+// clientIP 获取客户端IP
+func (m *BaseAuthMiddleware) clientIP(c WebContext) string {
+	if m.config.ClientIPExtractor != nil {
+		return m.config.ClientIPExtractor(c)
+	}
+	if provider, ok := c.(clientIPProvider); ok {
+		return provider.ClientIP()
+	}
+	remoteAddr := c.GetRequest().RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
 // shouldSkip 检查是否应该跳过认证
 func (m *BaseAuthMiddleware) shouldSkip(c WebContext) bool {
 	reqPath := c.GetRequest().URL.Path
@@ -248,25 +451,17 @@ func (m *BaseAuthMiddleware) RequireAuth() MiddlewareFunc {
 			return
 		}
 
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 
 		// 将用户信息存储到上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
-		// 如果配置了用户信息提取器，获取完整用户信息
-		if m.config.UserInfoExtractor != nil {
+		// 如果配置了用户信息提取器，获取完整用户信息（API Key 与 OAuth2 认证时保留授权范围）
+		if m.config.UserInfoExtractor != nil && token != "" && userInfo.ClientID == "" {
 			userInfo, err := m.config.UserInfoExtractor(c.GetContext(), token)
 			if err == nil {
 				c.Set(ContextKeyUserInfo, userInfo)
@@ -287,19 +482,13 @@ func (m *BaseAuthMiddleware) RequirePermission(permission string) MiddlewareFunc
 			return
 		}
 
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 
-		hasPermission, err := m.gsToken.CheckPermission(c.GetContext(), userInfo.ID, permission)
+		hasPermission, err := m.checkPermission(c.GetContext(), userInfo, permission)
 		if err != nil {
 			m.config.ForbiddenHandler(c, err)
 			return
@@ -311,9 +500,7 @@ func (m *BaseAuthMiddleware) RequirePermission(permission string) MiddlewareFunc
 		}
 
 		// 将用户信息存储到上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		c.Next()
 	}
@@ -327,19 +514,13 @@ func (m *BaseAuthMiddleware) RequireRole(role string) MiddlewareFunc {
 			return
 		}
 
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 
-		hasRole, err := m.gsToken.CheckRole(c.GetContext(), userInfo.ID, role)
+		hasRole, err := m.checkRole(c.GetContext(), userInfo, role)
 		if err != nil {
 			m.config.ForbiddenHandler(c, err)
 			return
@@ -351,9 +532,7 @@ func (m *BaseAuthMiddleware) RequireRole(role string) MiddlewareFunc {
 		}
 
 		// 将用户信息存储到上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		c.Next()
 	}
@@ -370,24 +549,17 @@ func (m *BaseAuthMiddleware) RequireAnyPermission(permissions ...string) Middlew
 		}
 
 		// 内联认证，避免提前放行
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 		// 写入上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 任意权限命中则通过
 		for _, permission := range permissions {
-			hasPermission, err := m.gsToken.CheckPermission(c.GetContext(), userInfo.ID, permission)
+			hasPermission, err := m.checkPermission(c.GetContext(), userInfo, permission)
 			if err == nil && hasPermission {
 				c.Next()
 				return
@@ -410,24 +582,17 @@ func (m *BaseAuthMiddleware) RequireAllPermissions(permissions ...string) Middle
 		}
 
 		// 内联认证，避免提前放行
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 		// 写入上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 所有权限均需命中
 		for _, permission := range permissions {
-			hasPermission, err := m.gsToken.CheckPermission(c.GetContext(), userInfo.ID, permission)
+			hasPermission, err := m.checkPermission(c.GetContext(), userInfo, permission)
 			if err != nil || !hasPermission {
 				m.config.ForbiddenHandler(c, core.ErrPermissionDenied)
 				return
@@ -449,24 +614,17 @@ func (m *BaseAuthMiddleware) RequireAnyRole(roles ...string) MiddlewareFunc {
 		}
 
 		// 内联认证
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 		// 写入上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 任意角色命中则通过
 		for _, role := range roles {
-			hasRole, err := m.gsToken.CheckRole(c.GetContext(), userInfo.ID, role)
+			hasRole, err := m.checkRole(c.GetContext(), userInfo, role)
 			if err == nil && hasRole {
 				c.Next()
 				return
@@ -488,24 +646,17 @@ func (m *BaseAuthMiddleware) RequireAllRoles(roles ...string) MiddlewareFunc {
 		}
 
 		// 内联认证
-		token := m.extractToken(c)
-		if token == "" {
-			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-			return
-		}
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			m.config.UnauthorizedHandler(c, err)
 			return
 		}
 		// 写入上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 所有角色均需命中
 		for _, role := range roles {
-			hasRole, err := m.gsToken.CheckRole(c.GetContext(), userInfo.ID, role)
+			hasRole, err := m.checkRole(c.GetContext(), userInfo, role)
 			if err != nil || !hasRole {
 				m.config.ForbiddenHandler(c, core.ErrRoleNotFound)
 				return
@@ -524,41 +675,34 @@ func (m *BaseAuthMiddleware) RequireRoleOrPermission(roles []string, permissions
 			m.softAuth(c)
 		} else {
 			// 强认证
-			token := m.extractToken(c)
-			if token == "" {
-				m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
-				return
-			}
-			userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+			userInfo, token, err := m.authenticate(c)
 			if err != nil {
 				m.config.UnauthorizedHandler(c, err)
 				return
 			}
 			// 写入上下文
-			c.Set(ContextKeyUserID, userInfo.ID)
-			c.Set(ContextKeyToken, token)
-			c.Set(ContextKeyUserInfo, userInfo)
+			m.setAuthContext(c, userInfo, token)
 		}
 
-		// 读取用户ID（可能来自软认证）
-		userIDVal, ok := c.Get(ContextKeyUserID)
-		if !ok || userIDVal == nil {
+		// 读取用户信息（可能来自软认证）
+		userInfoVal, ok := c.Get(ContextKeyUserInfo)
+		if !ok || userInfoVal == nil {
 			m.config.UnauthorizedHandler(c, core.ErrTokenNotFound)
 			return
 		}
-		userID := userIDVal.(string)
+		userInfo := userInfoVal.(*core.UserInfo)
 
 		// 只要任一满足即可
 		hasAnyRole := false
 		for _, role := range roles {
-			if ok, err := m.gsToken.CheckRole(c.GetContext(), userID, role); err == nil && ok {
+			if ok, err := m.checkRole(c.GetContext(), userInfo, role); err == nil && ok {
 				hasAnyRole = true
 				break
 			}
 		}
 		hasAnyPerm := false
 		for _, p := range permissions {
-			if ok, err := m.gsToken.CheckPermission(c.GetContext(), userID, p); err == nil && ok {
+			if ok, err := m.checkPermission(c.GetContext(), userInfo, p); err == nil && ok {
 				hasAnyPerm = true
 				break
 			}
@@ -581,25 +725,17 @@ func (m *BaseAuthMiddleware) OptionalAuth() MiddlewareFunc {
 			return
 		}
 
-		token := m.extractToken(c)
-		if token == "" {
-			c.Next()
-			return
-		}
-
-		userInfo, err := m.gsToken.Verify(c.GetContext(), token)
+		userInfo, token, err := m.authenticate(c)
 		if err != nil {
 			c.Next()
 			return
 		}
 
 		// 将用户信息存储到上下文
-		c.Set(ContextKeyUserID, userInfo.ID)
-		c.Set(ContextKeyToken, token)
-		c.Set(ContextKeyUserInfo, userInfo)
+		m.setAuthContext(c, userInfo, token)
 
 		// 如果配置了用户信息提取器，获取完整用户信息
-		if m.config.UserInfoExtractor != nil {
+		if m.config.UserInfoExtractor != nil && token != "" && userInfo.ClientID == "" {
 			userInfo, err := m.config.UserInfoExtractor(c.GetContext(), token)
 			if err == nil {
 				c.Set(ContextKeyUserInfo, userInfo)
@@ -609,3 +745,123 @@ func (m *BaseAuthMiddleware) OptionalAuth() MiddlewareFunc {
 		c.Next()
 	}
 }
+
+// RequireNotDisabled 要求账号未在指定服务上被封禁的中间件
+// 全部服务的封禁在 Verify 中已拦截，该中间件用于按服务范围（如 "comments"）拦截
+func (m *BaseAuthMiddleware) RequireNotDisabled(service string) MiddlewareFunc {
+	return func(c WebContext) {
+		if m.shouldSkip(c) {
+			m.softAuth(c)
+			c.Next()
+			return
+		}
+
+		userInfo, token, err := m.authenticate(c)
+		if err != nil {
+			m.config.UnauthorizedHandler(c, err)
+			return
+		}
+
+		// 无法检查封禁状态时拒绝请求，避免配置错误导致封禁失效
+		checker, ok := m.gsToken.(DisableChecker)
+		if !ok {
+			m.config.ForbiddenHandler(c, core.ErrDisableCheckNotSupported)
+			return
+		}
+		if err := checker.CheckDisabled(c.GetContext(), userInfo.ID, service); err != nil {
+			m.config.ForbiddenHandler(c, err)
+			return
+		}
+
+		// 将用户信息存储到上下文
+		m.setAuthContext(c, userInfo, token)
+
+		c.Next()
+	}
+}
+
+// RequireSafe 要求Token处于指定服务二级认证窗口内的中间件
+// 未开启或已过期时以 core.ErrSafeAuthRequired 调用 UnauthorizedHandler，默认返回 401 safe_auth_required
+func (m *BaseAuthMiddleware) RequireSafe(service string) MiddlewareFunc {
+	return func(c WebContext) {
+		if m.shouldSkip(c) {
+			m.softAuth(c)
+			c.Next()
+			return
+		}
+
+		userInfo, token, err := m.authenticate(c)
+		if err != nil {
+			m.config.UnauthorizedHandler(c, err)
+			return
+		}
+
+		// 二级认证窗口绑定Token，API Key 认证的请求不能通过
+		checker, ok := m.gsToken.(SafeChecker)
+		if !ok || token == "" {
+			m.config.UnauthorizedHandler(c, core.ErrSafeAuthRequired)
+			return
+		}
+		safe, err := checker.IsSafe(c.GetContext(), token, service)
+		if err != nil {
+			m.config.UnauthorizedHandler(c, err)
+			return
+		}
+		if !safe {
+			m.config.UnauthorizedHandler(c, core.ErrSafeAuthRequired)
+			return
+		}
+
+		// 将用户信息存储到上下文
+		m.setAuthContext(c, userInfo, token)
+
+		c.Next()
+	}
+}
+
+// RequireAPIKey 仅接受 API Key 认证的中间件，携带Token的请求同样需要提供 API Key
+// Key 缺失或无效时以 core.ErrAPIKeyInvalid、已过期时以 core.ErrAPIKeyExpired 调用 UnauthorizedHandler
+func (m *BaseAuthMiddleware) RequireAPIKey() MiddlewareFunc {
+	return func(c WebContext) {
+		if m.shouldSkip(c) {
+			c.Next()
+			return
+		}
+
+		key := m.extractAPIKey(c)
+		if key == "" {
+			m.config.UnauthorizedHandler(c, core.ErrAPIKeyInvalid)
+			return
+		}
+
+		userInfo, err := m.verifyAPIKey(c.GetContext(), key)
+		if err != nil {
+			m.config.UnauthorizedHandler(c, err)
+			return
+		}
+
+		// 将用户信息存储到上下文
+		m.setAuthContext(c, userInfo, "")
+
+		c.Next()
+	}
+}
+
+// LimitLoginAttempts 登录路由使用的中间件，客户端IP处于锁定期时以 *core.TooManyAttemptsError 调用 UnauthorizedHandler
+// 默认返回 429 too_many_attempts；登录名维度的锁定与失败计数由 LoginWithPassword 处理
+// 客户端IP写入上下文 ContextKeyClientIP，登录处理器可直接用于 PasswordLoginRequest.IP
+func (m *BaseAuthMiddleware) LimitLoginAttempts() MiddlewareFunc {
+	return func(c WebContext) {
+		ip := m.clientIP(c)
+
+		if checker, ok := m.gsToken.(LoginAttemptChecker); ok {
+			if err := checker.CheckLoginAttempts(c.GetContext(), "", ip); err != nil {
+				m.config.UnauthorizedHandler(c, err)
+				return
+			}
+		}
+
+		c.Set(ContextKeyClientIP, ip)
+		c.Next()
+	}
+}
@@ -5,6 +5,8 @@ const (
 	ContextKeyUserID   = "user_id"
 	ContextKeyToken    = "token"
 	ContextKeyUserInfo = "user_info"
+	ContextKeyClientIP = "client_ip"
+	ContextKeyAPIKeyID = "api_key_id"
 )
 
 // HTTP头常量
@@ -12,6 +14,8 @@ const (
 	HeaderAuthorization = "Authorization"
 	HeaderXToken        = "X-Token"
 	BearerPrefix        = "Bearer "
+	HeaderRetryAfter    = "Retry-After"
+	HeaderXAPIKey       = "X-API-Key"
 )
 
 // 查询参数常量
@@ -21,7 +25,10 @@ const (
 
 // 错误响应常量
 const (
-	ErrorUnauthorized = "unauthorized"
-	ErrorForbidden    = "forbidden"
-	ErrorMessage      = "message"
+	ErrorUnauthorized    = "unauthorized"
+	ErrorForbidden       = "forbidden"
+	ErrorUserDisabled    = "user_disabled"
+	ErrorSafeRequired    = "safe_auth_required"
+	ErrorTooManyAttempts = "too_many_attempts"
+	ErrorMessage         = "message"
 )
@@ -207,3 +207,35 @@ func (m *GinAuthMiddleware) RequireRoleOrPermission(roles []string, perms []stri
 		middlewareFunc(NewGinContext(c))
 	}
 }
+
+// RequireNotDisabled 要求账号未在指定服务上被封禁的 Gin 中间件
+func (m *GinAuthMiddleware) RequireNotDisabled(service string) gin.HandlerFunc {
+	middlewareFunc := m.BaseAuthMiddleware.RequireNotDisabled(service)
+	return func(c *gin.Context) {
+		middlewareFunc(NewGinContext(c))
+	}
+}
+
+// RequireSafe 要求Token处于指定服务二级认证窗口内的 Gin 中间件
+func (m *GinAuthMiddleware) RequireSafe(service string) gin.HandlerFunc {
+	middlewareFunc := m.BaseAuthMiddleware.RequireSafe(service)
+	return func(c *gin.Context) {
+		middlewareFunc(NewGinContext(c))
+	}
+}
+
+// RequireAPIKey 仅接受 API Key 认证的 Gin 中间件
+func (m *GinAuthMiddleware) RequireAPIKey() gin.HandlerFunc {
+	middlewareFunc := m.BaseAuthMiddleware.RequireAPIKey()
+	return func(c *gin.Context) {
+		middlewareFunc(NewGinContext(c))
+	}
+}
+
+// LimitLoginAttempts 登录路由使用的 Gin 中间件，客户端IP因登录失败过多被锁定时拒绝请求
+func (m *GinAuthMiddleware) LimitLoginAttempts() gin.HandlerFunc {
+	middlewareFunc := m.BaseAuthMiddleware.LimitLoginAttempts()
+	return func(c *gin.Context) {
+		middlewareFunc(NewGinContext(c))
+	}
+}
@@ -38,6 +38,54 @@ func (a *GSTokenWebAdapter) Verify(ctx context.Context, token string) (*core.Use
 	return a.gsToken.GetAuthEngine().Verify(ctx, token)
 }
 
+// ValidateTokenFormat 本地校验Token格式（认证引擎支持时）
+func (a *GSTokenWebAdapter) ValidateTokenFormat(token string) error {
+	if validator, ok := a.gsToken.GetAuthEngine().(TokenFormatValidator); ok {
+		return validator.ValidateTokenFormat(token)
+	}
+	return nil
+}
+
+// CheckDisabled 检查账号在指定服务上是否被封禁，认证引擎不支持时返回 core.ErrDisableCheckNotSupported
+func (a *GSTokenWebAdapter) CheckDisabled(ctx context.Context, userID, service string) error {
+	if checker, ok := a.gsToken.GetAuthEngine().(DisableChecker); ok {
+		return checker.CheckDisabled(ctx, userID, service)
+	}
+	return core.ErrDisableCheckNotSupported
+}
+
+// IsSafe 检查Token是否处于二级认证窗口内（认证引擎支持时）
+func (a *GSTokenWebAdapter) IsSafe(ctx context.Context, token, service string) (bool, error) {
+	if checker, ok := a.gsToken.GetAuthEngine().(SafeChecker); ok {
+		return checker.IsSafe(ctx, token, service)
+	}
+	return false, nil
+}
+
+// CheckLoginAttempts 检查登录名或IP是否因登录失败过多被锁定（认证引擎支持时）
+func (a *GSTokenWebAdapter) CheckLoginAttempts(ctx context.Context, username, ip string) error {
+	if checker, ok := a.gsToken.GetAuthEngine().(LoginAttemptChecker); ok {
+		return checker.CheckLoginAttempts(ctx, username, ip)
+	}
+	return nil
+}
+
+// VerifyAPIKey 校验 API Key（GSToken 支持时）
+func (a *GSTokenWebAdapter) VerifyAPIKey(ctx context.Context, key string) (*core.UserInfo, error) {
+	if verifier, ok := a.gsToken.(APIKeyVerifier); ok {
+		return verifier.VerifyAPIKey(ctx, key)
+	}
+	return nil, core.ErrAPIKeyInvalid
+}
+
+// VerifyAccessToken 校验 OAuth2 访问Token（GSToken 支持时）
+func (a *GSTokenWebAdapter) VerifyAccessToken(ctx context.Context, token string) (*core.UserInfo, error) {
+	if verifier, ok := a.gsToken.(AccessTokenVerifier); ok {
+		return verifier.VerifyAccessToken(ctx, token)
+	}
+	return nil, core.ErrOAuthTokenInvalid
+}
+
 // CheckPermission 检查权限
 func (a *GSTokenWebAdapter) CheckPermission(ctx context.Context, userID, permission string) (bool, error) {
 	return a.gsToken.CheckPermission(ctx, userID, permission)